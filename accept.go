@@ -0,0 +1,142 @@
+package leego
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type (
+	// AcceptSpec is a media range parsed from an `Accept` header.
+	AcceptSpec struct {
+		// Type is the media type, e.g. `text`, or `*` for a wildcard.
+		Type string
+
+		// Subtype is the media subtype, e.g. `html`, or `*` for a wildcard.
+		Subtype string
+
+		// Quality is the relative quality factor `q`, between 0 and 1.
+		Quality float64
+
+		// Params holds the media type parameters other than `q`.
+		Params map[string]string
+	}
+)
+
+// MediaType returns the media range as `type/subtype`.
+func (a AcceptSpec) MediaType() string {
+	return a.Type + "/" + a.Subtype
+}
+
+// Match returns true if the media range accepts the provided media type, which
+// may carry parameters, e.g. `application/json; charset=utf-8`.
+func (a AcceptSpec) Match(mediaType string) bool {
+	if i := strings.IndexByte(mediaType, ';'); i != -1 {
+		mediaType = mediaType[:i]
+	}
+	t, st, ok := splitMediaType(mediaType)
+	if !ok {
+		return false
+	}
+	return (a.Type == "*" || a.Type == t) && (a.Subtype == "*" || a.Subtype == st)
+}
+
+// specificity ranks `type/subtype` over `type/*` over `*/*`.
+func (a AcceptSpec) specificity() int {
+	switch {
+	case a.Type == "*":
+		return 0
+	case a.Subtype == "*":
+		return 1
+	case len(a.Params) > 0:
+		return 3
+	}
+	return 2
+}
+
+// ParseAccept parses an `Accept` header into media ranges sorted by quality,
+// most preferred first. Ranges with equal quality are ordered by specificity and
+// then by their position in the header. Malformed entries are skipped.
+func ParseAccept(header string) []AcceptSpec {
+	specs := []AcceptSpec{}
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		t, st, ok := splitMediaType(strings.TrimSpace(fields[0]))
+		if !ok {
+			continue
+		}
+		spec := AcceptSpec{Type: t, Subtype: st, Quality: 1}
+		for _, f := range fields[1:] {
+			kv := strings.SplitN(f, "=", 2)
+			if len(kv) != 2 {
+				continue
+			}
+			k := strings.ToLower(strings.TrimSpace(kv[0]))
+			v := strings.Trim(strings.TrimSpace(kv[1]), `"`)
+			if k == "q" {
+				q, err := strconv.ParseFloat(v, 64)
+				if err != nil || q < 0 || q > 1 {
+					ok = false
+					break
+				}
+				spec.Quality = q
+				continue
+			}
+			if spec.Params == nil {
+				spec.Params = make(map[string]string)
+			}
+			spec.Params[k] = v
+		}
+		if ok {
+			specs = append(specs, spec)
+		}
+	}
+	sort.SliceStable(specs, func(i, j int) bool {
+		if specs[i].Quality != specs[j].Quality {
+			return specs[i].Quality > specs[j].Quality
+		}
+		return specs[i].specificity() > specs[j].specificity()
+	})
+	return specs
+}
+
+func splitMediaType(mediaType string) (t, st string, ok bool) {
+	i := strings.IndexByte(mediaType, '/')
+	if i <= 0 || i == len(mediaType)-1 {
+		return
+	}
+	t = strings.ToLower(strings.TrimSpace(mediaType[:i]))
+	st = strings.ToLower(strings.TrimSpace(mediaType[i+1:]))
+	if t == "" || st == "" || (t == "*" && st != "*") {
+		return
+	}
+	return t, st, true
+}
+
+// AcceptsEncoding returns true if an `Accept-Encoding` header accepts the content
+// coding, either explicitly or through `*`, with a non-zero quality.
+func AcceptsEncoding(header, coding string) bool {
+	accepted := false
+	for _, part := range strings.Split(header, ",") {
+		fields := strings.Split(part, ";")
+		c := strings.TrimSpace(fields[0])
+		if c != "*" && !strings.EqualFold(c, coding) {
+			continue
+		}
+		q := 1.0
+		for _, f := range fields[1:] {
+			kv := strings.SplitN(f, "=", 2)
+			if len(kv) == 2 && strings.ToLower(strings.TrimSpace(kv[0])) == "q" {
+				if v, err := strconv.ParseFloat(strings.TrimSpace(kv[1]), 64); err == nil {
+					q = v
+				}
+			}
+		}
+		if c != "*" {
+			// An explicit coding overrides the wildcard
+			return q > 0
+		}
+		accepted = q > 0
+	}
+	return accepted
+}
@@ -0,0 +1,49 @@
+package leego
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAccept(t *testing.T) {
+	specs := ParseAccept("text/*;q=0.3, text/html;q=0.7, text/html;level=1, text/html;level=2;q=0.4, */*;q=0.5")
+	types := []string{}
+	for _, s := range specs {
+		types = append(types, s.MediaType())
+	}
+	assert.Equal(t, []string{"text/html", "text/html", "*/*", "text/html", "text/*"}, types)
+	assert.Equal(t, "1", specs[0].Params["level"])
+	assert.Equal(t, 0.7, specs[1].Quality)
+	assert.Equal(t, "2", specs[3].Params["level"])
+
+	// Specificity breaks ties in quality
+	specs = ParseAccept("*/*, application/*, application/json")
+	assert.Equal(t, "application/json", specs[0].MediaType())
+	assert.Equal(t, "application/*", specs[1].MediaType())
+	assert.Equal(t, "*/*", specs[2].MediaType())
+	assert.True(t, specs[1].Match(MIMEApplicationJSONCharsetUTF8))
+	assert.False(t, specs[1].Match(MIMETextHTML))
+
+	// Malformed entries are skipped
+	specs = ParseAccept("json, */html, text/plain;q=abc, , application/xml;q=0.9")
+	if assert.Len(t, specs, 1) {
+		assert.Equal(t, MIMEApplicationXML, specs[0].MediaType())
+		assert.Equal(t, 0.9, specs[0].Quality)
+	}
+
+	assert.Empty(t, ParseAccept(""))
+}
+
+func TestAcceptsEncoding(t *testing.T) {
+	assert.True(t, AcceptsEncoding("gzip, deflate", "gzip"))
+	assert.True(t, AcceptsEncoding("deflate, GZIP;q=0.5", "gzip"))
+	assert.False(t, AcceptsEncoding("gzip;q=0", "gzip"))
+	assert.False(t, AcceptsEncoding("deflate", "gzip"))
+	assert.False(t, AcceptsEncoding("", "gzip"))
+
+	// Explicit codings override the wildcard
+	assert.True(t, AcceptsEncoding("*", "br"))
+	assert.False(t, AcceptsEncoding("*, br;q=0", "br"))
+	assert.False(t, AcceptsEncoding("*;q=0", "br"))
+}
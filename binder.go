@@ -1,6 +1,8 @@
 package leego
 
 import (
+	"bufio"
+	"encoding"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
@@ -9,6 +11,8 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+
+	"github.com/go-wyvern/leego/engine"
 )
 
 type (
@@ -17,13 +21,53 @@ type (
 		Bind(interface{}, Context) error
 	}
 
-	binder struct{}
+	// ParamUnmarshaler is the interface implemented by types which decode
+	// themselves from a query or form value, e.g. to validate enums. An error
+	// fails the binding with a 400 carrying its message.
+	ParamUnmarshaler interface {
+		UnmarshalParam(string) error
+	}
+
+	binder struct {
+		sniffContentType bool
+		defaultsOnZero   bool
+	}
+)
+
+var (
+	paramUnmarshalerType = reflect.TypeOf((*ParamUnmarshaler)(nil)).Elem()
+	textUnmarshalerType  = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
 )
 
+// SetSniffContentType sets whether a request body without `Content-Type` is
+// bound as JSON or XML based on its first non-whitespace byte, instead of
+// failing with `ErrUnsupportedMediaType`. It's disabled by default.
+func (b *binder) SetSniffContentType(on bool) {
+	b.sniffContentType = on
+}
+
+// SetDefaultsOnZero sets whether the `default` tag values also apply to the
+// fields bound to their zero value, e.g. from `?limit=` or `"limit": 0`, not
+// only to those absent from the input. It's disabled by default.
+func (b *binder) SetDefaultsOnZero(on bool) {
+	b.defaultsOnZero = on
+}
+
 func (b *binder) Bind(i interface{}, c Context) (err error) {
+	// The defaults are set first, so the input overrides them
+	if err = setDefaults(i); err != nil {
+		return
+	}
+	if err = b.bind(i, c); err != nil || !b.defaultsOnZero {
+		return
+	}
+	return setDefaults(i)
+}
+
+func (b *binder) bind(i interface{}, c Context) (err error) {
 	req := c.Request()
 	if req.Method() == GET {
-		if err = b.bindData(i, c.QueryParams()); err != nil {
+		if err = b.bindData(i, c.QueryParams(), "form"); err != nil {
 			err = NewHTTPError(http.StatusBadRequest, err.Error())
 		}
 		return
@@ -33,15 +77,18 @@ func (b *binder) Bind(i interface{}, c Context) (err error) {
 		err = NewHTTPError(http.StatusBadRequest, "request body can't be empty")
 		return
 	}
+	if ctype == "" && b.sniffContentType {
+		ctype = sniffContentType(req)
+	}
 	err = ErrUnsupportedMediaType
 	switch {
 	case strings.HasPrefix(ctype, MIMEApplicationJSON):
 		if err = json.NewDecoder(req.Body()).Decode(i); err != nil {
 			if ute, ok := err.(*json.UnmarshalTypeError); ok {
-				err = NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unmarshal type error: expected=%v, got=%v, offset=%v", ute.Type, ute.Value, ute.Offset))
+				err = NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unmarshal type error: field=%v, expected=%v, got=%v, offset=%v", ute.Field, ute.Type, ute.Value, ute.Offset))
 			} else if se, ok := err.(*json.SyntaxError); ok {
 				err = NewHTTPError(http.StatusBadRequest, fmt.Sprintf("syntax error: offset=%v, error=%v", se.Offset, se.Error()))
-			} else {
+			} else if _, ok := err.(*HTTPError); !ok {
 				err = NewHTTPError(http.StatusBadRequest, err.Error())
 			}
 		}
@@ -51,19 +98,44 @@ func (b *binder) Bind(i interface{}, c Context) (err error) {
 				err = NewHTTPError(http.StatusBadRequest, fmt.Sprintf("unsupported type error: type=%v, error=%v", ute.Type, ute.Error()))
 			} else if se, ok := err.(*xml.SyntaxError); ok {
 				err = NewHTTPError(http.StatusBadRequest, fmt.Sprintf("syntax error: line=%v, error=%v", se.Line, se.Error()))
-			} else {
+			} else if _, ok := err.(*HTTPError); !ok {
 				err = NewHTTPError(http.StatusBadRequest, err.Error())
 			}
 		}
 	case strings.HasPrefix(ctype, MIMEApplicationForm), strings.HasPrefix(ctype, MIMEMultipartForm):
-		if err = b.bindData(i, req.FormParams()); err != nil {
+		if err = b.bindData(i, c.FormParams(), "form"); err != nil {
 			err = NewHTTPError(http.StatusBadRequest, err.Error())
 		}
 	}
 	return
 }
 
-func (b *binder) bindData(ptr interface{}, data map[string][]string) error {
+// sniffContentType guesses the content type of the request body from its first
+// non-whitespace byte. The body is only peeked at, so decoding reads it in full.
+func sniffContentType(req engine.Request) string {
+	br := bufio.NewReader(req.Body())
+	req.SetBody(br)
+	for n := 1; ; n++ {
+		// Fails at the end of the body or when the buffer is full
+		p, _ := br.Peek(n)
+		if len(p) < n {
+			return ""
+		}
+		switch p[n-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{', '[':
+			return MIMEApplicationJSON
+		case '<':
+			return MIMEApplicationXML
+		}
+		return ""
+	}
+}
+
+// bindData binds the input values into the fields of the struct ptr points to,
+// named by the tag, which may carry a `required` option, e.g. `form:"id,required"`.
+func (b *binder) bindData(ptr interface{}, data map[string][]string, tag string) error {
 	typ := reflect.TypeOf(ptr).Elem()
 	val := reflect.ValueOf(ptr).Elem()
 
@@ -78,21 +150,34 @@ func (b *binder) bindData(ptr interface{}, data map[string][]string) error {
 			continue
 		}
 		structFieldKind := structField.Kind()
-		inputFieldName := typeField.Tag.Get("form")
+		inputFieldName, opts := typeField.Tag.Get(tag), ""
+		if j := strings.IndexByte(inputFieldName, ','); j != -1 {
+			inputFieldName, opts = inputFieldName[:j], inputFieldName[j+1:]
+		}
 
 		if inputFieldName == "" {
 			inputFieldName = typeField.Name
-			// If "form" tag is nil, we inspect if the field is a struct.
-			if structFieldKind == reflect.Struct {
-				err := b.bindData(structField.Addr().Interface(), data)
+			// If the tag is nil, we inspect if the field is a struct.
+			if structFieldKind == reflect.Struct && !isUnmarshaler(structField.Type()) {
+				err := b.bindData(structField.Addr().Interface(), data, tag)
 				if err != nil {
 					return err
 				}
 				continue
 			}
 		}
+		if structFieldKind == reflect.Slice && structField.Type().Elem().Kind() == reflect.Struct &&
+			!isUnmarshaler(structField.Type().Elem()) {
+			if err := b.bindStructSlice(inputFieldName, structField, data, tag); err != nil {
+				return err
+			}
+			continue
+		}
 		inputValue, exists := data[inputFieldName]
 		if !exists {
+			if opts == "required" {
+				return fmt.Errorf("%s: required", inputFieldName)
+			}
 			continue
 		}
 
@@ -101,20 +186,135 @@ func (b *binder) bindData(ptr interface{}, data map[string][]string) error {
 			sliceOf := structField.Type().Elem().Kind()
 			slice := reflect.MakeSlice(structField.Type(), numElems, numElems)
 			for i := 0; i < numElems; i++ {
-				if err := setWithProperType(sliceOf, inputValue[i], slice.Index(i)); err != nil {
-					return err
+				if err := setField(sliceOf, inputValue[i], slice.Index(i)); err != nil {
+					return bindError(inputFieldName, sliceOf, inputValue[i], err)
 				}
 			}
 			val.Field(i).Set(slice)
 		} else {
-			if err := setWithProperType(typeField.Type.Kind(), inputValue[0], structField); err != nil {
-				return err
+			if err := setField(typeField.Type.Kind(), inputValue[0], structField); err != nil {
+				return bindError(inputFieldName, typeField.Type.Kind(), inputValue[0], err)
+			}
+		}
+	}
+	return nil
+}
+
+// bindStructSlice binds the bracketed-index inputs of a slice of structs, e.g.
+// `items[0].name=x&items[1].name=y`, each element from its own set of inputs.
+// Indices must be contiguous from 0.
+func (b *binder) bindStructSlice(name string, field reflect.Value, data map[string][]string, tag string) error {
+	prefix := name + "["
+	elems := make(map[int]map[string][]string)
+	for k, v := range data {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		end := strings.IndexByte(k, ']')
+		if end < 0 {
+			return fmt.Errorf("%s: missing closing bracket", k)
+		}
+		idx, err := strconv.Atoi(k[len(prefix):end])
+		if err != nil || idx < 0 {
+			return fmt.Errorf("%s: invalid index", k)
+		}
+		if elems[idx] == nil {
+			elems[idx] = make(map[string][]string)
+		}
+		elems[idx][strings.TrimPrefix(k[end+1:], ".")] = v
+	}
+	if len(elems) == 0 {
+		return nil
+	}
+
+	slice := reflect.MakeSlice(field.Type(), len(elems), len(elems))
+	for i := 0; i < len(elems); i++ {
+		sub, ok := elems[i]
+		if !ok {
+			return fmt.Errorf("%s: missing index %d", name, i)
+		}
+		if err := b.bindData(slice.Index(i).Addr().Interface(), sub, tag); err != nil {
+			return fmt.Errorf("%s[%d].%v", name, i, err)
+		}
+	}
+	field.Set(slice)
+	return nil
+}
+
+// setDefaults sets the zero fields of the struct ptr points to, if any, to the
+// value of their `default` tag, e.g. `default:"20"`, converted as input values
+// are. The values of slices are comma separated. Nested structs are walked.
+func setDefaults(ptr interface{}) error {
+	val := reflect.ValueOf(ptr)
+	if val.Kind() != reflect.Ptr || val.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	return setStructDefaults(val.Elem())
+}
+
+func setStructDefaults(val reflect.Value) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		typeField := typ.Field(i)
+		field := val.Field(i)
+		if !field.CanSet() {
+			continue
+		}
+		def, ok := typeField.Tag.Lookup("default")
+		if !ok {
+			if field.Kind() == reflect.Struct && !isUnmarshaler(field.Type()) {
+				if err := setStructDefaults(field); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if !field.IsZero() {
+			continue
+		}
+		if field.Kind() == reflect.Slice {
+			vals := strings.Split(def, ",")
+			slice := reflect.MakeSlice(field.Type(), len(vals), len(vals))
+			for j, v := range vals {
+				if err := setField(field.Type().Elem().Kind(), v, slice.Index(j)); err != nil {
+					return fmt.Errorf("%s: invalid default %q: %v", typeField.Name, def, err)
+				}
 			}
+			field.Set(slice)
+		} else if err := setField(field.Kind(), def, field); err != nil {
+			return fmt.Errorf("%s: invalid default %q: %v", typeField.Name, def, err)
 		}
 	}
 	return nil
 }
 
+// bindError describes the failure to bind the input value of a field.
+func bindError(name string, kind reflect.Kind, value string, err error) error {
+	if _, ok := err.(*strconv.NumError); ok {
+		return fmt.Errorf("%s: cannot bind %q as %v", name, value, kind)
+	}
+	return fmt.Errorf("%s: %v", name, err)
+}
+
+// isUnmarshaler returns true if the values of type t decode themselves with
+// `ParamUnmarshaler` or `encoding.TextUnmarshaler`.
+func isUnmarshaler(t reflect.Type) bool {
+	pt := reflect.PtrTo(t)
+	return pt.Implements(paramUnmarshalerType) || pt.Implements(textUnmarshalerType)
+}
+
+// setField sets an addressable field from its input value, decoded by the field
+// itself if it implements `ParamUnmarshaler` or `encoding.TextUnmarshaler`.
+func setField(valueKind reflect.Kind, val string, field reflect.Value) error {
+	switch u := field.Addr().Interface().(type) {
+	case ParamUnmarshaler:
+		return u.UnmarshalParam(val)
+	case encoding.TextUnmarshaler:
+		return u.UnmarshalText([]byte(val))
+	}
+	return setWithProperType(valueKind, val, field)
+}
+
 func setWithProperType(valueKind reflect.Kind, val string, structField reflect.Value) error {
 	switch valueKind {
 	case reflect.Int:
@@ -0,0 +1,210 @@
+package leego
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-wyvern/leego/engine"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBinderSniffContentType(t *testing.T) {
+	e := New()
+	bind := func(body string) (*user, error) {
+		req := test.NewRequest(POST, "/", strings.NewReader(body))
+		c := e.NewContext(req, test.NewResponseRecorder())
+		u := new(user)
+		return u, c.Bind(u)
+	}
+
+	// Strict by default
+	_, err := bind(userJSON)
+	assert.Equal(t, ErrUnsupportedMediaType, err)
+
+	e.SetSniffContentType(true)
+	u, err := bind("\n  " + userJSON)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, u.ID)
+		assert.Equal(t, "Jon Snow", u.Name)
+	}
+
+	u, err = bind(`<user><ID>1</ID><Name>Jon Snow</Name></user>`)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, u.ID)
+		assert.Equal(t, "Jon Snow", u.Name)
+	}
+
+	_, err = bind("id=1")
+	assert.Equal(t, ErrUnsupportedMediaType, err)
+
+	// Malformed bodies are still rejected by the decoder
+	_, err = bind(`{"id":`)
+	if he, ok := err.(*HTTPError); assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+	}
+}
+
+func TestBinderBindDefaults(t *testing.T) {
+	type page struct {
+		Limit  int      `form:"limit" json:"limit" default:"20"`
+		Offset int      `form:"offset" json:"offset" default:"5"`
+		Sort   string   `form:"sort" json:"sort" default:"name"`
+		Fields []string `form:"fields" json:"fields" default:"id,name"`
+		Filter struct {
+			Active bool `form:"active" json:"active" default:"true"`
+		} `json:"filter"`
+	}
+	e := New()
+	bind := func(req engine.Request) (*page, error) {
+		c := e.NewContext(req, test.NewResponseRecorder())
+		p := new(page)
+		return p, c.Bind(p)
+	}
+
+	// Query
+	p, err := bind(test.NewRequest(GET, "/?limit=50&offset=", nil))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 50, p.Limit)
+		assert.Equal(t, 0, p.Offset)
+		assert.Equal(t, "name", p.Sort)
+		assert.Equal(t, []string{"id", "name"}, p.Fields)
+		assert.True(t, p.Filter.Active)
+	}
+
+	// JSON
+	req := test.NewRequest(POST, "/", strings.NewReader(`{"limit":50,"offset":0,"fields":["id"]}`))
+	req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	p, err = bind(req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 50, p.Limit)
+		assert.Equal(t, 0, p.Offset)
+		assert.Equal(t, "name", p.Sort)
+		assert.Equal(t, []string{"id"}, p.Fields)
+		assert.True(t, p.Filter.Active)
+	}
+
+	// Zero values too
+	e.SetDefaultsOnZero(true)
+	p, err = bind(test.NewRequest(GET, "/?limit=50&offset=", nil))
+	if assert.NoError(t, err) {
+		assert.Equal(t, 50, p.Limit)
+		assert.Equal(t, 5, p.Offset)
+	}
+	req = test.NewRequest(POST, "/", strings.NewReader(`{"offset":0}`))
+	req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	p, err = bind(req)
+	if assert.NoError(t, err) {
+		assert.Equal(t, 5, p.Offset)
+	}
+
+	// Invalid default
+	type invalid struct {
+		Limit int `form:"limit" default:"many"`
+	}
+	c := e.NewContext(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.Error(t, c.Bind(new(invalid)))
+}
+
+func TestBinderBindSlices(t *testing.T) {
+	type item struct {
+		Name string `form:"name"`
+		Qty  int    `form:"qty"`
+	}
+	type order struct {
+		Tags  []string `form:"tags"`
+		IDs   []int    `form:"ids"`
+		Items []item   `form:"items"`
+	}
+	e := New()
+	bind := func(form string) (*order, error) {
+		req := test.NewRequest(POST, "/", strings.NewReader(form))
+		req.Header().Set(HeaderContentType, MIMEApplicationForm)
+		c := e.NewContext(req, test.NewResponseRecorder())
+		o := new(order)
+		return o, c.Bind(o)
+	}
+
+	// Repeated fields
+	o, err := bind("tags=a&tags=b&tags=c&ids=1&ids=2")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []string{"a", "b", "c"}, o.Tags)
+		assert.Equal(t, []int{1, 2}, o.IDs)
+		assert.Nil(t, o.Items)
+	}
+
+	_, err = bind("ids=1&ids=x")
+	if he, ok := err.(*HTTPError); assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+		assert.Equal(t, `ids: cannot bind "x" as int`, he.Message)
+	}
+
+	// Bracketed indices
+	o, err = bind("items[1].name=y&items[0].name=x&items[0].qty=2")
+	if assert.NoError(t, err) {
+		assert.Equal(t, []item{{Name: "x", Qty: 2}, {Name: "y"}}, o.Items)
+	}
+
+	_, err = bind("items[0].name=x&items[2].name=z")
+	if he, ok := err.(*HTTPError); assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+		assert.Equal(t, "items: missing index 1", he.Message)
+	}
+
+	_, err = bind("items[0].name=x&items[1].qty=many")
+	if he, ok := err.(*HTTPError); assert.True(t, ok) {
+		assert.Equal(t, `items[1].qty: cannot bind "many" as int`, he.Message)
+	}
+
+	_, err = bind("items[x].name=x")
+	if he, ok := err.(*HTTPError); assert.True(t, ok) {
+		assert.Equal(t, "items[x].name: invalid index", he.Message)
+	}
+}
+
+type status string
+
+func (s *status) UnmarshalParam(v string) error {
+	switch v {
+	case "active", "inactive":
+		*s = status(v)
+		return nil
+	}
+	return fmt.Errorf("invalid value %q, valid values: active, inactive", v)
+}
+
+func TestBinderBindParamUnmarshaler(t *testing.T) {
+	type filter struct {
+		Status  status    `form:"status"`
+		Exclude []status  `form:"exclude"`
+		Since   time.Time `form:"since"`
+	}
+	e := New()
+	bind := func(query string) (*filter, error) {
+		req := test.NewRequest(GET, "/?"+query, nil)
+		c := e.NewContext(req, test.NewResponseRecorder())
+		f := new(filter)
+		return f, c.Bind(f)
+	}
+
+	f, err := bind("status=active&exclude=inactive&since=2016-05-01T00:00:00Z")
+	if assert.NoError(t, err) {
+		assert.Equal(t, status("active"), f.Status)
+		assert.Equal(t, []status{"inactive"}, f.Exclude)
+		assert.Equal(t, time.Date(2016, 5, 1, 0, 0, 0, 0, time.UTC), f.Since)
+	}
+
+	_, err = bind("status=deleted")
+	if he, ok := err.(*HTTPError); assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+		assert.Equal(t, `status: invalid value "deleted", valid values: active, inactive`, he.Message)
+	}
+
+	_, err = bind("exclude=active&exclude=archived")
+	if he, ok := err.(*HTTPError); assert.True(t, ok) {
+		assert.Equal(t, `exclude: invalid value "archived", valid values: active, inactive`, he.Message)
+	}
+}
@@ -1,14 +1,24 @@
 package leego
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"mime"
 	"mime/multipart"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-wyvern/leego/engine"
@@ -27,6 +37,11 @@ type (
 		// SetContext sets `net/context.Context`.
 		SetContext(context.Context)
 
+		// SetTimeout sets a deadline of d from now on the remainder of the request,
+		// so downstream operations using `Context()` are canceled once it expires.
+		// A deadline set earlier, e.g. by a timeout middleware, is only shortened.
+		SetTimeout(d time.Duration)
+
 		// Deadline returns the time when work done on behalf of this context
 		// should be canceled.  Deadline returns ok==false when no deadline is
 		// set.  Successive calls to Deadline return the same results.
@@ -54,9 +69,20 @@ type (
 		// Request returns `engine.Response` interface.
 		Response() engine.Response
 
-		// Path returns the registered path for the handler.
+		// RealIP returns the client IP: the `X-Real-IP` header, or the first
+		// address of the `X-Forwarded-For` one, if it's a valid IP, otherwise the
+		// remote address of the request.
+		RealIP() string
+
+		// Path returns the registered path for the handler, i.e. the matched route
+		// pattern such as `/users/:id` rather than the concrete request path.
 		Path() string
 
+		// RouteMeta returns the metadata of the route matched by the router, which
+		// lets middleware act on tags set with `Route#SetMeta()`. It returns nil if
+		// no route matched or the route has no metadata.
+		RouteMeta() map[string]interface{}
+
 		// SetPath sets the registered path for the handler.
 		SetPath(string)
 
@@ -78,13 +104,20 @@ type (
 		// SetParamValues sets path parameter values.
 		SetParamValues(...string)
 
-		// QueryParam returns the query param for the provided name. It is an alias
-		// for `engine.URL#QueryParam()`.
+		// Params returns a copy of the path parameters as a map of names to
+		// values.
+		Params() map[string]string
+
+		// QueryParam returns the first value of the query param for the provided
+		// name, or an empty string if it's missing.
 		QueryParam(string) string
 
-		// QueryParams returns the query parameters as map.
-		// It is an alias for `engine.URL#QueryParams()`.
-		QueryParams() map[string][]string
+		// QueryParams returns the query parameters, which are parsed once per
+		// request.
+		QueryParams() url.Values
+
+		// QueryString returns the raw URL query string, without the leading `?`.
+		QueryString() string
 
 		// FormValue returns the form field value for the provided name. It is an
 		// alias for `engine.Request#FormValue()`.
@@ -114,19 +147,70 @@ type (
 		// It is an alias for `engine.Request#Cookies()`.
 		Cookies() []engine.Cookie
 
-		// Get retrieves data from the context.
-		Get(string) interface{}
-
-		// Set saves data in the context.
-		Set(string, interface{})
-
-		Bind binds the request body into provided type `i`. The default binder
-		does it based on Content-Type header.
+		// SetHeaders sets the response headers, replacing any existing values. It
+		// returns `ErrResponseCommitted` if the response has been committed.
+		SetHeaders(map[string]string) error
+
+		// AddHeader adds a response header value. It returns
+		// `ErrResponseCommitted` if the response has been committed.
+		AddHeader(string, string) error
+
+		// AppendVary adds field to the `Vary` response header unless it's already
+		// listed, so several middleware can contribute to it without overwriting
+		// each other.
+		AppendVary(field string)
+
+		// Get retrieves data saved in the context with `Set()`, or else the value
+		// for key of the request's `net/context.Context`, e.g. set with
+		// `WithValue()`.
+		Get(key string) interface{}
+
+		// Set saves data in the context for the rest of the request, e.g. to pass
+		// values from middleware to the handler.
+		Set(key string, val interface{})
+
+		// Keys returns the keys of the data saved with `Set()`, sorted, e.g. for
+		// debugging.
+		Keys() []string
+
+		// Once returns the result of fn, computed on the first call for key and
+		// cached for the rest of the request, e.g. so the middleware and handler
+		// needing the resolved user load it once. Later calls for key return the
+		// cached value and error without calling fn.
+		Once(key string, fn func() (interface{}, error)) (interface{}, error)
+
+		// Timing starts a timing span named name, e.g. "db", and returns the
+		// function stopping it. The spans stopped before the response is
+		// committed are written in the `Server-Timing` header, so browser
+		// devtools show them, the durations of the spans of a name summed.
+		Timing(name string) func()
+
+		// WithValue derives the request's `net/context.Context` with the value for
+		// key, like `context.WithValue()`. The context is shared by the whole chain,
+		// so the value is visible through `Value()` to the middleware and handler
+		// running after the caller, including those after the router when called
+		// from a pre-middleware.
+		WithValue(key, val interface{})
+
+		// BodyString returns the raw request body as a string. The body is restored
+		// afterwards, so it can still be read by `Bind()` or the handler.
+		BodyString() (string, error)
+
+		// Bind binds the request body into provided type `i`. The default binder
+		// does it based on Content-Type header, and sets the fields absent from
+		// the input to the value of their `default` tag, e.g. `default:"20"`.
 		Bind(interface{}) error
 
+		// BindCookies binds the request cookies into the fields of the struct
+		// `i` points to, named by their `cookie` tag. Missing cookies leave the
+		// fields untouched, unless tagged `required`, e.g. `cookie:"session,required"`.
+		BindCookies(interface{}) error
+
 		// Render renders a template with data and sends a text/html response with status
-		// code. Templates can be registered using `Echo.SetRenderer()`.
-		//Render(int, string, interface{}) error
+		// code. Templates can be registered using `Leego.SetRenderer()`. The output
+		// is buffered so a failing template doesn't produce a partial response,
+		// unless disabled with `Leego.SetRenderBuffering()`.
+		Render(int, string, interface{}) error
 
 		// HTML sends an HTTP response with status code.
 		HTML(int, string) error
@@ -150,22 +234,53 @@ type (
 		// XMLBlob sends a XML blob response with status code.
 		XMLBlob(int, []byte) error
 
-		// File sends a response with the content of the file.
+		// Negotiate sends a JSON or XML response with status code, picking the
+		// format preferred by the `Accept` header. If neither is acceptable it
+		// falls back to JSON, or returns `ErrNotAcceptable` in strict mode. See
+		// `Leego#SetStrictNegotiation()`.
+		Negotiate(int, interface{}) error
+
+		// File sends a response with the content of the file. A pre-compressed
+		// sibling of the file, e.g. `app.js.br` or `app.js.gz` for `app.js`, is
+		// sent instead with the matching `Content-Encoding` if the client accepts it.
 		File(string) error
 
 		// Attachment sends a response from `io.ReaderSeeker` as attachment, prompting
 		// client to save the file.
 		Attachment(io.ReadSeeker, string) error
 
+		// Copy copies from src to dst until either EOF is reached on src, an error
+		// occurs or the request context is done, which is checked between chunks.
+		// The chunk size can be set with `Leego#SetCopyBufferSize()`.
+		Copy(dst io.Writer, src io.Reader) (int64, error)
+
 		// NoContent sends a response with no body and a status code.
 		NoContent(int) error
 
-		// Redirect redirects the request with status code.
+		// Redirect redirects the request with status code. It returns
+		// `ErrResponseCommitted` if the response has been committed, as the
+		// `Location` header can no longer be set.
 		Redirect(int, string) error
 
+		// AbortWithStatus sends a response with no body and a status code, and
+		// returns `ErrAborted` so the rest of the chain is skipped.
+		AbortWithStatus(int) LeegoError
+
+		// AbortWithJSON sends a JSON response with status code, and returns
+		// `ErrAborted` so the rest of the chain is skipped.
+		AbortWithJSON(int, interface{}) LeegoError
+
 		// Error invokes the registered HTTP error handler. Generally used by middleware.
 		Error(err error)
 
+		// Recover recovers a panic of the handler, converted into an error by fn
+		// and handled like a returned one, see `Leego#ResponseHandler()`, so it
+		// isn't sent if the response is already committed. It must be deferred
+		// directly, e.g.
+		// `defer c.Recover(func(r interface{}) leego.LeegoError { ... })`. A nil
+		// error from fn means the panic was handled.
+		Recover(fn func(interface{}) LeegoError)
+
 		// Handler returns the matched handler by router.
 		Handler() HandlerFunc
 
@@ -182,27 +297,88 @@ type (
 		// Echo returns the `Echo` instance.
 		Leego() *Leego
 
+		// LastModified sets the `Last-Modified` response header and returns true if
+		// the request's `If-Modified-Since` is not older than t, in which case the
+		// handler can respond with 304. Times are compared with second precision.
+		LastModified(time.Time) bool
+
 		// ServeContent sends static content from `io.Reader` and handles caching
 		// via `If-Modified-Since` request header. It automatically sets `Content-Type`
-		// and `Last-Modified` response headers.
+		// and `Last-Modified` response headers. Range requests are handled as in
+		// `ServeReader()`.
 		ServeContent(io.ReadSeeker, string, time.Time) error
 
+		// ServeReader sends size bytes of content type from `io.ReadSeeker`, which
+		// needn't be a file, e.g. an object from remote storage. It handles caching
+		// like `ServeContent()` and single byte ranges of the `Range` request header,
+		// responding with 206 and only the requested part of the content.
+		ServeReader(io.ReadSeeker, string, int64, time.Time) error
+
 		// Reset resets the context after request completes. It must be called along
 		// with `Echo#AcquireContext()` and `Echo#ReleaseContext()`.
 		// See `Echo#ServeHTTP()`
+		// The previous request's `net/context.Context` is canceled, and a fresh one
+		// is derived for the new request.
 		Reset(engine.Request, engine.Response)
 
 		SetData(string, interface{})
 
 		GetData(string) interface{}
 
+		// RequestID returns the id of the request set by the RequestID middleware,
+		// or an empty string if it isn't used.
+		RequestID() string
+
 		Language() string
 
 		SetLang(string)
+
+		// Defer registers a function to be called once the response has been
+		// written, even if the handler panics. Deferred functions are called in
+		// last-in-first-out order.
+		Defer(func())
+
+		// SetStreaming marks the response as a stream. An error returned after a
+		// streamed response has been committed is logged and the connection is
+		// closed instead of invoking the HTTP error handler.
+		SetStreaming(bool)
+
+		// Streaming returns true if the response is marked as a stream.
+		Streaming() bool
+
+		// Hijack lets the handler take over the connection, e.g. for protocol
+		// upgrades. It returns `ErrHijackNotSupported` if the response doesn't
+		// support hijacking. Once hijacked, the framework doesn't write a response,
+		// even for an error returned by the handler.
+		Hijack() (net.Conn, *bufio.ReadWriter, error)
+
+		// Flush sends the response data written so far to the client, e.g. for
+		// long-polling or progress reporting. It returns `ErrFlushNotSupported` if
+		// the response doesn't implement `engine.Flusher`.
+		Flush() error
+
+		// MultipartResponse starts a streamed `multipart/mixed` response with
+		// status 200 and returns the writer of its parts, which are flushed to the
+		// client as they're written. The writer must be closed to terminate the
+		// response. It returns `ErrResponseCommitted` if the response has been
+		// committed.
+		MultipartResponse() (*multipart.Writer, error)
+	}
+
+	// flushWriter flushes the response after each write, if supported.
+	flushWriter struct {
+		res engine.Response
+	}
+
+	// requestContexter is implemented by an `engine.Request` which carries the
+	// `net/context.Context` of the underlying request, e.g. `standard.Request`.
+	requestContexter interface {
+		Context() context.Context
 	}
 
 	echoContext struct {
 		context   context.Context
+		cancel    context.CancelFunc
 		request   engine.Request
 		response  engine.Response
 		path      string
@@ -213,11 +389,55 @@ type (
 		leego     *Leego
 		lang      string
 		data      map[string]interface{}
+		streaming bool
+		deferred  []func()
+		multipart bool
+		query     url.Values
+		hijacked  bool
+		timings   []timing
+		timed     bool
+
+		// errorHandler is the error handler of the matched route's group.
+		errorHandler HTTPErrorHandler
+	}
+
+	// timing is the summed duration of the spans of a name recorded with
+	// `Context#Timing()`.
+	timing struct {
+		name string
+		dur  time.Duration
 	}
 )
 
 var _ Context = new(echoContext)
 
+// onceKeyPrefix prefixes the keys of the results cached by `Context#Once()` in
+// the context data, so they don't collide with the data saved with `Set()`.
+const onceKeyPrefix = "leego.once:"
+
+// onceResult is a result cached by `Context#Once()`.
+type onceResult struct {
+	val interface{}
+	err error
+}
+
+// RequestIDKey is the key of the request id in the context data, see
+// `Context#RequestID()`.
+const RequestIDKey = "request_id"
+
+// precompressed lists the encodings and file extensions of the pre-compressed
+// files looked for by `File()`, by order of preference.
+var precompressed = []struct{ encoding, ext string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
 func (c *echoContext) Language() string {
 	return c.lang
 }
@@ -231,6 +451,66 @@ func (c *echoContext) SetLang(lang string) {
 	c.lang = lang
 }
 
+func (c *echoContext) Defer(fn func()) {
+	c.deferred = append(c.deferred, fn)
+}
+
+// runDeferred calls the functions registered with `Defer()`.
+func (c *echoContext) runDeferred() {
+	for i := len(c.deferred) - 1; i >= 0; i-- {
+		c.deferred[i]()
+	}
+}
+
+func (c *echoContext) SetStreaming(s bool) {
+	c.streaming = s
+}
+
+func (c *echoContext) Streaming() bool {
+	return c.streaming
+}
+
+func (c *echoContext) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := c.response.(hijacker)
+	if !ok {
+		return nil, nil, ErrHijackNotSupported
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		c.hijacked = true
+	}
+	return conn, rw, err
+}
+
+func (c *echoContext) Flush() error {
+	f, ok := c.response.(engine.Flusher)
+	if !ok {
+		return ErrFlushNotSupported
+	}
+	return f.FlushError()
+}
+
+func (c *echoContext) MultipartResponse() (*multipart.Writer, error) {
+	if c.response.Committed() {
+		return nil, ErrResponseCommitted
+	}
+	w := multipart.NewWriter(flushWriter{c.response})
+	c.response.Header().Set(HeaderContentType, MIMEMultipartMixed+"; boundary="+w.Boundary())
+	c.response.WriteHeader(http.StatusOK)
+	c.streaming = true
+	return w, nil
+}
+
+func (w flushWriter) Write(b []byte) (n int, err error) {
+	if n, err = w.res.Write(b); err != nil {
+		return
+	}
+	if f, ok := w.res.(engine.Flusher); ok {
+		err = f.FlushError()
+	}
+	return
+}
+
 func (c *echoContext) SetParamsMap(m map[string]string) {
 	c.paramsMap = m
 }
@@ -244,13 +524,18 @@ func (c *echoContext) GetParamsMap() map[string]string {
 }
 
 func (c *echoContext) SetData(key string, data interface{}) {
-	c.data[key] = data
+	c.Set(key, data)
 }
 
 func (c *echoContext) GetData(key string) interface{} {
 	return c.data[key]
 }
 
+func (c *echoContext) RequestID() string {
+	id, _ := c.data[RequestIDKey].(string)
+	return id
+}
+
 func (c *echoContext) Context() context.Context {
 	return c.context
 }
@@ -259,6 +544,13 @@ func (c *echoContext) SetContext(ctx context.Context) {
 	c.context = ctx
 }
 
+func (c *echoContext) SetTimeout(d time.Duration) {
+	ctx, cancel := context.WithTimeout(c.context, d)
+	c.context = ctx
+	// Release the timer once the request completes
+	c.Defer(cancel)
+}
+
 func (c *echoContext) Deadline() (deadline time.Time, ok bool) {
 	return c.context.Deadline()
 }
@@ -283,10 +575,33 @@ func (c *echoContext) Response() engine.Response {
 	return c.response
 }
 
+func (c *echoContext) RealIP() string {
+	h := c.request.Header()
+	if ip := strings.TrimSpace(h.Get(HeaderXRealIP)); net.ParseIP(ip) != nil {
+		return ip
+	}
+	xff := h.Get(HeaderXForwardedFor)
+	if i := strings.IndexByte(xff, ','); i != -1 {
+		xff = xff[:i]
+	}
+	if ip := strings.TrimSpace(xff); net.ParseIP(ip) != nil {
+		return ip
+	}
+	ra := c.request.RemoteAddress()
+	if ip, _, err := net.SplitHostPort(ra); err == nil {
+		return ip
+	}
+	return ra
+}
+
 func (c *echoContext) Path() string {
 	return c.path
 }
 
+func (c *echoContext) RouteMeta() map[string]interface{} {
+	return c.leego.router.meta[c.request.Method()+c.path]
+}
+
 func (c *echoContext) SetPath(p string) {
 	c.path = p
 }
@@ -326,30 +641,80 @@ func (c *echoContext) SetParamValues(values ...string) {
 	c.pvalues = values
 }
 
+func (c *echoContext) Params() map[string]string {
+	params := make(map[string]string, len(c.pnames))
+	for i, n := range c.pnames {
+		if i < len(c.pvalues) {
+			params[n] = c.pvalues[i]
+		}
+	}
+	return params
+}
+
 func (c *echoContext) QueryParam(name string) string {
-	return c.request.URL().QueryParam(name)
+	return c.QueryParams().Get(name)
+}
+
+func (c *echoContext) QueryParams() url.Values {
+	if c.query == nil {
+		c.query = url.Values(c.request.URL().QueryParams())
+	}
+	return c.query
 }
 
-func (c *echoContext) QueryParams() map[string][]string {
-	return c.request.URL().QueryParams()
+func (c *echoContext) QueryString() string {
+	return c.request.URL().QueryString()
 }
 
 func (c *echoContext) FormValue(name string) string {
+	c.parseMultipartForm()
 	return c.request.FormValue(name)
 }
 
 func (c *echoContext) FormParams() map[string][]string {
+	c.parseMultipartForm()
 	return c.request.FormParams()
 }
 
 func (c *echoContext) FormFile(name string) (*multipart.FileHeader, error) {
+	if err := c.parseMultipart(); err != nil {
+		return nil, err
+	}
 	return c.request.FormFile(name)
 }
 
 func (c *echoContext) MultipartForm() (*multipart.Form, error) {
+	if err := c.parseMultipart(); err != nil {
+		return nil, err
+	}
 	return c.request.MultipartForm()
 }
 
+// parseMultipart parses a multipart request body with the configured multipart
+// memory, before the engine parses it with its own default. The temporary files
+// of the form are removed once the response has been written.
+func (c *echoContext) parseMultipart() error {
+	if err := c.request.ParseMultipartForm(c.leego.multipartMemory); err != nil {
+		return err
+	}
+	if !c.multipart {
+		c.multipart = true
+		if form, err := c.request.MultipartForm(); err == nil && form != nil {
+			c.Defer(func() {
+				form.RemoveAll()
+			})
+		}
+	}
+	return nil
+}
+
+// parseMultipartForm parses the request body if it's a multipart form.
+func (c *echoContext) parseMultipartForm() {
+	if strings.HasPrefix(c.request.Header().Get(HeaderContentType), MIMEMultipartForm) {
+		c.parseMultipart()
+	}
+}
+
 func (c *echoContext) Cookie(name string) (engine.Cookie, error) {
 	return c.request.Cookie(name)
 }
@@ -362,31 +727,159 @@ func (c *echoContext) Cookies() []engine.Cookie {
 	return c.request.Cookies()
 }
 
+func (c *echoContext) SetHeaders(headers map[string]string) error {
+	if c.response.Committed() {
+		return ErrResponseCommitted
+	}
+	h := c.response.Header()
+	for k, v := range headers {
+		h.Set(k, v)
+	}
+	return nil
+}
+
+func (c *echoContext) AddHeader(key, value string) error {
+	if c.response.Committed() {
+		return ErrResponseCommitted
+	}
+	c.response.Header().Add(key, value)
+	return nil
+}
+
+func (c *echoContext) AppendVary(field string) {
+	h := c.response.Header()
+	vary := h.Get(HeaderVary)
+	for _, f := range strings.Split(vary, ",") {
+		f = strings.TrimSpace(f)
+		if f == "*" || strings.EqualFold(f, field) {
+			return
+		}
+	}
+	if vary != "" {
+		field = vary + ", " + field
+	}
+	h.Set(HeaderVary, field)
+}
+
 func (c *echoContext) Set(key string, val interface{}) {
+	if c.data == nil {
+		c.data = make(map[string]interface{})
+	}
+	c.data[key] = val
+}
+
+func (c *echoContext) Keys() []string {
+	keys := make([]string, 0, len(c.data))
+	for k := range c.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (c *echoContext) Once(key string, fn func() (interface{}, error)) (interface{}, error) {
+	key = onceKeyPrefix + key
+	if r, ok := c.data[key].(onceResult); ok {
+		return r.val, r.err
+	}
+	val, err := fn()
+	c.Set(key, onceResult{val, err})
+	return val, err
+}
+
+func (c *echoContext) Timing(name string) func() {
+	if !c.timed {
+		c.timed = true
+		c.response.Before(c.writeServerTiming)
+	}
+	start := time.Now()
+	stopped := false
+	return func() {
+		if stopped {
+			return
+		}
+		stopped = true
+		d := time.Since(start)
+		for i := range c.timings {
+			if c.timings[i].name == name {
+				c.timings[i].dur += d
+				return
+			}
+		}
+		c.timings = append(c.timings, timing{name, d})
+	}
+}
+
+// writeServerTiming writes the spans recorded with `Timing()` in the
+// `Server-Timing` header, with their durations in milliseconds.
+func (c *echoContext) writeServerTiming() {
+	for _, t := range c.timings {
+		ms := strconv.FormatFloat(float64(t.dur)/float64(time.Millisecond), 'f', 3, 64)
+		c.response.Header().Add(HeaderServerTiming, t.name+";dur="+ms)
+	}
+}
+
+func (c *echoContext) WithValue(key, val interface{}) {
 	c.context = context.WithValue(c.context, key, val)
 }
 
 func (c *echoContext) Get(key string) interface{} {
+	if v, ok := c.data[key]; ok {
+		return v
+	}
 	return c.context.Value(key)
 }
 
+func (c *echoContext) BodyString() (string, error) {
+	body := c.request.Body()
+	if body == nil {
+		return "", nil
+	}
+	b, err := ioutil.ReadAll(body)
+	if err != nil {
+		return "", err
+	}
+	c.request.SetBody(bytes.NewReader(b))
+	return string(b), nil
+}
+
 func (c *echoContext) Bind(i interface{}) error {
 	return c.leego.binder.Bind(i, c)
 }
 
-//func (c *echoContext) Render(code int, name string, data interface{}) (err error) {
-//	if c.echo.renderer == nil {
-//		return ErrRendererNotRegistered
-//	}
-//	buf := new(bytes.Buffer)
-//	if err = c.echo.renderer.Render(buf, name, data, c); err != nil {
-//		return
-//	}
-//	c.response.Header().Set(HeaderContentType, MIMETextHTMLCharsetUTF8)
-//	c.response.WriteHeader(code)
-//	_, err = c.response.Write(buf.Bytes())
-//	return
-//}
+func (c *echoContext) BindCookies(i interface{}) error {
+	cookies := c.request.Cookies()
+	data := make(map[string][]string, len(cookies))
+	for _, cookie := range cookies {
+		data[cookie.Name()] = append(data[cookie.Name()], cookie.Value())
+	}
+	if err := new(binder).bindData(i, data, "cookie"); err != nil {
+		return NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+func (c *echoContext) Render(code int, name string, data interface{}) (err error) {
+	r := c.leego.renderer
+	if r == nil {
+		return ErrRendererNotRegistered
+	}
+	if !c.leego.renderBuffering {
+		c.response.Header().Set(HeaderContentType, MIMETextHTMLCharsetUTF8)
+		c.response.WriteHeader(code)
+		return r.Render(c.response, name, data, c)
+	}
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufferPool.Put(buf)
+	if err = r.Render(buf, name, data, c); err != nil {
+		return
+	}
+	c.response.Header().Set(HeaderContentType, MIMETextHTMLCharsetUTF8)
+	c.response.WriteHeader(code)
+	_, err = c.response.Write(buf.Bytes())
+	return
+}
 
 func (c *echoContext) HTML(code int, html string) (err error) {
 	c.response.Header().Set(HeaderContentType, MIMETextHTMLCharsetUTF8)
@@ -458,6 +951,29 @@ func (c *echoContext) XMLBlob(code int, b []byte) (err error) {
 	return
 }
 
+func (c *echoContext) Negotiate(code int, i interface{}) error {
+	c.AppendVary(HeaderAccept)
+	accept := c.request.Header().Get(HeaderAccept)
+	if accept == "" {
+		return c.JSON(code, i)
+	}
+	for _, spec := range ParseAccept(accept) {
+		if spec.Quality == 0 {
+			continue
+		}
+		switch {
+		case spec.Match(MIMEApplicationJSON):
+			return c.JSON(code, i)
+		case spec.Match(MIMEApplicationXML):
+			return c.XML(code, i)
+		}
+	}
+	if c.leego.strictNegotiation {
+		return ErrNotAcceptable
+	}
+	return c.JSON(code, i)
+}
+
 func (c *echoContext) File(file string) error {
 	f, err := os.Open(file)
 	if err != nil {
@@ -472,10 +988,32 @@ func (c *echoContext) File(file string) error {
 		if err != nil {
 			return ErrNotFound
 		}
+		defer f.Close()
 		if fi, err = f.Stat(); err != nil {
 			return err
 		}
 	}
+
+	accept := c.request.Header().Get(HeaderAcceptEncoding)
+	for _, p := range precompressed {
+		cf, err := os.Open(file + p.ext)
+		if err != nil {
+			continue
+		}
+		defer cf.Close()
+		cfi, err := cf.Stat()
+		if err != nil || cfi.IsDir() {
+			continue
+		}
+		// The response depends on the accepted encodings as soon as a
+		// pre-compressed variant exists
+		c.AppendVary(HeaderAcceptEncoding)
+		if !AcceptsEncoding(accept, p.encoding) {
+			continue
+		}
+		c.response.Header().Set(HeaderContentEncoding, p.encoding)
+		return c.ServeReader(cf, ContentTypeByExtension(fi.Name()), cfi.Size(), fi.ModTime())
+	}
 	return c.ServeContent(f, fi.Name(), fi.ModTime())
 }
 
@@ -483,10 +1021,39 @@ func (c *echoContext) Attachment(r io.ReadSeeker, name string) (err error) {
 	c.response.Header().Set(HeaderContentType, ContentTypeByExtension(name))
 	c.response.Header().Set(HeaderContentDisposition, "attachment; filename="+name)
 	c.response.WriteHeader(http.StatusOK)
-	_, err = io.Copy(c.response, r)
+	_, err = c.Copy(c.response, r)
 	return
 }
 
+func (c *echoContext) Copy(dst io.Writer, src io.Reader) (written int64, err error) {
+	buf := make([]byte, c.leego.copyBufferSize)
+	done := c.context.Done()
+	for {
+		select {
+		case <-done:
+			return written, c.context.Err()
+		default:
+		}
+		nr, er := src.Read(buf)
+		if nr > 0 {
+			nw, ew := dst.Write(buf[:nr])
+			written += int64(nw)
+			if ew != nil {
+				return written, ew
+			}
+			if nw != nr {
+				return written, io.ErrShortWrite
+			}
+		}
+		if er == io.EOF {
+			return written, nil
+		}
+		if er != nil {
+			return written, er
+		}
+	}
+}
+
 func (c *echoContext) NoContent(code int) error {
 	c.response.WriteHeader(code)
 	return nil
@@ -496,15 +1063,52 @@ func (c *echoContext) Redirect(code int, url string) error {
 	if code < http.StatusMultipleChoices || code > http.StatusTemporaryRedirect {
 		return ErrInvalidRedirectCode
 	}
+	if c.response.Committed() {
+		return ErrResponseCommitted
+	}
 	c.response.Header().Set(HeaderLocation, url)
 	c.response.WriteHeader(code)
 	return nil
 }
 
+func (c *echoContext) AbortWithStatus(code int) LeegoError {
+	c.NoContent(code)
+	return ErrAborted
+}
+
+func (c *echoContext) AbortWithJSON(code int, i interface{}) LeegoError {
+	if err := c.JSON(code, i); err != nil {
+		return err
+	}
+	return ErrAborted
+}
+
 func (c *echoContext) Error(err error) {
+	if c.errorHandler != nil {
+		c.errorHandler(err, c)
+		return
+	}
 	c.leego.httpErrorHandler(err, c)
 }
 
+func (c *echoContext) Recover(fn func(interface{}) LeegoError) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	err := fn(r)
+	if err == nil {
+		return
+	}
+	if c.response.Committed() && !c.Streaming() {
+		if l := c.leego.logger; l != nil {
+			l.Errorf("leego: response already committed, recovered error not sent: %v", err)
+		}
+	}
+	// Like a returned error, so the error mappers and hooks see it
+	c.leego.ResponseHandler(err, c)
+}
+
 func (c *echoContext) Leego() *Leego {
 	return c.leego
 }
@@ -521,23 +1125,116 @@ func (c *echoContext) SetHandler(h HandlerFunc) {
 //	return c.echo.logger
 //}
 
+func (c *echoContext) LastModified(t time.Time) bool {
+	if t.IsZero() {
+		return false
+	}
+	// HTTP dates have a resolution of one second
+	t = t.UTC().Truncate(time.Second)
+	c.response.Header().Set(HeaderLastModified, t.Format(http.TimeFormat))
+
+	if m := c.request.Method(); m != GET && m != HEAD {
+		return false
+	}
+	ims, err := http.ParseTime(c.request.Header().Get(HeaderIfModifiedSince))
+	if err != nil {
+		return false
+	}
+	return !t.After(ims)
+}
+
 func (c *echoContext) ServeContent(content io.ReadSeeker, name string, modtime time.Time) error {
-	req := c.Request()
+	size, err := content.Seek(0, io.SeekEnd)
+	if err != nil {
+		return err
+	}
+	if _, err = content.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return c.ServeReader(content, ContentTypeByExtension(name), size, modtime)
+}
+
+func (c *echoContext) ServeReader(content io.ReadSeeker, contentType string, size int64, modtime time.Time) error {
 	res := c.Response()
 
-	if t, err := time.Parse(http.TimeFormat, req.Header().Get(HeaderIfModifiedSince)); err == nil && modtime.Before(t.Add(1*time.Second)) {
+	if c.LastModified(modtime) {
 		res.Header().Del(HeaderContentType)
 		res.Header().Del(HeaderContentLength)
 		return c.NoContent(http.StatusNotModified)
 	}
 
-	res.Header().Set(HeaderContentType, ContentTypeByExtension(name))
-	res.Header().Set(HeaderLastModified, modtime.UTC().Format(http.TimeFormat))
-	res.WriteHeader(http.StatusOK)
-	_, err := io.Copy(res, content)
+	res.Header().Set(HeaderContentType, contentType)
+	res.Header().Set(HeaderAcceptRanges, "bytes")
+	code := http.StatusOK
+	start, length, ok := parseRange(c.request.Header().Get(HeaderRange), size)
+	if !ok {
+		res.Header().Set(HeaderContentRange, fmt.Sprintf("bytes */%d", size))
+		return ErrRangeNotSatisfiable
+	}
+	if length < size {
+		if _, err := content.Seek(start, io.SeekStart); err != nil {
+			return err
+		}
+		code = http.StatusPartialContent
+		res.Header().Set(HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, size))
+	}
+	res.Header().Set(HeaderContentLength, strconv.FormatInt(length, 10))
+	res.WriteHeader(code)
+	if c.request.Method() == HEAD {
+		return nil
+	}
+	_, err := c.Copy(res, io.LimitReader(content, length))
 	return err
 }
 
+// parseRange returns the start and length of the part of content of size
+// requested by the `Range` header s. Anything but a single byte range, including
+// an empty or malformed header, selects the whole content. ok is false if the
+// range is not satisfiable.
+func parseRange(s string, size int64) (start, length int64, ok bool) {
+	const prefix = "bytes="
+	if !strings.HasPrefix(s, prefix) || strings.Contains(s, ",") {
+		return 0, size, true
+	}
+	i := strings.Index(s, "-")
+	if i < 0 {
+		return 0, size, true
+	}
+	first, last := strings.TrimSpace(s[len(prefix):i]), strings.TrimSpace(s[i+1:])
+	if first == "" {
+		// Suffix range, i.e. the last n bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n < 0 {
+			return 0, size, true
+		}
+		if n == 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, n, true
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, size, true
+	}
+	if start >= size {
+		return 0, 0, false
+	}
+	end := size - 1
+	if last != "" {
+		e, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || e < start {
+			return 0, size, true
+		}
+		if e < end {
+			end = e
+		}
+	}
+	return start, end - start + 1, true
+}
+
 // ContentTypeByExtension returns the MIME type associated with the file based on
 // its extension. It returns `application/octet-stream` incase MIME type is not
 // found.
@@ -549,9 +1246,34 @@ func ContentTypeByExtension(name string) (t string) {
 }
 
 func (c *echoContext) Reset(req engine.Request, res engine.Response) {
+	// Cancel the previous request's context, and derive a fresh one for the new
+	// request, so a pooled context never carries a canceled or expired one over.
+	if c.cancel != nil {
+		c.cancel()
+		c.cancel = nil
+	}
 	c.context = context.Background()
+	if req != nil {
+		if r, ok := req.(requestContexter); ok {
+			c.context = r.Context()
+		}
+		c.context, c.cancel = context.WithCancel(c.context)
+	}
 	c.request = req
 	c.response = res
+	c.path = ""
+	c.pnames = nil
 	c.handler = NotFoundHandler
-	c.data = make(map[string]interface{})
+	// Cleared rather than reallocated, as the context is reused
+	for k := range c.data {
+		delete(c.data, k)
+	}
+	c.streaming = false
+	c.deferred = c.deferred[:0]
+	c.multipart = false
+	c.query = nil
+	c.hijacked = false
+	c.timings = c.timings[:0]
+	c.timed = false
+	c.errorHandler = nil
 }
@@ -0,0 +1,854 @@
+package leego
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+const userJSON = `{"id":1,"name":"Jon Snow"}`
+
+type user struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestContextBodyString(t *testing.T) {
+	e := New()
+	req := test.NewRequest(POST, "/", strings.NewReader(userJSON))
+	req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	c := e.NewContext(req, test.NewResponseRecorder())
+
+	body, err := c.BodyString()
+	if assert.NoError(t, err) {
+		assert.Equal(t, userJSON, body)
+	}
+
+	// Body can still be bound afterwards
+	u := new(user)
+	if assert.NoError(t, c.Bind(u)) {
+		assert.Equal(t, 1, u.ID)
+		assert.Equal(t, "Jon Snow", u.Name)
+	}
+}
+
+func TestContextAbort(t *testing.T) {
+	e := New()
+	called := false
+	e.SetHTTPErrorHandler(func(err LeegoError, c Context) {
+		called = true
+	})
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			switch c.QueryParam("abort") {
+			case "status":
+				return c.AbortWithStatus(http.StatusForbidden)
+			case "json":
+				return c.AbortWithJSON(http.StatusUnauthorized, map[string]string{"error": "token expired"})
+			}
+			return next(c)
+		}
+	})
+	e.GET("/", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	// AbortWithStatus
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/?abort=status", nil), rec)
+	assert.Equal(t, http.StatusForbidden, rec.Status())
+	assert.Empty(t, rec.Body.String())
+
+	// AbortWithJSON
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/?abort=json", nil), rec)
+	assert.Equal(t, http.StatusUnauthorized, rec.Status())
+	assert.Equal(t, MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Equal(t, `{"error":"token expired"}`, rec.Body.String())
+
+	assert.False(t, called)
+}
+
+func TestContextNegotiate(t *testing.T) {
+	e := New()
+	u := user{1, "Jon Snow"}
+	negotiate := func(accept string) (*test.ResponseRecorder, error) {
+		req := test.NewRequest(GET, "/", nil)
+		req.Header().Set(HeaderAccept, accept)
+		rec := test.NewResponseRecorder()
+		c := e.NewContext(req, rec)
+		return rec, c.Negotiate(http.StatusOK, u)
+	}
+
+	rec, err := negotiate("application/xml, application/json;q=0.9")
+	if assert.NoError(t, err) {
+		assert.Equal(t, MIMEApplicationXMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+	}
+
+	rec, err = negotiate("text/html, application/*;q=0.5")
+	if assert.NoError(t, err) {
+		assert.Equal(t, MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType))
+		assert.Equal(t, userJSON, rec.Body.String())
+	}
+
+	// Lenient
+	rec, err = negotiate("text/html")
+	if assert.NoError(t, err) {
+		assert.Equal(t, MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType))
+	}
+
+	// Strict
+	e.SetStrictNegotiation(true)
+	rec, err = negotiate("text/html")
+	assert.Equal(t, ErrNotAcceptable, err)
+	assert.False(t, rec.Committed())
+}
+
+type testRenderer struct {
+	templates *template.Template
+}
+
+func (t *testRenderer) Render(w io.Writer, name string, data interface{}, c Context) error {
+	return t.templates.ExecuteTemplate(w, name, data)
+}
+
+func TestContextRender(t *testing.T) {
+	e := New()
+	tpl := template.Must(template.New("hello").Parse("Hello, {{.}}!"))
+	template.Must(tpl.New("broken").Funcs(template.FuncMap{
+		"fail": func() (string, error) {
+			return "", errors.New("template failed")
+		},
+	}).Parse("<h1>Partial</h1>{{fail}}"))
+	e.GET("/:name", func(c Context) LeegoError {
+		return c.Render(http.StatusOK, c.Param("name"), "Jon Snow")
+	})
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/hello", nil), rec)
+	assert.Equal(t, http.StatusInternalServerError, rec.Status())
+
+	e.SetRenderer(&testRenderer{tpl})
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/hello", nil), rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, MIMETextHTMLCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Equal(t, "Hello, Jon Snow!", rec.Body.String())
+
+	// Failing template doesn't leak partial output
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/broken", nil), rec)
+	assert.Equal(t, http.StatusInternalServerError, rec.Status())
+	assert.NotContains(t, rec.Body.String(), "Partial")
+
+	// Unbuffered
+	e.SetRenderBuffering(false)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/broken", nil), rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Contains(t, rec.Body.String(), "Partial")
+}
+
+func TestContextMultipartMemory(t *testing.T) {
+	e := New()
+	e.SetMultipartMemory(1 << 10)
+
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	fw, _ := mw.CreateFormFile("file", "large.bin")
+	fw.Write(bytes.Repeat([]byte("a"), 10<<10))
+	mw.Close()
+	req := test.NewRequest(POST, "/", buf)
+	req.Header().Set(HeaderContentType, mw.FormDataContentType())
+	c := e.NewContext(req, test.NewResponseRecorder())
+
+	fh, err := c.FormFile("file")
+	if assert.NoError(t, err) {
+		f, err := fh.Open()
+		if assert.NoError(t, err) {
+			defer f.Close()
+			_, onDisk := f.(*os.File)
+			assert.True(t, onDisk)
+		}
+		form, _ := c.MultipartForm()
+		form.RemoveAll()
+	}
+}
+
+func TestContextMultipartCleanup(t *testing.T) {
+	e := New()
+	e.SetMultipartMemory(1 << 10)
+	tmp := ""
+	deferred := []int{}
+	e.POST("/upload", func(c Context) LeegoError {
+		c.Defer(func() {
+			deferred = append(deferred, 1)
+		})
+		c.Defer(func() {
+			deferred = append(deferred, 2)
+		})
+		fh, err := c.FormFile("file")
+		if err != nil {
+			return err
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		tmp = f.(*os.File).Name()
+		if _, err = os.Stat(tmp); err != nil {
+			return err
+		}
+		return c.NoContent(http.StatusOK)
+	})
+
+	buf := new(bytes.Buffer)
+	mw := multipart.NewWriter(buf)
+	fw, _ := mw.CreateFormFile("file", "large.bin")
+	fw.Write(bytes.Repeat([]byte("a"), 10<<10))
+	mw.Close()
+	req := test.NewRequest(POST, "/upload", buf)
+	req.Header().Set(HeaderContentType, mw.FormDataContentType())
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.NotEmpty(t, tmp)
+	_, err := os.Stat(tmp)
+	assert.True(t, os.IsNotExist(err))
+	assert.Equal(t, []int{2, 1}, deferred)
+}
+
+func TestContextSetHeaders(t *testing.T) {
+	e := New()
+	rec := test.NewResponseRecorder()
+	c := e.NewContext(test.NewRequest(GET, "/", nil), rec)
+
+	assert.NoError(t, c.SetHeaders(map[string]string{
+		HeaderXFrameOptions:       "DENY",
+		HeaderXContentTypeOptions: "nosniff",
+	}))
+	assert.NoError(t, c.AddHeader(HeaderVary, HeaderOrigin))
+	assert.NoError(t, c.AddHeader(HeaderVary, HeaderAcceptEncoding))
+	assert.Equal(t, "DENY", rec.Header().Get(HeaderXFrameOptions))
+	assert.Equal(t, "nosniff", rec.Header().Get(HeaderXContentTypeOptions))
+
+	// Headers can't be set once the response is committed
+	c.NoContent(http.StatusOK)
+	assert.Equal(t, ErrResponseCommitted, c.SetHeaders(map[string]string{HeaderServer: "leego"}))
+	assert.Equal(t, ErrResponseCommitted, c.AddHeader(HeaderServer, "leego"))
+	assert.Empty(t, rec.Header().Get(HeaderServer))
+}
+
+// cancelReader is an endless reader which cancels after the given number of
+// reads.
+type cancelReader struct {
+	reads  int
+	after  int
+	cancel func()
+}
+
+func (r *cancelReader) Read(b []byte) (int, error) {
+	r.reads++
+	if r.reads == r.after {
+		r.cancel()
+	}
+	for i := range b {
+		b[i] = 'a'
+	}
+	return len(b), nil
+}
+
+func TestContextCopy(t *testing.T) {
+	e := New()
+	e.SetCopyBufferSize(4)
+	c := e.NewContext(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+
+	buf := new(bytes.Buffer)
+	n, err := c.Copy(buf, strings.NewReader("Hello, World!"))
+	if assert.NoError(t, err) {
+		assert.Equal(t, int64(13), n)
+		assert.Equal(t, "Hello, World!", buf.String())
+	}
+
+	// Cancellation stops the copy
+	ctx, cancel := context.WithCancel(c.Context())
+	c.SetContext(ctx)
+	buf.Reset()
+	n, err = c.Copy(buf, &cancelReader{after: 3, cancel: cancel})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, int64(12), n)
+
+	// Invalid sizes fall back to the default
+	for _, size := range []int{0, -1} {
+		e.SetCopyBufferSize(size)
+		c = e.NewContext(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+		buf.Reset()
+		n, err = c.Copy(buf, strings.NewReader("Hello, World!"))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(13), n)
+	}
+	assert.Equal(t, defaultCopyBufferSize, e.copyBufferSize)
+}
+
+func TestContextLastModified(t *testing.T) {
+	e := New()
+	modtime := time.Date(2016, 9, 19, 10, 30, 15, 500, time.FixedZone("CST", 8*3600))
+	check := func(method, ims string) (bool, *test.ResponseRecorder) {
+		req := test.NewRequest(method, "/", nil)
+		if ims != "" {
+			req.Header().Set(HeaderIfModifiedSince, ims)
+		}
+		rec := test.NewResponseRecorder()
+		c := e.NewContext(req, rec)
+		return c.LastModified(modtime), rec
+	}
+
+	notModified, rec := check(GET, "")
+	assert.False(t, notModified)
+	assert.Equal(t, "Mon, 19 Sep 2016 02:30:15 GMT", rec.Header().Get(HeaderLastModified))
+
+	// Matching, sub-second precision is ignored
+	notModified, _ = check(GET, "Mon, 19 Sep 2016 02:30:15 GMT")
+	assert.True(t, notModified)
+
+	// Newer than the client's copy
+	notModified, _ = check(GET, "Mon, 19 Sep 2016 02:30:14 GMT")
+	assert.False(t, notModified)
+
+	// Only GET and HEAD are conditional
+	notModified, _ = check(POST, "Mon, 19 Sep 2016 02:30:15 GMT")
+	assert.False(t, notModified)
+}
+
+func TestContextServeReader(t *testing.T) {
+	e := New()
+	content := []byte("Hello, World!")
+	serve := func(r string) *test.ResponseRecorder {
+		req := test.NewRequest(GET, "/", nil)
+		if r != "" {
+			req.Header().Set(HeaderRange, r)
+		}
+		rec := test.NewResponseRecorder()
+		c := e.NewContext(req, rec)
+		if err := c.ServeReader(bytes.NewReader(content), MIMETextPlain, int64(len(content)), time.Time{}); err != nil {
+			e.ResponseHandler(err, c)
+		}
+		return rec
+	}
+
+	rec := serve("")
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "bytes", rec.Header().Get(HeaderAcceptRanges))
+	assert.Equal(t, "Hello, World!", rec.Body.String())
+
+	rec = serve("bytes=7-11")
+	assert.Equal(t, http.StatusPartialContent, rec.Status())
+	assert.Equal(t, "bytes 7-11/13", rec.Header().Get(HeaderContentRange))
+	assert.Equal(t, "5", rec.Header().Get(HeaderContentLength))
+	assert.Equal(t, "World", rec.Body.String())
+
+	// Open-ended and suffix ranges
+	rec = serve("bytes=7-")
+	assert.Equal(t, "World!", rec.Body.String())
+	rec = serve("bytes=-6")
+	assert.Equal(t, "World!", rec.Body.String())
+
+	// Multiple ranges get the whole content
+	rec = serve("bytes=0-1,3-4")
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "Hello, World!", rec.Body.String())
+
+	rec = serve("bytes=20-")
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Status())
+	assert.Equal(t, "bytes */13", rec.Header().Get(HeaderContentRange))
+}
+
+func TestContextFlush(t *testing.T) {
+	e := New()
+	c := e.NewContext(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.Equal(t, ErrFlushNotSupported, c.Flush())
+}
+
+func TestContextQueryParams(t *testing.T) {
+	e := New()
+	c := e.NewContext(test.NewRequest(GET, "/?tag=a&tag=b&name=jon&empty=", nil), test.NewResponseRecorder())
+
+	assert.Equal(t, "tag=a&tag=b&name=jon&empty=", c.QueryString())
+	assert.Equal(t, "jon", c.QueryParam("name"))
+	// Repeated
+	assert.Equal(t, "a", c.QueryParam("tag"))
+	assert.Equal(t, []string{"a", "b"}, c.QueryParams()["tag"])
+	// Missing
+	assert.Equal(t, "", c.QueryParam("missing"))
+	_, ok := c.QueryParams()["missing"]
+	assert.False(t, ok)
+	_, ok = c.QueryParams()["empty"]
+	assert.True(t, ok)
+
+	// Not carried over to the next request
+	c.Reset(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.Equal(t, "", c.QueryParam("name"))
+	assert.Equal(t, "", c.QueryString())
+}
+
+type hijackRecorder struct {
+	*test.ResponseRecorder
+	conn net.Conn
+}
+
+func (r *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return r.conn, bufio.NewReadWriter(bufio.NewReader(r.conn), bufio.NewWriter(r.conn)), nil
+}
+
+func TestContextHijack(t *testing.T) {
+	e := New()
+	e.GET("/upgrade", func(c Context) LeegoError {
+		conn, rw, err := c.Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n\r\n")
+		rw.Flush()
+		// Errors after hijacking don't produce a response
+		return errors.New("closed")
+	})
+
+	server, client := net.Pipe()
+	rec := &hijackRecorder{ResponseRecorder: test.NewResponseRecorder(), conn: server}
+	done := make(chan struct{})
+	go func() {
+		e.ServeHTTP(test.NewRequest(GET, "/upgrade", nil), rec)
+		close(done)
+	}()
+	line, err := bufio.NewReader(client).ReadString('\n')
+	if assert.NoError(t, err) {
+		assert.Equal(t, "HTTP/1.1 101 Switching Protocols\r\n", line)
+	}
+	<-done
+	client.Close()
+	assert.False(t, rec.Committed())
+	assert.Empty(t, rec.Body.String())
+
+	// Not supported
+	c := e.NewContext(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	_, _, err = c.Hijack()
+	assert.Equal(t, ErrHijackNotSupported, err)
+}
+
+func TestContextSetTimeout(t *testing.T) {
+	e := New()
+	var err error
+	e.GET("/", func(c Context) LeegoError {
+		c.SetTimeout(10 * time.Millisecond)
+		// A downstream operation observing the request context
+		select {
+		case <-c.Context().Done():
+			err = c.Context().Err()
+		case <-time.After(time.Second):
+		}
+		return nil
+	})
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.Equal(t, context.DeadlineExceeded, err)
+
+	// The deadline is cleared on reset
+	c := e.NewContext(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	c.SetTimeout(time.Minute)
+	_, ok := c.Deadline()
+	assert.True(t, ok)
+	c.Reset(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	_, ok = c.Deadline()
+	assert.False(t, ok)
+}
+
+func TestContextResetFreshContext(t *testing.T) {
+	e := New()
+	var prev context.Context
+	var err error
+	e.GET("/", func(c Context) LeegoError {
+		err = c.Context().Err()
+		prev = c.Context()
+		return nil
+	})
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.NoError(t, err)
+	// The request's context is canceled once it completes
+	assert.Equal(t, context.Canceled, prev.Err())
+
+	// A new request gets a live context, even from a reused one
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.NoError(t, err)
+
+	// A canceled or expired context doesn't carry over
+	c := e.NewContext(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c.SetContext(ctx)
+	c.SetTimeout(time.Nanosecond)
+	c.Reset(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.NoError(t, c.Context().Err())
+	_, ok := c.Deadline()
+	assert.False(t, ok)
+}
+
+func TestContextTiming(t *testing.T) {
+	e := New()
+	e.GET("/", func(c Context) LeegoError {
+		stop := c.Timing("db")
+		time.Sleep(2 * time.Millisecond)
+		stop()
+		stop() // no-op
+		c.Timing("db")()
+		c.Timing("render")()
+		// Not stopped before commit
+		c.Timing("late")
+		return c.String(http.StatusOK, "OK")
+	})
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), rec)
+	h := rec.Header().Values(HeaderServerTiming)
+	if assert.Len(t, h, 2) {
+		assert.True(t, strings.HasPrefix(h[0], "db;dur="))
+		d, err := time.ParseDuration(strings.TrimPrefix(h[0], "db;dur=") + "ms")
+		assert.NoError(t, err)
+		assert.True(t, d >= 2*time.Millisecond)
+		assert.True(t, strings.HasPrefix(h[1], "render;dur="))
+	}
+
+	// No spans, no header
+	e.GET("/none", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "OK")
+	})
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/none", nil), rec)
+	assert.Empty(t, rec.Header().Get(HeaderServerTiming))
+}
+
+func TestContextRealIP(t *testing.T) {
+	e := New()
+	realIP := func(headers map[string]string) string {
+		req := test.NewRequest(GET, "/", nil)
+		req.(*test.Request).SetRemoteAddress("192.0.2.1:1234")
+		for k, v := range headers {
+			req.Header().Set(k, v)
+		}
+		return e.NewContext(req, test.NewResponseRecorder()).RealIP()
+	}
+
+	// X-Forwarded-For only
+	assert.Equal(t, "203.0.113.1", realIP(map[string]string{
+		HeaderXForwardedFor: " 203.0.113.1 , 10.0.0.1",
+	}))
+	assert.Equal(t, "2001:db8::1", realIP(map[string]string{
+		HeaderXForwardedFor: "2001:db8::1",
+	}))
+
+	// X-Real-IP first
+	assert.Equal(t, "198.51.100.2", realIP(map[string]string{
+		HeaderXRealIP:       "198.51.100.2",
+		HeaderXForwardedFor: "203.0.113.1",
+	}))
+
+	// Invalid entries are ignored
+	assert.Equal(t, "203.0.113.1", realIP(map[string]string{
+		HeaderXRealIP:       "unknown",
+		HeaderXForwardedFor: "203.0.113.1",
+	}))
+
+	// Neither
+	assert.Equal(t, "192.0.2.1", realIP(nil))
+	assert.Equal(t, "192.0.2.1", realIP(map[string]string{
+		HeaderXForwardedFor: "garbage",
+	}))
+}
+
+func TestContextRedirectCommitted(t *testing.T) {
+	e := New()
+	rec := test.NewResponseRecorder()
+	c := e.NewContext(test.NewRequest(GET, "/", nil), rec)
+	c.String(http.StatusOK, "hello")
+	assert.Equal(t, ErrResponseCommitted, c.Redirect(http.StatusFound, "/login"))
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Empty(t, rec.Header().Get(HeaderLocation))
+
+	rec = test.NewResponseRecorder()
+	c = e.NewContext(test.NewRequest(GET, "/", nil), rec)
+	if assert.NoError(t, c.Redirect(http.StatusFound, "/login")) {
+		assert.Equal(t, http.StatusFound, rec.Status())
+		assert.Equal(t, "/login", rec.Header().Get(HeaderLocation))
+	}
+}
+
+func TestContextAppendVary(t *testing.T) {
+	e := New()
+	rec := test.NewResponseRecorder()
+	c := e.NewContext(test.NewRequest(GET, "/", nil), rec)
+	gzip := func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			c.AppendVary(HeaderAcceptEncoding)
+			return next(c)
+		}
+	}
+	cors := func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			c.AppendVary(HeaderOrigin)
+			return next(c)
+		}
+	}
+	h := gzip(cors(func(c Context) LeegoError {
+		// Duplicates are ignored, case-insensitively
+		c.AppendVary("accept-encoding")
+		return c.String(http.StatusOK, "test")
+	}))
+	h(c)
+	assert.Equal(t, "Accept-Encoding, Origin", rec.Header().Get(HeaderVary))
+
+	// `*` already covers everything
+	rec = test.NewResponseRecorder()
+	c = e.NewContext(test.NewRequest(GET, "/", nil), rec)
+	rec.Header().Set(HeaderVary, "*")
+	c.AppendVary(HeaderOrigin)
+	assert.Equal(t, "*", rec.Header().Get(HeaderVary))
+}
+
+func TestContextFilePrecompressed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "leego")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(filepath.Join(dir, "app.js"), []byte("plain"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "app.js.gz"), []byte("gzipped"), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "style.css"), []byte("plain"), 0644)
+
+	e := New()
+	serve := func(name, acceptEncoding string) *test.ResponseRecorder {
+		req := test.NewRequest(GET, "/", nil)
+		req.Header().Set(HeaderAcceptEncoding, acceptEncoding)
+		rec := test.NewResponseRecorder()
+		c := e.NewContext(req, rec)
+		assert.NoError(t, c.File(filepath.Join(dir, name)))
+		return rec
+	}
+
+	// Pre-compressed variant accepted
+	rec := serve("app.js", "br;q=0.8, gzip")
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "gzipped", rec.Body.String())
+	assert.Equal(t, "gzip", rec.Header().Get(HeaderContentEncoding))
+	assert.Equal(t, ContentTypeByExtension("app.js"), rec.Header().Get(HeaderContentType))
+	assert.Equal(t, HeaderAcceptEncoding, rec.Header().Get(HeaderVary))
+
+	// Not accepted
+	rec = serve("app.js", "br")
+	assert.Equal(t, "plain", rec.Body.String())
+	assert.Empty(t, rec.Header().Get(HeaderContentEncoding))
+	assert.Equal(t, HeaderAcceptEncoding, rec.Header().Get(HeaderVary))
+
+	// No pre-compressed variant
+	rec = serve("style.css", "gzip")
+	assert.Equal(t, "plain", rec.Body.String())
+	assert.Empty(t, rec.Header().Get(HeaderContentEncoding))
+	assert.Empty(t, rec.Header().Get(HeaderVary))
+}
+
+func TestContextParams(t *testing.T) {
+	e := New()
+	var params map[string]string
+	e.GET("/users/:uid/files/:fid", func(c Context) LeegoError {
+		params = c.Params()
+		// Changing the copy leaves the context untouched
+		params["uid"] = "2"
+		return c.String(http.StatusOK, c.Param("uid"))
+	})
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/users/1/files/readme", nil), rec)
+	assert.Equal(t, "1", rec.Body.String())
+	assert.Equal(t, map[string]string{"uid": "2", "fid": "readme"}, params)
+
+	c := e.NewContext(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.Empty(t, c.Params())
+}
+
+func TestContextBindCookies(t *testing.T) {
+	type prefs struct {
+		Session string  `cookie:"session,required"`
+		Theme   string  `cookie:"theme"`
+		Visits  int     `cookie:"visits"`
+		Ratio   float64 `cookie:"ratio"`
+	}
+	e := New()
+	bind := func(cookie string) (*prefs, error) {
+		req := test.NewRequest(GET, "/", nil)
+		req.Header().Set(HeaderCookie, cookie)
+		c := e.NewContext(req, test.NewResponseRecorder())
+		p := &prefs{Theme: "light"}
+		return p, c.BindCookies(p)
+	}
+
+	// Missing theme keeps its value
+	p, err := bind("session=abc; visits=3; ratio=0.5")
+	if assert.NoError(t, err) {
+		assert.Equal(t, &prefs{Session: "abc", Theme: "light", Visits: 3, Ratio: 0.5}, p)
+	}
+
+	_, err = bind("visits=3")
+	if he, ok := err.(*HTTPError); assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+		assert.Equal(t, "session: required", he.Message)
+	}
+
+	_, err = bind("session=abc; visits=many")
+	if he, ok := err.(*HTTPError); assert.True(t, ok) {
+		assert.Equal(t, `visits: cannot bind "many" as int`, he.Message)
+	}
+}
+
+func TestContextRecover(t *testing.T) {
+	e := New()
+	parse := func(c Context) LeegoError {
+		defer c.Recover(func(r interface{}) LeegoError {
+			return NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid input: %v", r))
+		})
+		if c.QueryParam("commit") != "" {
+			c.String(http.StatusOK, "partial")
+		}
+		panic("unexpected token")
+	}
+	e.GET("/", parse)
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), rec)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+	assert.Equal(t, "invalid input: unexpected token", rec.Body.String())
+
+	// The committed response is left as is
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/?commit=1", nil), rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "partial", rec.Body.String())
+
+	// Through the error mappers and hooks
+	var hooked LeegoError
+	e.OnError(func(err LeegoError, c Context) LeegoError {
+		if he, ok := err.(*HTTPError); ok && he.Code == http.StatusBadRequest {
+			return NewHTTPError(http.StatusUnprocessableEntity, he.Message)
+		}
+		return err
+	})
+	e.UseOnError(func(err LeegoError, c Context) LeegoError {
+		hooked = err
+		return err
+	})
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), rec)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Status())
+	assert.Equal(t, "invalid input: unexpected token", rec.Body.String())
+	if assert.NotNil(t, hooked) {
+		assert.Equal(t, http.StatusUnprocessableEntity, hooked.(*HTTPError).Code)
+	}
+}
+
+func TestContextGetSet(t *testing.T) {
+	e := New()
+	e.Pre(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			c.Set("user", "jon")
+			c.Set("admin", true)
+			return next(c)
+		}
+	})
+	var user, admin, missing interface{}
+	var keys []string
+	e.GET("/", func(c Context) LeegoError {
+		user, admin, missing = c.Get("user"), c.Get("admin"), c.Get("missing")
+		keys = c.Keys()
+		return c.NoContent(http.StatusOK)
+	})
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.Equal(t, "jon", user)
+	assert.Equal(t, true, admin)
+	assert.Nil(t, missing)
+	assert.Equal(t, []string{"admin", "user"}, keys)
+
+	// Before and after Reset
+	c := e.NewContext(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.Empty(t, c.Keys())
+	c.Set("user", "jon")
+	c.WithValue("trace", "abc")
+	assert.Equal(t, "jon", c.Get("user"))
+	assert.Equal(t, "abc", c.Get("trace"))
+	c.Reset(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.Nil(t, c.Get("user"))
+	assert.Nil(t, c.Get("trace"))
+	assert.Empty(t, c.Keys())
+	c.Set("user", "arya")
+	assert.Equal(t, "arya", c.Get("user"))
+}
+
+func TestContextOnce(t *testing.T) {
+	e := New()
+	calls := 0
+	loadUser := func(c Context) (interface{}, error) {
+		return c.Once("user", func() (interface{}, error) {
+			calls++
+			return "jon", nil
+		})
+	}
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			if _, err := loadUser(c); err != nil {
+				return err
+			}
+			return next(c)
+		}
+	})
+	var user interface{}
+	e.GET("/", func(c Context) LeegoError {
+		user, _ = loadUser(c)
+		return c.NoContent(http.StatusOK)
+	})
+
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.Equal(t, 1, calls)
+	assert.Equal(t, "jon", user)
+
+	// Once per request
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.Equal(t, 2, calls)
+
+	// Errors are cached too
+	c := e.NewContext(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	errLoad := errors.New("load failed")
+	for i := 0; i < 2; i++ {
+		v, err := c.Once("user", func() (interface{}, error) {
+			calls++
+			return nil, errLoad
+		})
+		assert.Nil(t, v)
+		assert.Equal(t, errLoad, err)
+	}
+	assert.Equal(t, 3, calls)
+	assert.Nil(t, c.Get("user"))
+}
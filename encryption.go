@@ -0,0 +1,131 @@
+package leego
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+type (
+	// encryptWriter buffers the response body, so it's encrypted as a whole once
+	// the request completes.
+	encryptWriter struct {
+		io.Writer
+		buf bytes.Buffer
+	}
+
+	// decryptReader decrypts the request body on the first read, so a request
+	// whose body isn't read, e.g. rejected by a middleware, isn't decrypted.
+	decryptReader struct {
+		body  io.Reader
+		c     Context
+		leego *Leego
+		r     io.Reader
+		err   error
+	}
+)
+
+// SetRequestDecryptor sets the function decrypting the request bodies when
+// they are read, e.g. bound, for payload encryption. A body it fails to decrypt
+// fails the read with 400, one larger than the limit set with
+// `SetDecryptLimit()` with 413. Empty bodies aren't decrypted.
+func (e *Leego) SetRequestDecryptor(fn func([]byte, Context) ([]byte, error)) {
+	e.requestDecryptor = fn
+}
+
+// SetDecryptLimit sets the maximum size of the encrypted request bodies, which
+// are read in full to be decrypted. Default value is 10 MB, which a limit <= 0
+// resets it to.
+func (e *Leego) SetDecryptLimit(limit int64) {
+	if limit <= 0 {
+		limit = defaultDecryptLimit
+	}
+	e.decryptLimit = limit
+}
+
+// SetResponseEncryptor sets the function encrypting the response bodies before
+// they are sent, for payload encryption. The whole body is buffered, so the
+// header is only sent with it, or when the response is flushed. Empty bodies
+// aren't encrypted. An encryption failure is logged and the body dropped, with
+// the status set to 500 unless the header was flushed.
+func (e *Leego) SetResponseEncryptor(fn func([]byte, Context) ([]byte, error)) {
+	e.responseEncryptor = fn
+}
+
+// decryptRequest replaces the request body with one decrypting it.
+func (e *Leego) decryptRequest(c Context) {
+	req := c.Request()
+	if req.Body() == nil {
+		return
+	}
+	req.SetBody(&decryptReader{body: req.Body(), c: c, leego: e})
+}
+
+func (d *decryptReader) Read(b []byte) (int, error) {
+	if d.r == nil && d.err == nil {
+		d.decrypt()
+	}
+	if d.err != nil {
+		return 0, d.err
+	}
+	return d.r.Read(b)
+}
+
+func (d *decryptReader) decrypt() {
+	// One byte over the limit tells a body at the limit from a larger one
+	b, err := ioutil.ReadAll(io.LimitReader(d.body, d.leego.decryptLimit+1))
+	if err != nil {
+		d.err = err
+		return
+	}
+	if int64(len(b)) > d.leego.decryptLimit {
+		d.err = ErrStatusRequestEntityTooLarge
+		return
+	}
+	if len(b) > 0 {
+		if b, err = d.leego.requestDecryptor(b, d.c); err != nil {
+			d.err = NewHTTPError(http.StatusBadRequest, "request body can't be decrypted")
+			return
+		}
+	}
+	d.r = bytes.NewReader(b)
+}
+
+// encryptResponse buffers the response body, and defers writing it encrypted
+// once the response is complete.
+func (e *Leego) encryptResponse(c Context) {
+	res := c.Response()
+	var ew *encryptWriter
+	// Installed when the response is committed, so it sees the plain body
+	res.Before(func() {
+		ew = &encryptWriter{Writer: res.Writer()}
+		res.Header().Del(HeaderContentLength)
+		res.SetWriter(ew)
+	})
+	c.Defer(func() {
+		if ew == nil || ew.buf.Len() == 0 {
+			return
+		}
+		b, err := e.responseEncryptor(ew.buf.Bytes(), c)
+		if err != nil {
+			// The header is still held back, unless flushed
+			res.SetStatus(http.StatusInternalServerError)
+			res.Header().Del(HeaderContentEncoding)
+		} else {
+			_, err = ew.Writer.Write(b)
+		}
+		if err != nil && e.logger != nil {
+			e.logger.Errorf("leego: response to %s not encrypted: %v", c.Request().URI(), err)
+		}
+	})
+}
+
+func (w *encryptWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+// Flush holds the body back, as only the whole of it can be encrypted.
+func (w *encryptWriter) Flush() error {
+	return nil
+}
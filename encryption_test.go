@@ -0,0 +1,96 @@
+package leego
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeegoEncryption(t *testing.T) {
+	xor := func(b []byte) []byte {
+		out := make([]byte, len(b))
+		for i := range b {
+			out[i] = b[i] ^ 0x5a
+		}
+		return out
+	}
+	var decrypted, encrypted int
+	e := New()
+	e.SetRequestDecryptor(func(b []byte, c Context) ([]byte, error) {
+		decrypted++
+		if b[0] == 0 {
+			return nil, errors.New("bad payload")
+		}
+		return xor(b), nil
+	})
+	e.SetResponseEncryptor(func(b []byte, c Context) ([]byte, error) {
+		encrypted++
+		return xor(b), nil
+	})
+	e.POST("/", func(c Context) LeegoError {
+		u := new(user)
+		if err := c.Bind(u); err != nil {
+			return err
+		}
+		u.Name += "!"
+		return c.JSON(http.StatusOK, u)
+	})
+
+	req := test.NewRequest(POST, "/", bytes.NewReader(xor([]byte(userJSON))))
+	req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, `{"id":1,"name":"Jon Snow!"}`, string(xor(rec.Body.Bytes())))
+	assert.Empty(t, rec.Header().Get(HeaderContentLength))
+	assert.Equal(t, 1, decrypted)
+	assert.Equal(t, 1, encrypted)
+
+	// Undecryptable
+	req = test.NewRequest(POST, "/", bytes.NewReader([]byte{0}))
+	req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+	// The error response is encrypted too
+	assert.Contains(t, string(xor(rec.Body.Bytes())), "can't be decrypted")
+}
+
+func TestLeegoEncryptionLimits(t *testing.T) {
+	e := New()
+	e.SetRequestDecryptor(func(b []byte, c Context) ([]byte, error) {
+		return b, nil
+	})
+	e.SetDecryptLimit(4)
+	e.SetResponseEncryptor(func(b []byte, c Context) ([]byte, error) {
+		return nil, errors.New("no key")
+	})
+	e.POST("/", func(c Context) LeegoError {
+		var s string
+		if err := c.Bind(&s); err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, s)
+	})
+
+	req := test.NewRequest(POST, "/", bytes.NewReader([]byte(`"jon snow"`)))
+	req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	// The error response can't be encrypted either, so it's dropped
+	assert.Equal(t, http.StatusInternalServerError, rec.Status())
+	assert.Empty(t, rec.Body.Bytes())
+
+	e.SetResponseEncryptor(func(b []byte, c Context) ([]byte, error) {
+		return b, nil
+	})
+	req = test.NewRequest(POST, "/", bytes.NewReader([]byte(`"jon snow"`)))
+	req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Status())
+}
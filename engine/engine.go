@@ -1,6 +1,7 @@
 package engine
 
 import (
+	"crypto/tls"
 	"io"
 	"mime/multipart"
 	"net"
@@ -18,7 +19,10 @@ type (
 		// SetLogger sets the logger for the HTTP server.
 		SetLogger(*logger.Logger)
 
+		// Stop stops accepting new connections, letting the in-flight requests
+		// complete.
 		Stop()
+
 		// Start starts th e HTTP server.
 		Start() error
 	}
@@ -59,6 +63,11 @@ type (
 		// ProtocolMinor returns the minor protocol version of the HTTP request.
 		// ProtocolMinor() int
 
+		// ConnectionState returns the state of the TLS connection the request was
+		// received on, including the verified client certificate chains, or nil
+		// if the connection is not TLS.
+		ConnectionState() *tls.ConnectionState
+
 		// ContentLength returns the size of request's body.
 		ContentLength() int64
 
@@ -92,6 +101,11 @@ type (
 		// MultipartForm returns the multipart form.
 		MultipartForm() (*multipart.Form, error)
 
+		// ParseMultipartForm parses a multipart form, storing up to maxMemory
+		// bytes of its file parts in memory and the remainder on disk in
+		// temporary files. Subsequent calls have no effect.
+		ParseMultipartForm(maxMemory int64) error
+
 		// Cookie returns the named cookie provided in the request.
 		Cookie(string) (Cookie, error)
 
@@ -107,6 +121,13 @@ type (
 		// WriteHeader sends an HTTP response header with status code.
 		WriteHeader(int)
 
+		// SetStatus overrides the status code of a committed response, e.g. from a
+		// middleware after the handler returned. It's only effective until the
+		// header is written to the client, which is deferred until the body
+		// reaches the client or the response is flushed, and is a no-op
+		// afterwards.
+		SetStatus(int)
+
 		// Write writes the data to the connection as part of an HTTP reply.
 		Write(b []byte) (int, error)
 
@@ -125,8 +146,24 @@ type (
 		// Write returns the HTTP response writer.
 		Writer() io.Writer
 
-		// SetWriter sets the HTTP response writer.
+		// SetWriter sets the HTTP response writer. The writer may wrap the
+		// previous one, returned by `Writer()`. The header is written when the
+		// first bytes of the body reach the client, so a writer buffering the
+		// body, e.g. to transform it, can still change the header and status.
 		SetWriter(io.Writer)
+
+		// Before registers a function which is called just before the response
+		// header is written, e.g. to adjust headers set by the handler. `Status()`
+		// already reports the status code being written.
+		Before(func())
+	}
+
+	// Flusher is implemented by a `Response` which can flush buffered data to
+	// the client.
+	Flusher interface {
+		// FlushError sends any buffered data to the client. It returns an error if
+		// the underlying writer doesn't support flushing.
+		FlushError() error
 	}
 
 	// Header defines the interface for HTTP header.
@@ -146,6 +183,9 @@ type (
 		// no values associated with the key, Get returns "".
 		Get(string) string
 
+		// Values returns all the values associated with the given key.
+		Values(string) []string
+
 		// Keys returns the header keys.
 		Keys() []string
 
@@ -0,0 +1,270 @@
+package standard
+
+import (
+	"bytes"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+)
+
+type (
+	// lengthCheckListener wraps the accepted plain connections with
+	// `lengthCheckConn`. TLS connections are left as is, as `net/http` only gets
+	// their state from a `*tls.Conn`.
+	lengthCheckListener struct {
+		net.Listener
+	}
+
+	// lengthCheckConn follows the framing of the requests read from the
+	// connection, to reject those carrying both `Content-Length` and
+	// `Transfer-Encoding` before `net/http` parses them. As `net/http` frames
+	// such a request by the chunked encoding and drops the `Content-Length`, a
+	// proxy framing it by the `Content-Length` would otherwise have the rest of
+	// the body served as another request, i.e. request smuggling.
+	//
+	// The header lines are held back until complete, and a malformed line is
+	// inserted before the end of an ambiguous header, which `net/http` answers
+	// with 400 before closing the connection.
+	lengthCheckConn struct {
+		net.Conn
+		mu     sync.Mutex
+		state  int
+		line   []byte // line being scanned, held back in a header
+		out    []byte // scanned bytes not read yet
+		remain int64  // bytes left of the body or chunk
+
+		// Header of the request being scanned
+		requestLine bool
+		http10      bool
+		length      int64
+		hasLength   bool
+		hasEncoding bool
+	}
+)
+
+const (
+	scanHeader = iota
+	scanBody
+	scanChunkSize
+	scanChunkData
+	scanChunkEnd
+	scanTrailer
+	// scanOff passes the rest of the connection through, once hijacked or
+	// when `net/http` fails to read it too.
+	scanOff
+)
+
+const (
+	// maxChunkLine is the `net/http` limit of a chunk size line.
+	maxChunkLine = 4096
+	// ambiguousLengthLine makes `net/http` reject an ambiguous request.
+	ambiguousLengthLine = "Ambiguous-Length\r\n"
+)
+
+// Accept implements `net.Listener#Accept` function.
+func (l lengthCheckListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := c.(*tls.Conn); ok {
+		return c, nil
+	}
+	return &lengthCheckConn{Conn: c}, nil
+}
+
+// Read implements `net.Conn#Read` function.
+func (c *lengthCheckConn) Read(b []byte) (n int, err error) {
+	for {
+		c.mu.Lock()
+		if len(c.out) > 0 {
+			n = copy(b, c.out)
+			c.out = c.out[n:]
+			c.mu.Unlock()
+			return
+		}
+		state := c.state
+		c.mu.Unlock()
+		if state == scanOff {
+			return c.Conn.Read(b)
+		}
+
+		n, err = c.Conn.Read(b)
+		c.mu.Lock()
+		if (c.state == scanBody || c.state == scanChunkData) && int64(n) <= c.remain {
+			// Within the body, so handed over as is
+			c.remain -= int64(n)
+			if c.remain == 0 {
+				c.endBody()
+			}
+			c.mu.Unlock()
+			return
+		}
+		c.scan(b[:n])
+		n = copy(b, c.out)
+		c.out = c.out[n:]
+		c.mu.Unlock()
+		if n > 0 || err != nil {
+			if n > 0 {
+				err = nil
+			}
+			return
+		}
+	}
+}
+
+// stop passes the rest of the connection through, e.g. once hijacked.
+func (c *lengthCheckConn) stop() {
+	c.mu.Lock()
+	c.stopLocked()
+	c.mu.Unlock()
+}
+
+func (c *lengthCheckConn) stopLocked() {
+	if c.state == scanHeader {
+		c.out = append(c.out, c.line...)
+	}
+	c.line = nil
+	c.state = scanOff
+}
+
+// scan follows the framing through b, appending it to the bytes to be read.
+func (c *lengthCheckConn) scan(b []byte) {
+	for len(b) > 0 {
+		switch c.state {
+		case scanBody, scanChunkData:
+			n := int64(len(b))
+			if n > c.remain {
+				n = c.remain
+			}
+			c.out = append(c.out, b[:n]...)
+			b = b[n:]
+			if c.remain -= n; c.remain == 0 {
+				c.endBody()
+			}
+		case scanOff:
+			c.out = append(c.out, b...)
+			return
+		default:
+			i := bytes.IndexByte(b, '\n')
+			if i < 0 {
+				i = len(b) - 1
+			}
+			c.line = append(c.line, b[:i+1]...)
+			if c.state != scanHeader {
+				// Only the header lines are held back
+				c.out = append(c.out, b[:i+1]...)
+			}
+			b = b[i+1:]
+			if c.line[len(c.line)-1] == '\n' {
+				c.scanLine()
+				c.line = c.line[:0]
+			} else if len(c.line) > http.DefaultMaxHeaderBytes {
+				// Rejected by `net/http` too
+				c.stopLocked()
+			}
+		}
+	}
+}
+
+// scanLine scans a complete line of a header, a chunk size or a trailer.
+func (c *lengthCheckConn) scanLine() {
+	line := bytes.TrimRight(c.line, "\r\n")
+	switch c.state {
+	case scanHeader:
+		c.scanHeaderLine(line)
+	case scanChunkSize:
+		if len(c.line) > maxChunkLine {
+			c.state = scanOff
+			return
+		}
+		if i := bytes.IndexByte(line, ';'); i >= 0 {
+			line = line[:i]
+		}
+		size, err := strconv.ParseInt(string(bytes.TrimSpace(line)), 16, 64)
+		switch {
+		case err != nil || size < 0:
+			c.state = scanOff
+		case size == 0:
+			c.state = scanTrailer
+		default:
+			c.remain = size
+			c.state = scanChunkData
+		}
+	case scanChunkEnd:
+		c.state = scanChunkSize
+	case scanTrailer:
+		if len(line) == 0 {
+			c.state = scanHeader
+		}
+	}
+}
+
+// scanHeaderLine scans a line of a request header, handing it over.
+func (c *lengthCheckConn) scanHeaderLine(line []byte) {
+	switch {
+	case !c.requestLine:
+		// Empty lines before the request line are skipped by `net/http`
+		if len(line) > 0 {
+			c.requestLine = true
+			c.http10 = bytes.HasSuffix(line, []byte(" HTTP/1.0"))
+		}
+	case len(line) == 0:
+		c.endHeader()
+		return
+	case line[0] == ' ' || line[0] == '\t':
+		// Continuation of the previous line
+	default:
+		i := bytes.IndexByte(line, ':')
+		if i < 0 {
+			break
+		}
+		name, value := string(line[:i]), string(bytes.TrimSpace(line[i+1:]))
+		switch {
+		case http.CanonicalHeaderKey(name) == "Content-Length":
+			n, err := strconv.ParseInt(value, 10, 64)
+			if err != nil || n < 0 || c.hasLength && n != c.length {
+				// Rejected by `net/http`
+				n = -1
+			}
+			c.length, c.hasLength = n, true
+		case http.CanonicalHeaderKey(name) == "Transfer-Encoding":
+			c.hasEncoding = true
+		}
+	}
+	c.out = append(c.out, c.line...)
+}
+
+// endHeader hands over the end of the request header, starting its body.
+func (c *lengthCheckConn) endHeader() {
+	http10, hasLength, hasEncoding, length := c.http10, c.hasLength, c.hasEncoding, c.length
+	c.requestLine, c.http10, c.hasLength, c.hasEncoding, c.length = false, false, false, false, 0
+	if hasLength && hasEncoding {
+		c.out = append(c.out, ambiguousLengthLine...)
+		c.out = append(c.out, c.line...)
+		c.state = scanOff
+		return
+	}
+	c.out = append(c.out, c.line...)
+	switch {
+	case hasEncoding && !http10:
+		// Anything but chunked is rejected by `net/http`
+		c.state = scanChunkSize
+	case length < 0:
+		c.state = scanOff
+	case length > 0:
+		c.remain = length
+		c.state = scanBody
+	}
+}
+
+// endBody moves on past the end of the body or chunk.
+func (c *lengthCheckConn) endBody() {
+	if c.state == scanChunkData {
+		c.state = scanChunkEnd
+	} else {
+		c.state = scanHeader
+	}
+}
@@ -29,6 +29,17 @@ func (h *Header) Get(key string) string {
 	return h.Header.Get(key)
 }
 
+// SetValues sets the values of the canonical key to values, used as is, e.g.
+// prebuilt once for many responses, so it doesn't allocate.
+func (h *Header) SetValues(key string, values []string) {
+	h.Header[key] = values
+}
+
+// Values implements `engine.Header#Values` function.
+func (h *Header) Values(key string) []string {
+	return h.Header.Values(key)
+}
+
 // Keys implements `engine.Header#Keys` function.
 func (h *Header) Keys() (keys []string) {
 	keys = make([]string, len(h.Header))
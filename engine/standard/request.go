@@ -1,6 +1,7 @@
 package standard
 
 import (
+	"crypto/tls"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
@@ -48,6 +49,11 @@ func (r *Request) Scheme() string {
 	return "http"
 }
 
+// ConnectionState implements `engine.Request#ConnectionState` function.
+func (r *Request) ConnectionState() *tls.ConnectionState {
+	return r.Request.TLS
+}
+
 // Host implements `engine.Request#Host` function.
 func (r *Request) Host() string {
 	return r.Request.Host
@@ -156,6 +162,11 @@ func (r *Request) MultipartForm() (*multipart.Form, error) {
 	return r.Request.MultipartForm, err
 }
 
+// ParseMultipartForm implements `engine.Request#ParseMultipartForm` function.
+func (r *Request) ParseMultipartForm(maxMemory int64) error {
+	return r.Request.ParseMultipartForm(maxMemory)
+}
+
 // Cookie implements `engine.Request#Cookie` function.
 func (r *Request) Cookie(name string) (engine.Cookie, error) {
 	c, err := r.Request.Cookie(name)
@@ -2,11 +2,13 @@ package standard
 
 import (
 	"bufio"
+	"errors"
 	"io"
 	"net"
 	"net/http"
 
 	"github.com/go-wyvern/leego/engine"
+	"github.com/go-wyvern/logger"
 )
 
 type (
@@ -18,12 +20,32 @@ type (
 		status    int
 		size      int64
 		committed bool
-		writer    io.Writer
+		// headerWritten is set once the header is written to the underlying
+		// `http.ResponseWriter`, which is deferred until the body reaches it, the
+		// response is flushed or the request completes.
+		headerWritten bool
+		writer        io.Writer
+		before        []func()
+		logger        *logger.Logger
 	}
 
 	responseAdapter struct {
 		*Response
 	}
+
+	// bodyWriter is the last writer of the chain, which writes the header just
+	// before the first bytes of the body reach the underlying
+	// `http.ResponseWriter`. So a writer set with `SetWriter()` buffering the
+	// body, e.g. to transform it, defers the header too.
+	bodyWriter struct {
+		r *Response
+	}
+
+	// bufferedFlusher is implemented by writers which buffer data, like
+	// `gzip.Writer`.
+	bufferedFlusher interface {
+		Flush() error
+	}
 )
 
 // NewResponse returns `Response` instance.
@@ -31,8 +53,8 @@ func NewResponse(w http.ResponseWriter) (r *Response) {
 	r = &Response{
 		ResponseWriter: w,
 		header:         &Header{Header: w.Header()},
-		writer:         w,
 	}
+	r.writer = bodyWriter{r}
 	r.adapter = &responseAdapter{Response: r}
 	return
 }
@@ -49,10 +71,33 @@ func (r *Response) WriteHeader(code int) {
 		return
 	}
 	r.status = code
-	r.ResponseWriter.WriteHeader(code)
+	for _, fn := range r.before {
+		fn()
+	}
 	r.committed = true
 }
 
+// SetStatus implements `engine.Response#SetStatus` function.
+func (r *Response) SetStatus(code int) {
+	if r.headerWritten {
+		if r.logger != nil {
+			r.logger.Warnf("standard: response header already written, status %d ignored", code)
+		}
+		return
+	}
+	r.status = code
+}
+
+// writeHeader writes the header with the committed status to the underlying
+// `http.ResponseWriter`, once.
+func (r *Response) writeHeader() {
+	if !r.committed || r.headerWritten {
+		return
+	}
+	r.headerWritten = true
+	r.ResponseWriter.WriteHeader(r.status)
+}
+
 // Write implements `engine.Response#Write` function.
 func (r *Response) Write(b []byte) (n int, err error) {
 	if !r.committed {
@@ -101,18 +146,57 @@ func (r *Response) SetWriter(w io.Writer) {
 	r.writer = w
 }
 
+// Before implements `engine.Response#Before` function.
+func (r *Response) Before(fn func()) {
+	r.before = append(r.before, fn)
+}
+
 // Flush implements the http.Flusher interface to allow an HTTP handler to flush
 // buffered data to the client.
 // See https://golang.org/pkg/net/http/#Flusher
 func (r *Response) Flush() {
+	r.writeHeader()
+	if bf, ok := r.writer.(bufferedFlusher); ok {
+		bf.Flush()
+	}
 	r.ResponseWriter.(http.Flusher).Flush()
 }
 
+// FlushError implements `engine.Flusher#FlushError` function. A buffering
+// writer set with `SetWriter()`, e.g. a `gzip.Writer`, is flushed first.
+func (r *Response) FlushError() error {
+	f, ok := r.ResponseWriter.(http.Flusher)
+	if !ok {
+		return errors.New("response writer does not support flushing")
+	}
+	r.writeHeader()
+	if bf, ok := r.writer.(bufferedFlusher); ok {
+		if err := bf.Flush(); err != nil {
+			return err
+		}
+	}
+	f.Flush()
+	return nil
+}
+
 // Hijack implements the http.Hijacker interface to allow an HTTP handler to
 // take over the connection.
 // See https://golang.org/pkg/net/http/#Hijacker
 func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	return r.ResponseWriter.(http.Hijacker).Hijack()
+	h, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	conn, rw, err := h.Hijack()
+	if err == nil {
+		// The connection is no longer ours to write a header to, nor to read
+		// requests from
+		r.headerWritten = true
+		if lc, ok := conn.(*lengthCheckConn); ok {
+			lc.stop()
+		}
+	}
+	return conn, rw, err
 }
 
 // CloseNotify implements the http.CloseNotifier interface to allow detecting
@@ -124,14 +208,22 @@ func (r *Response) CloseNotify() <-chan bool {
 	return r.ResponseWriter.(http.CloseNotifier).CloseNotify()
 }
 
-func (r *Response) reset(w http.ResponseWriter, a *responseAdapter, h engine.Header) {
+func (r *Response) reset(w http.ResponseWriter, a *responseAdapter, h engine.Header, l *logger.Logger) {
 	r.ResponseWriter = w
 	r.adapter = a
 	r.header = h
 	r.status = http.StatusOK
 	r.size = 0
 	r.committed = false
-	r.writer = w
+	r.headerWritten = false
+	r.writer = bodyWriter{r}
+	r.before = nil
+	r.logger = l
+}
+
+func (w bodyWriter) Write(b []byte) (int, error) {
+	w.r.writeHeader()
+	return w.r.ResponseWriter.Write(b)
 }
 
 func (r *responseAdapter) Header() http.Header {
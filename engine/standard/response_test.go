@@ -0,0 +1,137 @@
+package standard
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseFlush(t *testing.T) {
+	e := leego.New()
+	flushed := make(chan struct{})
+	done := make(chan struct{})
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		c.Response().Write([]byte("progress: 50%\n"))
+		if err := c.Flush(); err != nil {
+			return err
+		}
+		// Hold the response open until the client saw the flushed data
+		<-flushed
+		c.Response().Write([]byte("progress: 100%\n"))
+		close(done)
+		return nil
+	})
+	s := New("")
+	s.SetHandler(e)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer res.Body.Close()
+	r := bufio.NewReader(res.Body)
+	line, err := r.ReadString('\n')
+	if assert.NoError(t, err) {
+		assert.Equal(t, "progress: 50%\n", line)
+	}
+	select {
+	case <-done:
+		t.Fatal("handler returned before the data was received")
+	default:
+	}
+	close(flushed)
+	line, _ = r.ReadString('\n')
+	assert.Equal(t, "progress: 100%\n", line)
+}
+
+func TestResponseSetStatus(t *testing.T) {
+	e := leego.New()
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		c.NoContent(http.StatusInternalServerError)
+		c.Response().SetStatus(http.StatusAccepted)
+		return nil
+	})
+	e.GET("/written", func(c leego.Context) leego.LeegoError {
+		c.String(http.StatusInternalServerError, "failed")
+		c.Response().SetStatus(http.StatusOK)
+		return nil
+	})
+	s := New("")
+	s.SetHandler(e)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL)
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+	}
+
+	res, err = http.Get(ts.URL + "/written")
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	}
+}
+
+func TestResponseMultipart(t *testing.T) {
+	e := leego.New()
+	received := make(chan struct{})
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		mw, err := c.MultipartResponse()
+		if err != nil {
+			return err
+		}
+		pw, _ := mw.CreatePart(textproto.MIMEHeader{leego.HeaderContentType: {leego.MIMEApplicationJSON}})
+		pw.Write([]byte(`{"id":1}`))
+		// The boundary of the next part ends the first one
+		pw, _ = mw.CreatePart(textproto.MIMEHeader{leego.HeaderContentType: {leego.MIMETextPlain}})
+		<-received
+		pw.Write([]byte("second"))
+		return mw.Close()
+	})
+	s := New("")
+	s.SetHandler(e)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	res, err := http.Get(ts.URL)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer res.Body.Close()
+	mt, params, err := mime.ParseMediaType(res.Header.Get(leego.HeaderContentType))
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, leego.MIMEMultipartMixed, mt)
+	r := multipart.NewReader(res.Body, params["boundary"])
+
+	// Read while the handler is still writing
+	p, err := r.NextPart()
+	if assert.NoError(t, err) {
+		assert.Equal(t, leego.MIMEApplicationJSON, p.Header.Get(leego.HeaderContentType))
+		b, _ := ioutil.ReadAll(p)
+		assert.Equal(t, `{"id":1}`, string(b))
+	}
+	close(received)
+
+	p, err = r.NextPart()
+	if assert.NoError(t, err) {
+		b, _ := ioutil.ReadAll(p)
+		assert.Equal(t, "second", string(b))
+	}
+	_, err = r.NextPart()
+	assert.Equal(t, io.EOF, err)
+}
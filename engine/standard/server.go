@@ -1,9 +1,12 @@
 package standard
 
 import (
+	"net"
 	"net/http"
 	"sync"
 
+	"golang.org/x/net/context"
+
 	"github.com/go-wyvern/leego"
 	"github.com/go-wyvern/leego/engine"
 	"github.com/go-wyvern/logger"
@@ -11,6 +14,12 @@ import (
 
 type (
 	// Server implements `engine.Server`.
+	//
+	// Requests carrying both `Content-Length` and `Transfer-Encoding`, which a
+	// proxy in front of the server might frame differently, i.e. smuggle a
+	// request, are rejected with 400 before `net/http` parses them. TLS
+	// connections, which `net/http` needs as they are, aren't checked, so a
+	// proxy terminating TLS in front of the server must reject them itself.
 	Server struct {
 		*http.Server
 		config  engine.Config
@@ -103,10 +112,19 @@ func (s *Server) Start() error {
 	return s.startCustomListener()
 }
 
+// Stop implements `engine.Server#Stop` function. It closes the listener and
+// the idle connections, while the active ones are closed once their response is
+// sent.
 func (s *Server) Stop() {
 	if s.config.Listener != nil {
 		s.config.Listener.Close()
 	}
+	// Shutdown returns right away with a done context, past closing the
+	// listeners and disabling keep-alives, instead of waiting for the active
+	// connections
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s.Server.Shutdown(ctx)
 }
 
 func (s *Server) startDefaultListener() error {
@@ -114,11 +132,19 @@ func (s *Server) startDefaultListener() error {
 	if c.TLSCertFile != "" && c.TLSKeyFile != "" {
 		return s.ListenAndServeTLS(c.TLSCertFile, c.TLSKeyFile)
 	}
-	return s.ListenAndServe()
+	addr := s.Addr
+	if addr == "" {
+		addr = ":http"
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(lengthCheckListener{l})
 }
 
 func (s *Server) startCustomListener() error {
-	return s.Serve(s.config.Listener)
+	return s.Serve(lengthCheckListener{s.config.Listener})
 }
 
 // ServeHTTP implements `http.Handler` interface.
@@ -137,9 +163,11 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	resAdpt.reset(res)
 	resHdr := s.pool.header.Get().(*Header)
 	resHdr.reset(w.Header())
-	res.reset(w, resAdpt, resHdr)
+	res.reset(w, resAdpt, resHdr, s.logger)
 
 	s.handler.ServeHTTP(req, res)
+	// Write the header of a response without body
+	res.writeHeader()
 
 	// Return to pool
 	s.pool.request.Put(req)
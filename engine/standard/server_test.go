@@ -0,0 +1,220 @@
+package standard
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang.org/x/net/context"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/engine"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServerShutdown(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	e := leego.New()
+	var started sync.WaitGroup
+	var completed int32
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		started.Done()
+		time.Sleep(100 * time.Millisecond)
+		atomic.AddInt32(&completed, 1)
+		return c.String(http.StatusOK, "test")
+	})
+	stopped := make(chan error)
+	go func() {
+		stopped <- e.Start(WithConfig(engine.Config{Listener: l}))
+	}()
+
+	const n = 50
+	url := "http://" + l.Addr().String()
+	client := &http.Client{Transport: &http.Transport{MaxIdleConnsPerHost: n}}
+	started.Add(n)
+	var clients sync.WaitGroup
+	for i := 0; i < n; i++ {
+		clients.Add(1)
+		go func() {
+			defer clients.Done()
+			res, err := client.Get(url)
+			if assert.NoError(t, err) {
+				assert.Equal(t, http.StatusOK, res.StatusCode)
+				res.Body.Close()
+			}
+		}()
+	}
+	started.Wait()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, e.Shutdown(ctx))
+	assert.Equal(t, int32(n), atomic.LoadInt32(&completed))
+	assert.NoError(t, <-stopped)
+	clients.Wait()
+
+	// New connections are refused
+	_, err = http.Get(url)
+	assert.Error(t, err)
+}
+
+func TestServerShutdownTimeout(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	e := leego.New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		close(started)
+		<-release
+		return nil
+	})
+	go e.Start(WithConfig(engine.Config{Listener: l}))
+	go http.Get("http://" + l.Addr().String())
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, e.Shutdown(ctx))
+	close(release)
+}
+
+func TestServerAmbiguousLength(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	e := leego.New()
+	served := make(chan string, 4)
+	e.Any("/*", func(c leego.Context) leego.LeegoError {
+		body, _ := c.BodyString()
+		served <- c.Request().URL().Path() + " " + body
+		return c.String(http.StatusOK, body)
+	})
+	go e.Start(WithConfig(engine.Config{Listener: l}))
+	defer l.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	// A proxy framing by the Content-Length would forward a single request
+	_, err = conn.Write([]byte("POST / HTTP/1.1\r\nHost: leego\r\n" +
+		"Content-Length: 40\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"5\r\nhello\r\n0\r\n\r\n" +
+		"GET /admin HTTP/1.1\r\nHost: leego\r\n\r\n"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	br := bufio.NewReader(conn)
+	res, err := http.ReadResponse(br, nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	// Neither request is served, and the connection is closed
+	_, err = http.ReadResponse(br, nil)
+	assert.Error(t, err)
+	assert.Empty(t, served)
+}
+
+func TestServerRequestFraming(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	e := leego.New()
+	e.Any("/*", func(c leego.Context) leego.LeegoError {
+		body, _ := c.BodyString()
+		return c.String(http.StatusOK, c.Request().URL().Path()+" "+body)
+	})
+	go e.Start(WithConfig(engine.Config{Listener: l}))
+	defer l.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	// Pipelined requests, whose bodies look like requests, sent in pieces
+	requests := "POST /chunked HTTP/1.1\r\nHost: leego\r\nTransfer-Encoding: chunked\r\n\r\n" +
+		"1b\r\nContent-Length: 1\r\nX: y\r\n\r\n\r\n0\r\nTrailer: z\r\n\r\n" +
+		"POST /length HTTP/1.1\r\nHost: leego\r\nContent-Length: 27\r\n\r\n" +
+		"Transfer-Encoding: chunked\n" +
+		"GET /last HTTP/1.1\r\nHost: leego\r\n\r\n"
+	go func() {
+		for i := 0; i < len(requests); i += 7 {
+			end := i + 7
+			if end > len(requests) {
+				end = len(requests)
+			}
+			conn.Write([]byte(requests[i:end]))
+			time.Sleep(time.Millisecond)
+		}
+	}()
+	br := bufio.NewReader(conn)
+	for _, body := range []string{
+		"/chunked Content-Length: 1\r\nX: y\r\n\r\n",
+		"/length Transfer-Encoding: chunked\n",
+		"/last ",
+	} {
+		res, err := http.ReadResponse(br, nil)
+		if !assert.NoError(t, err) {
+			return
+		}
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, body, string(b))
+	}
+}
+
+func TestServerHijack(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if !assert.NoError(t, err) {
+		return
+	}
+	e := leego.New()
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		conn, rw, err := c.Hijack()
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		// Not a request, so passed through as is
+		b := make([]byte, 3)
+		if _, err := io.ReadFull(rw, b); err == nil {
+			rw.WriteString(string(b))
+			rw.Flush()
+		}
+		return nil
+	})
+	go e.Start(WithConfig(engine.Config{Listener: l}))
+	defer l.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: leego\r\n\r\nabc"))
+	b, err := ioutil.ReadAll(conn)
+	assert.NoError(t, err)
+	assert.Equal(t, "abc", string(b))
+}
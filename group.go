@@ -5,140 +5,168 @@ type (
 	// routes that share a common middlware or functionality that should be separate
 	// from the parent echo instance while still inheriting from it.
 	Group struct {
-		prefix     string
-		middleware []MiddlewareFunc
-		leego       *Leego
+		prefix       string
+		middleware   []MiddlewareFunc
+		leego        *Leego
+		errorHandler HTTPErrorHandler
 	}
 )
 
+// SetHTTPErrorHandler registers an HTTP error handler for the routes within the
+// Group, which is used instead of the one registered with
+// `Leego#SetHTTPErrorHandler()`.
+func (g *Group) SetHTTPErrorHandler(h HTTPErrorHandler) {
+	g.errorHandler = h
+}
+
+// useErrorHandler is the outermost middleware of the routes within the Group,
+// which makes the Group's error handler, if any, handle their errors. As it
+// runs before the ones of the parent groups, the innermost group's handler wins.
+func (g *Group) useErrorHandler(next HandlerFunc) HandlerFunc {
+	return func(c Context) LeegoError {
+		if g.errorHandler != nil {
+			if ec, ok := c.(*echoContext); ok && ec.errorHandler == nil {
+				ec.errorHandler = g.errorHandler
+			}
+		}
+		return next(c)
+	}
+}
+
 // Use implements `Echo#Use()` for sub-routes within the Group.
 func (g *Group) Use(m ...MiddlewareFunc) {
 	g.middleware = append(g.middleware, m...)
 	// Allow all requests to reach the group as they might get dropped if router
 	// doesn't find a match, making none of the group middleware process.
+	m = append([]MiddlewareFunc{g.useErrorHandler}, g.middleware...)
 	g.leego.Any(g.prefix+"*", func(c Context) LeegoError {
 		return ErrNotFound
-	}, g.middleware...)
+	}, m...)
 }
 
 // CONNECT implements `Echo#CONNECT()` for sub-routes within the Group.
-func (g *Group) CONNECT(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(CONNECT, path, h, m...)
+func (g *Group) CONNECT(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(CONNECT, path, h, m...)
 }
 
 // Connect is deprecated, use `CONNECT()` instead.
-func (g *Group) Connect(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(CONNECT, path, h, m...)
+func (g *Group) Connect(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(CONNECT, path, h, m...)
 }
 
 // DELETE implements `Echo#DELETE()` for sub-routes within the Group.
-func (g *Group) DELETE(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(DELETE, path, h, m...)
+func (g *Group) DELETE(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(DELETE, path, h, m...)
 }
 
 // Delete is deprecated, use `DELETE()` instead.
-func (g *Group) Delete(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(DELETE, path, h, m...)
+func (g *Group) Delete(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(DELETE, path, h, m...)
 }
 
 // GET implements `Echo#GET()` for sub-routes within the Group.
-func (g *Group) GET(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(GET, path, h, m...)
+func (g *Group) GET(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(GET, path, h, m...)
 }
 
 // Get is deprecated, use `GET()` instead.
-func (g *Group) Get(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(GET, path, h, m...)
+func (g *Group) Get(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(GET, path, h, m...)
 }
 
 // HEAD implements `Echo#HEAD()` for sub-routes within the Group.
-func (g *Group) HEAD(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(HEAD, path, h, m...)
+func (g *Group) HEAD(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(HEAD, path, h, m...)
 }
 
 // Head is deprecated, use `HEAD()` instead.
-func (g *Group) Head(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(HEAD, path, h, m...)
+func (g *Group) Head(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(HEAD, path, h, m...)
 }
 
 // OPTIONS implements `Echo#OPTIONS()` for sub-routes within the Group.
-func (g *Group) OPTIONS(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(OPTIONS, path, h, m...)
+func (g *Group) OPTIONS(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(OPTIONS, path, h, m...)
 }
 
 // Options is deprecated, use `OPTIONS()` instead.
-func (g *Group) Options(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(OPTIONS, path, h, m...)
+func (g *Group) Options(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(OPTIONS, path, h, m...)
 }
 
 // PATCH implements `Echo#PATCH()` for sub-routes within the Group.
-func (g *Group) PATCH(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(PATCH, path, h, m...)
+func (g *Group) PATCH(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(PATCH, path, h, m...)
 }
 
 // Patch is deprecated, use `PATCH()` instead.
-func (g *Group) Patch(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(PATCH, path, h, m...)
+func (g *Group) Patch(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(PATCH, path, h, m...)
 }
 
 // POST implements `Echo#POST()` for sub-routes within the Group.
-func (g *Group) POST(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(POST, path, h, m...)
+func (g *Group) POST(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(POST, path, h, m...)
 }
 
 // Post is deprecated, use `POST()` instead.
-func (g *Group) Post(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(POST, path, h, m...)
+func (g *Group) Post(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(POST, path, h, m...)
 }
 
 // PUT implements `Echo#PUT()` for sub-routes within the Group.
-func (g *Group) PUT(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(PUT, path, h, m...)
+func (g *Group) PUT(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(PUT, path, h, m...)
 }
 
 // Put is deprecated, use `PUT()` instead.
-func (g *Group) Put(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(PUT, path, h, m...)
+func (g *Group) Put(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(PUT, path, h, m...)
 }
 
 // TRACE implements `Echo#TRACE()` for sub-routes within the Group.
-func (g *Group) TRACE(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(TRACE, path, h, m...)
+func (g *Group) TRACE(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(TRACE, path, h, m...)
 }
 
 // Trace is deprecated, use `TRACE()` instead.
-func (g *Group) Trace(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	g.add(TRACE, path, h, m...)
+func (g *Group) Trace(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return g.add(TRACE, path, h, m...)
 }
 
 // Any implements `Echo#Any()` for sub-routes within the Group.
-func (g *Group) Any(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+func (g *Group) Any(path string, handler HandlerFunc, middleware ...MiddlewareFunc) []*Route {
+	routes := make([]*Route, 0, len(methods))
 	for _, m := range methods {
-		g.add(m, path, handler, middleware...)
+		routes = append(routes, g.add(m, path, handler, middleware...))
 	}
+	return routes
 }
 
 // Match implements `Echo#Match()` for sub-routes within the Group.
-func (g *Group) Match(methods []string, path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+func (g *Group) Match(methods []string, path string, handler HandlerFunc, middleware ...MiddlewareFunc) []*Route {
+	routes := make([]*Route, 0, len(methods))
 	for _, m := range methods {
-		g.add(m, path, handler, middleware...)
+		routes = append(routes, g.add(m, path, handler, middleware...))
 	}
+	return routes
 }
 
 // Group creates a new sub-group with prefix and optional sub-group-level middleware.
 func (g *Group) Group(prefix string, middleware ...MiddlewareFunc) *Group {
-	m := []MiddlewareFunc{}
+	// The parent's error handler applies unless the sub-group registers its own
+	m := []MiddlewareFunc{g.useErrorHandler}
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
 	return g.leego.Group(g.prefix+prefix, m...)
 }
 
-func (g *Group) add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+func (g *Group) add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route {
 	// Combine into a new slice, to avoid accidentally passing the same
 	// slice for multiple routes, which would lead to later add() calls overwriting
 	// the middleware from earlier calls
-	m := []MiddlewareFunc{}
+	m := []MiddlewareFunc{g.useErrorHandler}
 	m = append(m, g.middleware...)
 	m = append(m, middleware...)
-	g.leego.add(method, g.prefix+path, handler, m...)
+	return g.leego.add(method, g.prefix+path, handler, m...)
 }
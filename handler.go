@@ -0,0 +1,35 @@
+//go:build go1.18
+// +build go1.18
+
+package leego
+
+import "net/http"
+
+// Handler returns a handler which binds the request into a `Req`, validates it
+// with `Validate()`, and calls fn with it, sending the `Resp` it
+// returns as JSON with status 200. Binding and validation failures are sent as
+// 400, while an error returned by fn goes to the HTTP error handler like any
+// handler's, e.g.
+//
+//	e.POST("/users", leego.Handler(func(c leego.Context, u User) (*User, error) {
+//		return store.Create(u)
+//	}))
+func Handler[Req, Resp any](fn func(Context, Req) (Resp, error)) HandlerFunc {
+	return func(c Context) LeegoError {
+		var req Req
+		if err := c.Bind(&req); err != nil {
+			if he, ok := err.(*HTTPError); ok {
+				return he
+			}
+			return NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err := Validate(&req); err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		res, err := fn(c, req)
+		if err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, res)
+	}
+}
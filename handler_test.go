@@ -0,0 +1,61 @@
+//go:build go1.18
+// +build go1.18
+
+package leego
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type greetRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *greetRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type greetResponse struct {
+	Greeting string `json:"greeting"`
+}
+
+func TestHandler(t *testing.T) {
+	e := New()
+	e.POST("/greet", Handler(func(c Context, req greetRequest) (greetResponse, error) {
+		if req.Name == "Ramsay" {
+			return greetResponse{}, NewHTTPError(http.StatusForbidden)
+		}
+		return greetResponse{Greeting: "Hello, " + req.Name}, nil
+	}))
+	post := func(body string) *test.ResponseRecorder {
+		req := test.NewRequest(POST, "/greet", strings.NewReader(body))
+		req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec
+	}
+
+	rec := post(`{"name":"Jon"}`)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Equal(t, `{"greeting":"Hello, Jon"}`, rec.Body.String())
+
+	rec = post(`{"name":""}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+	assert.Equal(t, "name is required", rec.Body.String())
+
+	rec = post(`{"name":`)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+
+	rec = post(`{"name":"Ramsay"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Status())
+}
@@ -1,14 +1,22 @@
 package leego
 
 import (
+	"bufio"
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
+	"path/filepath"
 	"reflect"
+	"regexp"
 	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 
 	"golang.org/x/net/context"
 
@@ -21,17 +29,38 @@ type (
 	// Leego is the top-level framework instance.
 	Leego struct {
 		premiddleware      []MiddlewareFunc
+		interceptors       []func(Context) (bool, LeegoError)
 		middleware         []MiddlewareFunc
 		maxParam           *int
 		wg                 utils.WaitGroupWrapper
+		shutdownMu         sync.RWMutex
+		shuttingDown       bool
+		server             engine.Server
 		notFoundHandler    HandlerFunc
+		fallback           HandlerFunc
 		httpErrorHandler   HTTPErrorHandler
 		httpSuccessHandler HTTPSuccessHandler
+		errorMappers       []ErrorMapper
+		errorHooks         []func(LeegoError, Context) LeegoError
 		binder             Binder
 		renderer           Renderer
+		renderBuffering    bool
+		multipartMemory    int64
+		copyBufferSize     int
 		pool               sync.Pool
 		debug              bool
+		strictNegotiation  bool
+		slashPolicy        SlashPolicy
+		healthPath         string
+		health             *prebuiltResponse
+		versionPath        string
+		version            *prebuiltResponse
+		readyPath          string
+		readiness          int32
 		router             *Router
+		requestDecryptor   func([]byte, Context) ([]byte, error)
+		decryptLimit       int64
+		responseEncryptor  func([]byte, Context) ([]byte, error)
 		logger             *logger.Logger
 	}
 
@@ -40,12 +69,33 @@ type (
 		Method  string
 		Path    string
 		Handler string
+		router  *Router
+		// handler holds the current `HandlerFunc` of the route.
+		handler *atomic.Value
 	}
 
 	// HTTPError represents an error that occurred while handling a request.
 	HTTPError struct {
 		Code    int
 		Message string
+
+		// Detail is an explanation specific to this occurrence of the error,
+		// sent as the `detail` member of `application/problem+json` responses.
+		Detail string
+
+		// Type is a URI reference identifying the problem type, sent as the
+		// `type` member of `application/problem+json` responses. Default value
+		// "about:blank".
+		Type string
+	}
+
+	// problem is the RFC 7807 body of an `application/problem+json` response.
+	problem struct {
+		Type     string `json:"type"`
+		Title    string `json:"title"`
+		Status   int    `json:"status"`
+		Detail   string `json:"detail,omitempty"`
+		Instance string `json:"instance,omitempty"`
 	}
 
 	// MiddlewareFunc defines a function to process middleware.
@@ -60,10 +110,36 @@ type (
 
 	ResponseHandler func(LeegoError, Context)
 
+	// ErrorMapper transforms an error returned by the handler chain before it
+	// reaches the HTTP error handler.
+	ErrorMapper func(LeegoError, Context) LeegoError
+
+	// SlashPolicy tells how request paths are canonicalized regarding a trailing
+	// slash. See `Leego#SetSlashPolicy()`.
+	SlashPolicy uint8
+
 	LeegoError interface {
 		Error() string
 	}
 
+	// prebuiltResponse is a response built once and served ahead of the router.
+	prebuiltResponse struct {
+		code          int
+		body          []byte
+		contentType   []string
+		contentLength []string
+	}
+
+	hijacker interface {
+		Hijack() (net.Conn, *bufio.ReadWriter, error)
+	}
+
+	// valuesSetter is implemented by the engine headers which can take the
+	// values of a header as is, e.g. `standard.Header`.
+	valuesSetter interface {
+		SetValues(string, []string)
+	}
+
 	// Validator is the interface that wraps the Validate function.
 	Validator interface {
 		Validate() error
@@ -88,6 +164,17 @@ const (
 	TRACE   = "TRACE"
 )
 
+// Slash policies
+const (
+	// SlashPolicyNone leaves trailing slashes as registered.
+	SlashPolicyNone SlashPolicy = iota
+	// SlashPolicyAdd adds a trailing slash, see `middleware.AddTrailingSlash()`.
+	SlashPolicyAdd
+	// SlashPolicyRemove removes a trailing slash, see
+	// `middleware.RemoveTrailingSlash()`.
+	SlashPolicyRemove
+)
+
 var (
 	methods = [...]string{
 		CONNECT,
@@ -100,6 +187,9 @@ var (
 		PUT,
 		TRACE,
 	}
+
+	// closureName matches the runtime names of closures, e.g. `main.main.func1`.
+	closureName = regexp.MustCompile(`\.func\d+(\.\d+)*$`)
 )
 
 // MIME types
@@ -111,6 +201,7 @@ const (
 	MIMEApplicationXML                   = "application/xml"
 	MIMEApplicationXMLCharsetUTF8        = MIMEApplicationXML + "; " + charsetUTF8
 	MIMEApplicationForm                  = "application/x-www-form-urlencoded"
+	MIMEApplicationProblemJSON           = "application/problem+json"
 	MIMEApplicationProtobuf              = "application/protobuf"
 	MIMEApplicationMsgpack               = "application/msgpack"
 	MIMETextHTML                         = "text/html"
@@ -118,35 +209,57 @@ const (
 	MIMETextPlain                        = "text/plain"
 	MIMETextPlainCharsetUTF8             = MIMETextPlain + "; " + charsetUTF8
 	MIMEMultipartForm                    = "multipart/form-data"
+	MIMEMultipartMixed                   = "multipart/mixed"
 	MIMEOctetStream                      = "application/octet-stream"
 )
 
+const (
+	readinessUnset int32 = iota
+	readinessReady
+	readinessNotReady
+)
+
 const (
 	charsetUTF8 = "charset=utf-8"
+
+	defaultMultipartMemory = 32 << 20 // 32 MB
+	defaultCopyBufferSize  = 32 << 10 // 32 KB
+	defaultDecryptLimit    = 10 << 20 // 10 MB
 )
 
 // Headers
 const (
+	HeaderAccept                        = "Accept"
 	HeaderAcceptEncoding                = "Accept-Encoding"
+	HeaderAcceptRanges                  = "Accept-Ranges"
 	HeaderAllow                         = "Allow"
 	HeaderAuthorization                 = "Authorization"
+	HeaderCacheControl                  = "Cache-Control"
 	HeaderContentDisposition            = "Content-Disposition"
 	HeaderContentEncoding               = "Content-Encoding"
 	HeaderContentLength                 = "Content-Length"
+	HeaderContentRange                  = "Content-Range"
 	HeaderContentType                   = "Content-Type"
 	HeaderCookie                        = "Cookie"
 	HeaderSetCookie                     = "Set-Cookie"
+	HeaderETag                          = "ETag"
 	HeaderIfModifiedSince               = "If-Modified-Since"
+	HeaderIfNoneMatch                   = "If-None-Match"
 	HeaderLastModified                  = "Last-Modified"
 	HeaderLocation                      = "Location"
+	HeaderRange                         = "Range"
+	HeaderRetryAfter                    = "Retry-After"
 	HeaderUpgrade                       = "Upgrade"
+	HeaderUserAgent                     = "User-Agent"
 	HeaderVary                          = "Vary"
 	HeaderWWWAuthenticate               = "WWW-Authenticate"
 	HeaderXForwardedProto               = "X-Forwarded-Proto"
 	HeaderXHTTPMethodOverride           = "X-HTTP-Method-Override"
 	HeaderXForwardedFor                 = "X-Forwarded-For"
 	HeaderXRealIP                       = "X-Real-IP"
+	HeaderXRequestID                    = "X-Request-ID"
 	HeaderServer                        = "Server"
+	HeaderServerTiming                  = "Server-Timing"
 	HeaderOrigin                        = "Origin"
 	HeaderAccessControlRequestMethod    = "Access-Control-Request-Method"
 	HeaderAccessControlRequestHeaders   = "Access-Control-Request-Headers"
@@ -172,23 +285,55 @@ var (
 	ErrNotFound                    = NewHTTPError(http.StatusNotFound)
 	ErrUnauthorized                = NewHTTPError(http.StatusUnauthorized)
 	ErrMethodNotAllowed            = NewHTTPError(http.StatusMethodNotAllowed)
+	ErrNotAcceptable               = NewHTTPError(http.StatusNotAcceptable)
 	ErrStatusRequestEntityTooLarge = NewHTTPError(http.StatusRequestEntityTooLarge)
+	ErrRangeNotSatisfiable         = NewHTTPError(http.StatusRequestedRangeNotSatisfiable)
 	ErrRendererNotRegistered       = errors.New("renderer not registered")
 	ErrInvalidRedirectCode         = errors.New("invalid redirect status code")
 	ErrCookieNotFound              = errors.New("cookie not found")
+	ErrResponseCommitted           = errors.New("response already committed")
+	ErrFlushNotSupported           = errors.New("response does not support flushing")
+	ErrHijackNotSupported          = errors.New("response does not support hijacking")
+
+	// ErrAborted is returned by `Context#AbortWithStatus()` and
+	// `Context#AbortWithJSON()` to signal that the response has already been
+	// written and the chain should stop.
+	ErrAborted = errors.New("aborted")
 )
 
 // Error handlers
 var (
 	NotFoundHandler = func(c Context) LeegoError {
+		if h := c.Leego().fallback; h != nil {
+			return h(c)
+		}
 		return ErrNotFound
 	}
 
 	MethodNotAllowedHandler = func(c Context) LeegoError {
+		allowed := c.Leego().Router().AllowedMethods(c.Request().URL().Path())
+		if len(allowed) > 0 {
+			c.Response().Header().Set(HeaderAllow, strings.Join(allowed, ", "))
+		}
 		return ErrMethodNotAllowed
 	}
 )
 
+// SetMeta sets the metadata value for key on the route, e.g. a tag read by
+// middleware through `Context#RouteMeta()`. It returns the route, so it can be
+// chained with the route registration, e.g.
+// `e.GET("/admin", h).SetMeta("requireAuth", true)`.
+func (r *Route) SetMeta(key string, value interface{}) *Route {
+	k := r.Method + r.Path
+	m := r.router.meta[k]
+	if m == nil {
+		m = make(map[string]interface{})
+		r.router.meta[k] = m
+	}
+	m[key] = value
+	return r
+}
+
 // NewHTTPError creates a new HTTPError instance.
 func NewHTTPError(code int, msg ...string) *HTTPError {
 	he := &HTTPError{Code: code, Message: http.StatusText(code)}
@@ -199,6 +344,14 @@ func NewHTTPError(code int, msg ...string) *HTTPError {
 	return he
 }
 
+// NewProblem creates a new HTTPError instance with a title and a detail, sent as
+// an RFC 7807 `application/problem+json` body to the clients accepting it.
+func NewProblem(status int, title, detail string) *HTTPError {
+	he := NewHTTPError(status, title)
+	he.Detail = detail
+	return he
+}
+
 // Error makes it compatible with `error` interface.
 func (e *HTTPError) Error() string {
 	return e.Message
@@ -206,7 +359,16 @@ func (e *HTTPError) Error() string {
 
 // New creates an instance of Echo.
 func New() (e *Leego) {
-	e = &Leego{maxParam: new(int)}
+	e = &Leego{
+		maxParam:        new(int),
+		renderBuffering: true,
+		multipartMemory: defaultMultipartMemory,
+		copyBufferSize:  defaultCopyBufferSize,
+		decryptLimit:    defaultDecryptLimit,
+		healthPath:      "/health",
+		versionPath:     "/version",
+		readyPath:       "/ready",
+	}
 	e.pool.New = func() interface{} {
 		return e.NewContext(nil, nil)
 	}
@@ -231,15 +393,80 @@ func (e *Leego) NewContext(req engine.Request, res engine.Response) Context {
 	}
 }
 
+// WarmPool pre-populates the context pool with n contexts, so a burst of
+// requests right after startup doesn't allocate them. The contexts are sized
+// for the routes registered so far, so call it once all the routes are.
+// Note that the pool may still drop idle contexts on garbage collection.
+func (e *Leego) WarmPool(n int) {
+	for i := 0; i < n; i++ {
+		e.pool.Put(e.NewContext(nil, nil))
+	}
+}
+
 func (e *Leego) ResponseHandler(err LeegoError, c Context) {
+	if err == ErrAborted {
+		return
+	}
+	if ec, ok := c.(*echoContext); ok && ec.hijacked {
+		// The connection is owned by the handler
+		return
+	}
+	for _, m := range e.errorMappers {
+		if err == nil {
+			break
+		}
+		err = m(err, c)
+	}
+	for _, fn := range e.errorHooks {
+		if err == nil {
+			break
+		}
+		err = fn(err, c)
+	}
 	if err != nil {
-		e.httpErrorHandler(err, c)
+		if c.Streaming() && c.Response().Committed() {
+			e.abortStream(err, c)
+			return
+		}
+		c.Error(err)
 	} else {
 		e.httpSuccessHandler(c)
 	}
 
 }
 
+// abortStream logs an error that occurred after a streamed response has been
+// committed and closes the connection, since a normal error response can no
+// longer be written.
+func (e *Leego) abortStream(err LeegoError, c Context) {
+	if e.logger != nil {
+		e.logger.Errorf("leego: streaming %s aborted: %v", c.Request().URI(), err)
+	}
+	if h, ok := c.Response().(hijacker); ok {
+		if conn, _, err := h.Hijack(); err == nil {
+			conn.Close()
+		}
+	}
+}
+
+// DrainRoute makes the route registered for method and path, e.g.
+// `GET /users/:id`, respond with 503 and a `Retry-After` header while the other
+// routes keep serving, to take an endpoint offline without redeploying. It can
+// be called while serving and is reverted with `UndrainRoute()`.
+func (e *Leego) DrainRoute(method, path string) {
+	e.router.setDrained(method, path, true)
+}
+
+// UndrainRoute makes a route drained with `DrainRoute()` serve again.
+func (e *Leego) UndrainRoute(method, path string) {
+	e.router.setDrained(method, path, false)
+}
+
+// Routes returns the registered routes, sorted by path then method.
+func (e *Leego) Routes() []Route {
+	return e.router.Routes()
+}
+
 // Router returns router.
 func (e *Leego) Router() *Router {
 	return e.router
@@ -249,23 +476,54 @@ func (e *Leego) Router() *Router {
 func (e *Leego) DefaultHTTPErrorHandler(err LeegoError, c Context) {
 	code := http.StatusInternalServerError
 	msg := http.StatusText(code)
+	p := problem{Type: "about:blank"}
 	if he, ok := err.(*HTTPError); ok {
 		code = he.Code
 		msg = he.Message
+		p.Detail = he.Detail
+		if he.Type != "" {
+			p.Type = he.Type
+		}
 	}
+	p.Title = msg
 	if e.debug {
 		msg = err.Error()
+		if p.Detail == "" {
+			p.Detail = msg
+		}
 	}
 	if !c.Response().Committed() {
 		if c.Request().Method() == HEAD {
 			// Issue #608
 			c.NoContent(code)
+		} else if acceptsProblem(c.Request().Header().Get(HeaderAccept)) {
+			p.Status = code
+			p.Instance = c.Request().URL().Path()
+			b, _ := json.Marshal(p)
+			res := c.Response()
+			res.Header().Set(HeaderContentType, MIMEApplicationProblemJSON)
+			res.WriteHeader(code)
+			res.Write(b)
 		} else {
 			c.String(code, msg)
 		}
 	}
 }
 
+// acceptsProblem returns true if an `Accept` header explicitly accepts
+// `application/problem+json`, wildcards aside.
+func acceptsProblem(accept string) bool {
+	if accept == "" {
+		return false
+	}
+	for _, spec := range ParseAccept(accept) {
+		if spec.Quality > 0 && spec.MediaType() == MIMEApplicationProblemJSON {
+			return true
+		}
+	}
+	return false
+}
+
 func (e *Leego) DefaultHTTPSuccessHandler(c Context) {}
 
 func (e *Leego) SetHTTPErrorHandler(h HTTPErrorHandler) {
@@ -276,6 +534,202 @@ func (e *Leego) SetHTTPSuccessHandler(h HTTPSuccessHandler) {
 	e.httpSuccessHandler = h
 }
 
+// OnError adds error mappers which are run in order on an error returned by
+// the handler chain before it reaches the HTTP error handler. Each mapper can
+// transform the error or pass it through unchanged, which allows mapping
+// domain errors to HTTP errors in one place. A mapper returning nil marks the
+// error as handled.
+func (e *Leego) OnError(m ...ErrorMapper) {
+	e.errorMappers = append(e.errorMappers, m...)
+}
+
+// UseOnError adds a function to the error pipeline, e.g. to enrich errors,
+// alert or count failures. The pipeline runs only when the handler chain
+// returns an error other than `ErrAborted`, after the whole chain and the error
+// mappers added with `OnError()`, so it sees the mapped error, and before the
+// HTTP error handler. Its functions run in the registration order, each
+// receiving the error returned by the previous one; returning nil marks the
+// error as handled and ends the pipeline.
+func (e *Leego) UseOnError(fn func(LeegoError, Context) LeegoError) {
+	e.errorHooks = append(e.errorHooks, fn)
+}
+
+// SetStrictNegotiation sets whether `Context#Negotiate()` fails with
+// `ErrNotAcceptable` when the `Accept` header can't be satisfied. By default it
+// falls back to JSON.
+func (e *Leego) SetStrictNegotiation(on bool) {
+	e.strictNegotiation = on
+}
+
+// SetSniffContentType sets whether the default binder guesses JSON or XML
+// from the body of a request without `Content-Type`. It has no effect with a
+// custom `Binder`.
+func (e *Leego) SetSniffContentType(on bool) {
+	if b, ok := e.binder.(*binder); ok {
+		b.SetSniffContentType(on)
+	}
+}
+
+// SetDefaultsOnZero sets whether the default binder applies the `default` tag
+// values to the fields bound to their zero value, not only to those absent from
+// the input. It has no effect with a custom `Binder`.
+func (e *Leego) SetDefaultsOnZero(on bool) {
+	if b, ok := e.binder.(*binder); ok {
+		b.SetDefaultsOnZero(on)
+	}
+}
+
+// SetSlashPolicy tells the trailing slash policy of the slash middleware in
+// use, so that `URI()` generates the canonical form of paths which is not
+// redirected. It doesn't change request matching.
+func (e *Leego) SetSlashPolicy(p SlashPolicy) {
+	e.slashPolicy = p
+}
+
+// SetHealthResponse sets a pre-built response served for GET and HEAD requests
+// to the health path, see `SetHealthPath()`. It's matched first thing in
+// `ServeHTTP()`, bypassing the router and all middleware, so high-volume health
+// checks are cheap and don't show up in logs.
+func (e *Leego) SetHealthResponse(code int, body []byte, contentType string) {
+	e.health = newPrebuiltResponse(code, body, contentType)
+}
+
+func newPrebuiltResponse(code int, body []byte, contentType string) *prebuiltResponse {
+	// The header values are shared by the responses, whose header gets them as is
+	return &prebuiltResponse{
+		code:          code,
+		body:          body,
+		contentType:   []string{contentType},
+		contentLength: []string{strconv.Itoa(len(body))},
+	}
+}
+
+// SetHealthPath sets the path of the health response. Default value "/health".
+func (e *Leego) SetHealthPath(path string) {
+	e.healthPath = path
+}
+
+// serve writes the response if set and the request is a GET or HEAD request to
+// path, and returns true if so.
+func (h *prebuiltResponse) serve(path string, req engine.Request, res engine.Response) bool {
+	if h == nil || req.URL().Path() != path {
+		return false
+	}
+	m := req.Method()
+	if m != GET && m != HEAD {
+		return false
+	}
+	if vs, ok := res.Header().(valuesSetter); ok {
+		vs.SetValues(HeaderContentType, h.contentType)
+		vs.SetValues(HeaderContentLength, h.contentLength)
+	} else {
+		res.Header().Set(HeaderContentType, h.contentType[0])
+		res.Header().Set(HeaderContentLength, h.contentLength[0])
+	}
+	res.WriteHeader(h.code)
+	if m == GET {
+		res.Write(h.body)
+	}
+	return true
+}
+
+// SetVersionInfo sets the build metadata, e.g. the version, git SHA and build
+// time injected with `-ldflags`, served as JSON to GET and HEAD requests to the
+// version path, see `SetVersionPath()`. The response is built once and served
+// ahead of the router, like the health response. No version endpoint is served
+// unless set.
+func (e *Leego) SetVersionInfo(info map[string]string) {
+	b, err := json.Marshal(info)
+	if err != nil {
+		panic(err)
+	}
+	e.version = newPrebuiltResponse(http.StatusOK, b, MIMEApplicationJSONCharsetUTF8)
+}
+
+// SetVersionPath sets the path of the version endpoint. Default value "/version".
+func (e *Leego) SetVersionPath(path string) {
+	e.versionPath = path
+}
+
+// SetReady sets whether the instance is ready to receive traffic, as reported by
+// the readiness endpoint, see `SetReadyPath()`: 200 when ready, 503 otherwise, so
+// load balancers stop routing new requests to an instance being drained while
+// in-flight ones complete. The endpoint is served, ahead of the router like the
+// health response, once `SetReady()` has been called. It's safe for concurrent
+// use.
+func (e *Leego) SetReady(ready bool) {
+	r := readinessNotReady
+	if ready {
+		r = readinessReady
+	}
+	atomic.StoreInt32(&e.readiness, r)
+}
+
+// Ready returns true unless the instance was set not ready with `SetReady()`.
+func (e *Leego) Ready() bool {
+	return atomic.LoadInt32(&e.readiness) != readinessNotReady
+}
+
+// SetReadyPath sets the path of the readiness endpoint. Default value "/ready".
+func (e *Leego) SetReadyPath(path string) {
+	e.readyPath = path
+}
+
+// serveReady writes the readiness response if enabled and the request is a
+// readiness check, and returns true if so.
+func (e *Leego) serveReady(req engine.Request, res engine.Response) bool {
+	r := atomic.LoadInt32(&e.readiness)
+	if r == readinessUnset || req.URL().Path() != e.readyPath {
+		return false
+	}
+	m := req.Method()
+	if m != GET && m != HEAD {
+		return false
+	}
+	code := http.StatusOK
+	if r == readinessNotReady {
+		code = http.StatusServiceUnavailable
+	}
+	body := http.StatusText(code)
+	res.Header().Set(HeaderContentType, MIMETextPlainCharsetUTF8)
+	res.Header().Set(HeaderContentLength, strconv.Itoa(len(body)))
+	res.WriteHeader(code)
+	if m == GET {
+		res.Write([]byte(body))
+	}
+	return true
+}
+
+// SetRenderer registers an HTML template renderer. It's invoked by
+// `Context#Render()`.
+func (e *Leego) SetRenderer(r Renderer) {
+	e.renderer = r
+}
+
+// SetRenderBuffering sets whether `Context#Render()` buffers the rendered
+// output before writing it, so a failing template results in a clean error
+// response. It's enabled by default; disable it for streaming templates.
+func (e *Leego) SetRenderBuffering(on bool) {
+	e.renderBuffering = on
+}
+
+// SetMultipartMemory sets the maximum number of bytes of a multipart form's
+// file parts held in memory, the remainder is stored on disk in temporary
+// files. Default value is 32 MB.
+func (e *Leego) SetMultipartMemory(maxMemory int64) {
+	e.multipartMemory = maxMemory
+}
+
+// SetCopyBufferSize sets the size of the chunks `Context#Copy()` copies between
+// checks for request cancellation. Default value is 32 KB, which a size <= 0
+// resets it to.
+func (e *Leego) SetCopyBufferSize(size int) {
+	if size <= 0 {
+		size = defaultCopyBufferSize
+	}
+	e.copyBufferSize = size
+}
+
 // SetBinder registers a custom binder. It's invoked by `Context#Bind()`.
 func (e *Leego) SetBinder(b Binder) {
 	e.binder = b
@@ -286,6 +740,21 @@ func (e *Leego) Binder() Binder {
 	return e.binder
 }
 
+// Intercept registers a function which is run for every request ahead of the
+// pre-middleware, in the registration order. It vetoes the request by returning
+// `proceed` false, in which case the returned error, if any, is handled as the
+// handler's would be, e.g. for a maintenance mode:
+//
+//	e.Intercept(func(c leego.Context) (bool, leego.LeegoError) {
+//		if maintenance.Load() {
+//			return false, leego.NewHTTPError(http.StatusServiceUnavailable)
+//		}
+//		return true, nil
+//	})
+func (e *Leego) Intercept(fn func(c Context) (proceed bool, err LeegoError)) {
+	e.interceptors = append(e.interceptors, fn)
+}
+
 // Pre adds middleware to the chain which is run before router.
 func (e *Leego) Pre(middleware ...MiddlewareFunc) {
 	e.premiddleware = append(e.premiddleware, middleware...)
@@ -296,142 +765,208 @@ func (e *Leego) Use(middleware ...MiddlewareFunc) {
 	e.middleware = append(e.middleware, middleware...)
 }
 
+// MiddlewareStack returns the names of the pre-middleware followed by the
+// middleware, in the order they run, e.g. to check the chain is wired as
+// expected or print it at startup. Named functions are reported by their full
+// name, while closures, including those returned by middleware constructors,
+// are reported as "anonymous@file:line".
+func (e *Leego) MiddlewareStack() []string {
+	stack := make([]string, 0, len(e.premiddleware)+len(e.middleware))
+	for _, m := range e.premiddleware {
+		stack = append(stack, middlewareName(m))
+	}
+	for _, m := range e.middleware {
+		stack = append(stack, middlewareName(m))
+	}
+	return stack
+}
+
 // CONNECT registers a new CONNECT route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *Leego) CONNECT(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.add(CONNECT, path, h, m...)
+func (e *Leego) CONNECT(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.add(CONNECT, path, h, m...)
 }
 
 // Connect is deprecated, use `CONNECT()` instead.
-func (e *Leego) Connect(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.CONNECT(path, h, m...)
+func (e *Leego) Connect(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.CONNECT(path, h, m...)
 }
 
 // DELETE registers a new DELETE route for a path with matching handler in the router
 // with optional route-level middleware.
-func (e *Leego) DELETE(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.add(DELETE, path, h, m...)
+func (e *Leego) DELETE(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.add(DELETE, path, h, m...)
 }
 
 // Delete is deprecated, use `DELETE()` instead.
-func (e *Leego) Delete(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.DELETE(path, h, m...)
+func (e *Leego) Delete(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.DELETE(path, h, m...)
 }
 
 // GET registers a new GET route for a path with matching handler in the router
 // with optional route-level middleware.
-func (e *Leego) GET(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.add(GET, path, h, m...)
+func (e *Leego) GET(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.add(GET, path, h, m...)
 }
 
 // Get is deprecated, use `GET()` instead.
-func (e *Leego) Get(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.GET(path, h, m...)
+func (e *Leego) Get(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.GET(path, h, m...)
 }
 
 // HEAD registers a new HEAD route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *Leego) HEAD(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.add(HEAD, path, h, m...)
+func (e *Leego) HEAD(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.add(HEAD, path, h, m...)
 }
 
 // Head is deprecated, use `HEAD()` instead.
-func (e *Leego) Head(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.HEAD(path, h, m...)
+func (e *Leego) Head(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.HEAD(path, h, m...)
 }
 
 // OPTIONS registers a new OPTIONS route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *Leego) OPTIONS(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.add(OPTIONS, path, h, m...)
+func (e *Leego) OPTIONS(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.add(OPTIONS, path, h, m...)
 }
 
 // Options is deprecated, use `OPTIONS()` instead.
-func (e *Leego) Options(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.OPTIONS(path, h, m...)
+func (e *Leego) Options(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.OPTIONS(path, h, m...)
 }
 
 // PATCH registers a new PATCH route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *Leego) PATCH(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.add(PATCH, path, h, m...)
+func (e *Leego) PATCH(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.add(PATCH, path, h, m...)
 }
 
 // Patch is deprecated, use `PATCH()` instead.
-func (e *Leego) Patch(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.PATCH(path, h, m...)
+func (e *Leego) Patch(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.PATCH(path, h, m...)
 }
 
 // POST registers a new POST route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *Leego) POST(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.add(POST, path, h, m...)
+func (e *Leego) POST(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.add(POST, path, h, m...)
 }
 
 // Post is deprecated, use `POST()` instead.
-func (e *Leego) Post(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.POST(path, h, m...)
+func (e *Leego) Post(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.POST(path, h, m...)
 }
 
 // PUT registers a new PUT route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *Leego) PUT(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.add(PUT, path, h, m...)
+func (e *Leego) PUT(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.add(PUT, path, h, m...)
 }
 
 // Put is deprecated, use `PUT()` instead.
-func (e *Leego) Put(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.PUT(path, h, m...)
+func (e *Leego) Put(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.PUT(path, h, m...)
 }
 
 // TRACE registers a new TRACE route for a path with matching handler in the
 // router with optional route-level middleware.
-func (e *Leego) TRACE(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.add(TRACE, path, h, m...)
+func (e *Leego) TRACE(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.add(TRACE, path, h, m...)
 }
 
 // Trace is deprecated, use `TRACE()` instead.
-func (e *Leego) Trace(path string, h HandlerFunc, m ...MiddlewareFunc) {
-	e.TRACE(path, h, m...)
+func (e *Leego) Trace(path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	return e.TRACE(path, h, m...)
 }
 
 // Any registers a new route for all HTTP methods and path with matching handler
 // in the router with optional route-level middleware.
-func (e *Leego) Any(path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+func (e *Leego) Any(path string, handler HandlerFunc, middleware ...MiddlewareFunc) []*Route {
+	routes := make([]*Route, 0, len(methods))
 	for _, m := range methods {
-		e.add(m, path, handler, middleware...)
+		routes = append(routes, e.add(m, path, handler, middleware...))
 	}
+	return routes
 }
 
 // Match registers a new route for multiple HTTP methods and path with matching
 // handler in the router with optional route-level middleware.
-func (e *Leego) Match(methods []string, path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+func (e *Leego) Match(methods []string, path string, handler HandlerFunc, middleware ...MiddlewareFunc) []*Route {
+	routes := make([]*Route, 0, len(methods))
 	for _, m := range methods {
-		e.add(m, path, handler, middleware...)
+		routes = append(routes, e.add(m, path, handler, middleware...))
+	}
+	return routes
+}
+
+func (e *Leego) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route {
+	return e.add(method, path, handler, middleware...)
+}
+
+// AddIf registers a new route like `Add()` if cond is true, e.g. for debug-only
+// or feature-gated routes. Otherwise the route isn't registered at all and nil
+// is returned.
+func (e *Leego) AddIf(cond bool, method, path string, h HandlerFunc, m ...MiddlewareFunc) *Route {
+	if !cond {
+		return nil
 	}
+	return e.add(method, path, h, m...)
 }
 
-func (e *Leego) Add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
-	e.add(method, path, handler, middleware...)
+// ReplaceHandler swaps the handler of the route registered for method and path
+// with h, keeping its middleware, e.g. to reload handlers while developing. The
+// swap is atomic: each request runs either the old or the new handler. It's
+// only allowed in debug mode, see `SetDebug()`, and returns an error if the
+// route doesn't exist or h is nil. Reverse routing with `URI()` still refers to
+// the original handler.
+func (e *Leego) ReplaceHandler(method, path string, h HandlerFunc) error {
+	if !e.debug {
+		return errors.New("leego: replacing handlers requires debug mode")
+	}
+	if h == nil {
+		return errors.New("leego: replacement handler can't be nil")
+	}
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+	r, ok := e.router.routes[method+path]
+	if !ok || r.handler == nil {
+		return fmt.Errorf("leego: no route for %s %s", method, path)
+	}
+	r.handler.Store(h)
+	return nil
 }
 
-func (e *Leego) add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) {
+func (e *Leego) add(method, path string, handler HandlerFunc, middleware ...MiddlewareFunc) *Route {
 	name := handlerName(handler)
+	// Loaded on every request, so it can be swapped by `ReplaceHandler()`
+	current := new(atomic.Value)
+	current.Store(handler)
 	e.router.Add(method, path, func(c Context) LeegoError {
-		h := handler
+		h := current.Load().(HandlerFunc)
 		// Chain middleware
 		for i := len(middleware) - 1; i >= 0; i-- {
 			h = middleware[i](h)
 		}
 		return h(c)
 	}, e)
-	r := Route{
+	if path[0] != '/' {
+		path = "/" + path
+	}
+	r := &Route{
 		Method:  method,
 		Path:    path,
 		Handler: name,
+		router:  e.router,
+		handler: current,
 	}
 
 	e.router.routes[method+path] = r
+	if _, ok := e.router.names[name]; !ok {
+		e.router.names[name] = r
+	}
+	return r
 }
 
 // Logger returns the logger instance.
@@ -444,11 +979,57 @@ func (e *Leego) SetLogger(l *logger.Logger) {
 	e.logger = l
 }
 
+// SetDebug enables/disables debug mode, in which error responses carry the
+// error message and debugging aids, e.g. `middleware.RequestReplay()`, are on.
+func (e *Leego) SetDebug(on bool) {
+	e.debug = on
+}
+
+// Debug returns debug mode (enabled or disabled).
+func (e *Leego) Debug() bool {
+	return e.debug
+}
+
 func (e *Leego) ServeHTTP(req engine.Request, res engine.Response) {
+	if e.health.serve(e.healthPath, req, res) || e.serveReady(req, res) ||
+		e.version.serve(e.versionPath, req, res) {
+		return
+	}
+	if !e.track() {
+		res.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	defer e.wg.Done()
 	c := e.pool.Get().(*echoContext)
 	c.Reset(req, res)
+	// Always return the context to the pool, even if a handler panics, without
+	// holding on to the request state.
+	defer func() {
+		c.runDeferred()
+		c.Reset(nil, nil)
+		e.pool.Put(c)
+	}()
 	c.SetLang(req.Header().Get("Accept-Language"))
 
+	if e.responseEncryptor != nil {
+		// Encrypts the response once written, error responses included. Deferred
+		// first, so it runs last, once e.g. `Gzip()` has ended its stream.
+		e.encryptResponse(c)
+	}
+
+	for _, fn := range e.interceptors {
+		if proceed, err := fn(c); !proceed {
+			if err != nil {
+				e.ResponseHandler(err, c)
+			}
+			return
+		}
+	}
+
+	if e.requestDecryptor != nil {
+		e.decryptRequest(c)
+	}
+
 	// Middleware
 	h := func(Context) LeegoError {
 		method := req.Method()
@@ -469,15 +1050,82 @@ func (e *Leego) ServeHTTP(req engine.Request, res engine.Response) {
 	// Execute chain
 	err := h(c)
 	e.ResponseHandler(err, c)
-
-	e.pool.Put(c)
 }
 
 // Run starts the HTTP server.
 func (e *Leego) Run(s engine.Server) {
+	e.Start(s)
+}
+
+// Start starts the HTTP server and blocks until it stops. It returns the error
+// the server stopped with, or nil if stopped by `Shutdown()`.
+func (e *Leego) Start(s engine.Server) error {
 	s.SetLogger(e.logger)
 	s.SetHandler(e)
-	s.Start()
+	e.shutdownMu.Lock()
+	e.server = s
+	e.shutdownMu.Unlock()
+	err := s.Start()
+	e.shutdownMu.RLock()
+	defer e.shutdownMu.RUnlock()
+	if e.shuttingDown {
+		return nil
+	}
+	return err
+}
+
+// Shutdown gracefully stops the server started with `Start()` or `Run()`: it
+// stops accepting new connections, answers the requests still coming in on open
+// connections with 503, and waits for the in-flight requests to complete. It
+// returns the context error if the context is done first. To drain load balancer
+// traffic beforehand, report the instance not ready with `SetReady(false)`.
+func (e *Leego) Shutdown(ctx context.Context) error {
+	e.shutdownMu.Lock()
+	e.shuttingDown = true
+	s := e.server
+	e.shutdownMu.Unlock()
+	if s != nil {
+		s.Stop()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// track registers an in-flight request, unless shutting down, in which case it
+// returns false.
+func (e *Leego) track() bool {
+	e.shutdownMu.RLock()
+	defer e.shutdownMu.RUnlock()
+	if e.shuttingDown {
+		return false
+	}
+	e.wg.Add(1)
+	return true
+}
+
+// Fallback registers a handler with optional route-level middleware for the
+// requests which match no route, replacing the generic 404, e.g. to serve the
+// index page of a single-page application. Registered routes always take
+// precedence regardless of the registration order.
+func (e *Leego) Fallback(handler HandlerFunc, middleware ...MiddlewareFunc) {
+	e.fallback = func(c Context) LeegoError {
+		h := handler
+		// Chain middleware
+		for i := len(middleware) - 1; i >= 0; i-- {
+			h = middleware[i](h)
+		}
+		return h(c)
+	}
 }
 
 // Group creates a new router group with prefix and optional group-level middleware.
@@ -487,29 +1135,50 @@ func (e *Leego) Group(prefix string, m ...MiddlewareFunc) (g *Group) {
 	return
 }
 
-// URI generates a URI from handler.
+// URI generates a URI from handler. If the handler is registered for several
+// routes, the first registered one is used. The trailing slash follows the
+// policy set with `SetSlashPolicy()`.
 func (e *Leego) URI(handler HandlerFunc, params ...interface{}) string {
+	r, ok := e.router.names[handlerName(handler)]
+	if !ok {
+		return ""
+	}
 	uri := new(bytes.Buffer)
 	ln := len(params)
 	n := 0
-	name := handlerName(handler)
-	for _, r := range e.router.routes {
-		if r.Handler == name {
-			for i, l := 0, len(r.Path); i < l; i++ {
-				if r.Path[i] == ':' && n < ln {
-					for ; i < l && r.Path[i] != '/'; i++ {
-					}
-					uri.WriteString(fmt.Sprintf("%v", params[n]))
-					n++
-				}
-				if i < l {
-					uri.WriteByte(r.Path[i])
-				}
+	for i, l := 0, len(r.Path); i < l; i++ {
+		if r.Path[i] == ':' && n < ln {
+			for ; i < l && r.Path[i] != '/'; i++ {
 			}
+			uri.WriteString(fmt.Sprintf("%v", params[n]))
+			n++
+		} else if r.Path[i] == '*' && n < ln {
+			// The catch-all is last
+			uri.WriteString(fmt.Sprintf("%v", params[n]))
 			break
 		}
+		if i < l {
+			uri.WriteByte(r.Path[i])
+		}
 	}
-	return uri.String()
+	return canonicalSlash(uri.String(), e.slashPolicy)
+}
+
+// canonicalSlash adds or removes the trailing slash of path according to p.
+// The root path is left as is.
+func canonicalSlash(path string, p SlashPolicy) string {
+	if path == "" || path == "/" {
+		return path
+	}
+	switch p {
+	case SlashPolicyAdd:
+		if !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+	case SlashPolicyRemove:
+		path = strings.TrimRight(path, "/")
+	}
+	return path
 }
 
 // URL is an alias for `URI` function.
@@ -529,6 +1198,21 @@ func WrapMiddleware(h HandlerFunc) MiddlewareFunc {
 	}
 }
 
+// middlewareName returns the name of the function m, or "anonymous@file:line"
+// for a closure.
+func middlewareName(m MiddlewareFunc) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(m).Pointer())
+	if fn == nil {
+		return "anonymous"
+	}
+	name := fn.Name()
+	if !closureName.MatchString(name) {
+		return name
+	}
+	file, line := fn.FileLine(fn.Entry())
+	return fmt.Sprintf("anonymous@%s:%d", filepath.Base(file), line)
+}
+
 func handlerName(h HandlerFunc) string {
 	t := reflect.ValueOf(h).Type()
 	if t.Kind() == reflect.Func {
@@ -0,0 +1,787 @@
+package leego
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+
+	"golang.org/x/net/context"
+
+	"github.com/go-wyvern/leego/engine"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeegoStreamingError(t *testing.T) {
+	e := New()
+	called := false
+	e.SetHTTPErrorHandler(func(err LeegoError, c Context) {
+		called = true
+	})
+	e.GET("/stream", func(c Context) LeegoError {
+		c.SetStreaming(true)
+		c.Response().WriteHeader(http.StatusOK)
+		c.Response().Write([]byte("data: 1\n\n"))
+		return errors.New("upstream failed")
+	})
+
+	req := test.NewRequest(GET, "/stream", nil)
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.False(t, called)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "data: 1\n\n", rec.Body.String())
+
+	// Error before anything is streamed still goes through the error handler
+	e.GET("/early", func(c Context) LeegoError {
+		c.SetStreaming(true)
+		return errors.New("early failure")
+	})
+	req = test.NewRequest(GET, "/early", nil)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.True(t, called)
+}
+
+type recordNotFoundError struct {
+	id string
+}
+
+func (e *recordNotFoundError) Error() string {
+	return "record " + e.id + " not found"
+}
+
+func TestLeegoOnError(t *testing.T) {
+	e := New()
+	e.OnError(func(err LeegoError, c Context) LeegoError {
+		if _, ok := err.(*recordNotFoundError); ok {
+			return ErrNotFound
+		}
+		return err
+	})
+	e.GET("/users/:id", func(c Context) LeegoError {
+		return &recordNotFoundError{id: c.Param("id")}
+	})
+	e.GET("/fail", func(c Context) LeegoError {
+		return errors.New("boom")
+	})
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/users/1", nil), rec)
+	assert.Equal(t, http.StatusNotFound, rec.Status())
+
+	// Unmapped errors pass through
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/fail", nil), rec)
+	assert.Equal(t, http.StatusInternalServerError, rec.Status())
+}
+
+func TestLeegoServeHTTPPanic(t *testing.T) {
+	e := New()
+	var pc Context
+	e.GET("/panic", func(c Context) LeegoError {
+		pc = c
+		panic("oops")
+	})
+	e.GET("/", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "OK")
+	})
+
+	func() {
+		defer func() {
+			assert.Equal(t, "oops", recover())
+		}()
+		e.ServeHTTP(test.NewRequest(GET, "/panic", nil), test.NewResponseRecorder())
+	}()
+	// Context is reset and returned to the pool
+	assert.Nil(t, pc.Request())
+	assert.Nil(t, pc.Response())
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+}
+
+func TestGroupHTTPErrorHandler(t *testing.T) {
+	e := New()
+	api := e.Group("/api")
+	api.SetHTTPErrorHandler(func(err LeegoError, c Context) {
+		c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+	})
+	web := e.Group("/web")
+	web.SetHTTPErrorHandler(func(err LeegoError, c Context) {
+		c.HTML(http.StatusBadRequest, "<p>"+err.Error()+"</p>")
+	})
+	h := func(c Context) LeegoError {
+		return errors.New("invalid")
+	}
+	api.GET("/users", h)
+	web.GET("/users", h)
+	e.GET("/users", h)
+	// Sub-groups inherit the handler
+	api.Group("/v1").GET("/users", h)
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/api/users", nil), rec)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+	assert.Equal(t, `{"error":"invalid"}`, rec.Body.String())
+
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/web/users", nil), rec)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+	assert.Equal(t, "<p>invalid</p>", rec.Body.String())
+
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/api/v1/users", nil), rec)
+	assert.Equal(t, `{"error":"invalid"}`, rec.Body.String())
+
+	// Routes outside the groups use the default handler
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/users", nil), rec)
+	assert.Equal(t, http.StatusInternalServerError, rec.Status())
+}
+
+func TestLeegoFallback(t *testing.T) {
+	e := New()
+	e.Fallback(func(c Context) LeegoError {
+		return c.String(http.StatusOK, "index "+c.Get("mw").(string))
+	}, func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			c.Set("mw", "ok")
+			return next(c)
+		}
+	})
+	// Routes registered after the fallback still win
+	e.GET("/users/:id", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "user "+c.Param("id"))
+	})
+	e.GET("/static/*", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "static")
+	})
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/users/1", nil), rec)
+	assert.Equal(t, "user 1", rec.Body.String())
+
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/static/app.js", nil), rec)
+	assert.Equal(t, "static", rec.Body.String())
+
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/settings/profile", nil), rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "index ok", rec.Body.String())
+
+	// Method not allowed is not a miss
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(POST, "/users/1", nil), rec)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Status())
+}
+
+func userHandler(c Context) LeegoError {
+	return nil
+}
+
+func TestLeegoURI(t *testing.T) {
+	e := New()
+	e.GET("/users/:id/files/:file", userHandler)
+	// The first registered route wins
+	for i := 0; i < 10; i++ {
+		e.GET("/v"+strconv.Itoa(i)+"/users/:id", userHandler)
+	}
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, "/users/1/files/a.txt", e.URI(userHandler, 1, "a.txt"))
+	}
+	assert.Equal(t, "", e.URI(func(c Context) LeegoError { return nil }))
+
+	// Catch-all
+	e.GET("/static/:version/*filepath", staticHandler)
+	assert.Equal(t, "/static/v2/js/app.js", e.URI(staticHandler, "v2", "js/app.js"))
+}
+
+func staticHandler(c Context) LeegoError {
+	return nil
+}
+
+func BenchmarkLeegoURI(b *testing.B) {
+	e := New()
+	h := func(c Context) LeegoError { return nil }
+	for i := 0; i < 5000; i++ {
+		e.GET("/resources/"+strconv.Itoa(i)+"/:id", h)
+	}
+	e.GET("/users/:id/files/:file", userHandler)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.URI(userHandler, 1, "a.txt")
+	}
+}
+
+func TestLeegoURISlashPolicy(t *testing.T) {
+	e := New()
+	e.GET("/users/:id", userHandler)
+	h := func(c Context) LeegoError { return nil }
+	e.GET("/", h)
+
+	assert.Equal(t, "/users/1", e.URI(userHandler, 1))
+
+	e.SetSlashPolicy(SlashPolicyAdd)
+	assert.Equal(t, "/users/1/", e.URI(userHandler, 1))
+	assert.Equal(t, "/", e.URI(h))
+
+	e = New()
+	e.GET("/users/:id/", userHandler)
+	e.SetSlashPolicy(SlashPolicyRemove)
+	assert.Equal(t, "/users/1", e.URI(userHandler, 1))
+}
+
+func TestResponseSetStatus(t *testing.T) {
+	e := New()
+	// Envelope middleware reporting failures with 200
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			err := next(c)
+			if res := c.Response(); res.Status() == http.StatusInternalServerError {
+				res.SetStatus(http.StatusOK)
+				return c.JSON(http.StatusOK, map[string]interface{}{"ok": false})
+			}
+			return err
+		}
+	})
+	e.GET("/fail", func(c Context) LeegoError {
+		return c.NoContent(http.StatusInternalServerError)
+	})
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/fail", nil), rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, `{"ok":false}`, rec.Body.String())
+
+	// No-op once the header is written
+	e.GET("/written", func(c Context) LeegoError {
+		c.String(http.StatusInternalServerError, "failed")
+		c.Response().SetStatus(http.StatusOK)
+		return nil
+	})
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/written", nil), rec)
+	assert.Equal(t, http.StatusInternalServerError, rec.Status())
+}
+
+type requestIDKey struct{}
+
+func TestLeegoContextValuePropagation(t *testing.T) {
+	e := New()
+	e.Pre(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			c.WithValue(requestIDKey{}, "abc")
+			c.Set("user", "jon")
+			return next(c)
+		}
+	})
+	var seen []interface{}
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			seen = append(seen, c.Value(requestIDKey{}))
+			return next(c)
+		}
+	})
+	e.GET("/", func(c Context) LeegoError {
+		seen = append(seen, c.Value(requestIDKey{}), c.Get("user"), c.Context().Value(requestIDKey{}))
+		return nil
+	}, func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			seen = append(seen, c.Get("user"))
+			return next(c)
+		}
+	})
+
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), test.NewResponseRecorder())
+	assert.Equal(t, []interface{}{"abc", "jon", "abc", "jon", "abc"}, seen)
+}
+
+func TestLeegoHealthResponse(t *testing.T) {
+	e := New()
+	called := false
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			called = true
+			return next(c)
+		}
+	})
+	e.SetHealthResponse(http.StatusOK, []byte(`{"status":"ok"}`), MIMEApplicationJSON)
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/health", nil), rec)
+	assert.False(t, called)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, MIMEApplicationJSON, rec.Header().Get(HeaderContentType))
+	assert.Equal(t, "15", rec.Header().Get(HeaderContentLength))
+	assert.Equal(t, `{"status":"ok"}`, rec.Body.String())
+
+	// The prebuilt header values aren't changed through a response
+	rec.Header().Add(HeaderContentType, "text/plain")
+	rec = test.NewResponseRecorder()
+	req := test.NewRequest(GET, "/health", nil)
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, []string{MIMEApplicationJSON}, rec.Header().Values(HeaderContentType))
+	allocs := testing.AllocsPerRun(10, func() {
+		e.ServeHTTP(req, rec)
+		rec.Body.Reset()
+	})
+	assert.Zero(t, allocs)
+
+	e.SetHealthPath("/_ping")
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/_ping", nil), rec)
+	assert.False(t, called)
+	assert.Equal(t, `{"status":"ok"}`, rec.Body.String())
+
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/health", nil), rec)
+	assert.True(t, called)
+	assert.Equal(t, http.StatusNotFound, rec.Status())
+}
+
+func benchmarkHealth(b *testing.B, e *Leego) {
+	req := test.NewRequest(GET, "/health", nil)
+	rec := test.NewResponseRecorder()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e.ServeHTTP(req, rec)
+		rec.Body.Reset()
+	}
+}
+
+func BenchmarkLeegoHealthResponse(b *testing.B) {
+	e := New()
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError { return next(c) }
+	})
+	e.SetHealthResponse(http.StatusOK, []byte("ok"), MIMETextPlain)
+	benchmarkHealth(b, e)
+}
+
+func BenchmarkLeegoHealthRoute(b *testing.B) {
+	e := New()
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError { return next(c) }
+	})
+	e.GET("/health", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "ok")
+	})
+	benchmarkHealth(b, e)
+}
+
+func TestLeegoIntercept(t *testing.T) {
+	e := New()
+	maintenance := false
+	pre := false
+	e.Intercept(func(c Context) (bool, LeegoError) {
+		if maintenance && c.Request().URL().Path() != "/status" {
+			return false, NewHTTPError(http.StatusServiceUnavailable)
+		}
+		return true, nil
+	})
+	e.Intercept(func(c Context) (bool, LeegoError) {
+		if c.Request().Header().Get("X-Blocked") != "" {
+			// Vetoed with a response of its own
+			return false, c.NoContent(http.StatusForbidden)
+		}
+		return true, nil
+	})
+	e.Pre(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			pre = true
+			return next(c)
+		}
+	})
+	e.GET("/", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "test")
+	})
+	e.GET("/status", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "maintenance")
+	})
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), rec)
+	assert.True(t, pre)
+	assert.Equal(t, "test", rec.Body.String())
+
+	maintenance = true
+	pre = false
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), rec)
+	assert.False(t, pre)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Status())
+
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/status", nil), rec)
+	assert.True(t, pre)
+	assert.Equal(t, "maintenance", rec.Body.String())
+
+	pre = false
+	req := test.NewRequest(GET, "/status", nil)
+	req.Header().Set("X-Blocked", "1")
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.False(t, pre)
+	assert.Equal(t, http.StatusForbidden, rec.Status())
+}
+
+func TestLeegoProblemJSON(t *testing.T) {
+	e := New()
+	e.GET("/accounts/:id", func(c Context) LeegoError {
+		return NewProblem(http.StatusForbidden, "Insufficient funds", "Your balance is 30, but that costs 50.")
+	})
+	e.GET("/fail", func(c Context) LeegoError {
+		return errors.New("database down")
+	})
+
+	serve := func(path, accept string) *test.ResponseRecorder {
+		req := test.NewRequest(GET, path, nil)
+		req.Header().Set(HeaderAccept, accept)
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec
+	}
+
+	rec := serve("/accounts/12", "application/problem+json, application/json;q=0.9")
+	assert.Equal(t, http.StatusForbidden, rec.Status())
+	assert.Equal(t, MIMEApplicationProblemJSON, rec.Header().Get(HeaderContentType))
+	assert.JSONEq(t, `{
+		"type": "about:blank",
+		"title": "Insufficient funds",
+		"status": 403,
+		"detail": "Your balance is 30, but that costs 50.",
+		"instance": "/accounts/12"
+	}`, rec.Body.String())
+
+	rec = serve("/fail", MIMEApplicationProblemJSON)
+	assert.Equal(t, http.StatusInternalServerError, rec.Status())
+	assert.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"instance":"/fail"}`, rec.Body.String())
+
+	// Plain text otherwise, wildcards included
+	rec = serve("/accounts/12", "*/*")
+	assert.Equal(t, http.StatusForbidden, rec.Status())
+	assert.Equal(t, MIMETextPlainCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.Equal(t, "Insufficient funds", rec.Body.String())
+}
+
+func TestLeegoSetReady(t *testing.T) {
+	e := New()
+	e.GET("/ready", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "route")
+	})
+
+	// Disabled until set
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/ready", nil), rec)
+	assert.Equal(t, "route", rec.Body.String())
+	assert.True(t, e.Ready())
+
+	e.SetReady(true)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/ready", nil), rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "OK", rec.Body.String())
+
+	e.SetReady(false)
+	assert.False(t, e.Ready())
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/ready", nil), rec)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Status())
+
+	e.SetReadyPath("/_ready")
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(HEAD, "/_ready", nil), rec)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Status())
+	assert.Empty(t, rec.Body.String())
+}
+
+func TestLeegoWarmPool(t *testing.T) {
+	e := New()
+	e.GET("/users/:uid/files/:fid", func(c Context) LeegoError {
+		return c.String(http.StatusOK, c.Param("uid")+"/"+c.Param("fid"))
+	})
+	e.WarmPool(2)
+	c := e.pool.Get().(*echoContext)
+	assert.Len(t, c.pvalues, 2)
+	e.pool.Put(c)
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/users/1/files/2", nil), rec)
+	assert.Equal(t, "1/2", rec.Body.String())
+}
+
+func benchmarkBurst(b *testing.B, warm int) {
+	const burst = 32
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		e := New()
+		var arrived sync.WaitGroup
+		arrived.Add(burst)
+		e.GET("/users/:id", func(c Context) LeegoError {
+			// Hold every context until the whole burst is in flight
+			arrived.Done()
+			arrived.Wait()
+			return nil
+		})
+		e.WarmPool(warm)
+		reqs := make([]engine.Request, burst)
+		recs := make([]*test.ResponseRecorder, burst)
+		for j := range reqs {
+			reqs[j] = test.NewRequest(GET, "/users/1", nil)
+			recs[j] = test.NewResponseRecorder()
+		}
+		var done sync.WaitGroup
+		done.Add(burst)
+		b.StartTimer()
+		for j := 0; j < burst; j++ {
+			go func(j int) {
+				e.ServeHTTP(reqs[j], recs[j])
+				done.Done()
+			}(j)
+		}
+		done.Wait()
+	}
+}
+
+func BenchmarkLeegoColdBurst(b *testing.B) {
+	benchmarkBurst(b, 0)
+}
+
+func BenchmarkLeegoWarmBurst(b *testing.B) {
+	benchmarkBurst(b, 32)
+}
+
+func TestLeegoUseOnError(t *testing.T) {
+	e := New()
+	calls := []string{}
+	e.UseOnError(func(err LeegoError, c Context) LeegoError {
+		calls = append(calls, "alert: "+err.Error())
+		return err
+	})
+	// Added after the hooks, the mapper still runs first
+	e.OnError(func(err LeegoError, c Context) LeegoError {
+		calls = append(calls, "map: "+err.Error())
+		if err == errNotFoundInStore {
+			return ErrNotFound
+		}
+		return err
+	})
+	e.UseOnError(func(err LeegoError, c Context) LeegoError {
+		calls = append(calls, "count: "+err.Error())
+		return err
+	})
+	e.GET("/ok", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "test")
+	})
+	e.GET("/fail", func(c Context) LeegoError {
+		return errNotFoundInStore
+	})
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/ok", nil), rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Empty(t, calls)
+
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/fail", nil), rec)
+	assert.Equal(t, http.StatusNotFound, rec.Status())
+	assert.Equal(t, []string{"map: not found in store", "alert: Not Found", "count: Not Found"}, calls)
+
+	// A handled error ends the pipeline
+	calls = calls[:0]
+	e.OnError(func(err LeegoError, c Context) LeegoError {
+		return nil
+	})
+	e.ServeHTTP(test.NewRequest(GET, "/fail", nil), test.NewResponseRecorder())
+	assert.Equal(t, []string{"map: not found in store"}, calls)
+}
+
+var errNotFoundInStore = errors.New("not found in store")
+
+func TestLeegoShutdownRejectsRequests(t *testing.T) {
+	e := New()
+	e.GET("/", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "test")
+	})
+	assert.NoError(t, e.Shutdown(context.Background()))
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/", nil), rec)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Status())
+}
+
+func TestLeegoVersionInfo(t *testing.T) {
+	e := New()
+	e.GET("/version", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "route")
+	})
+
+	// Optional
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/version", nil), rec)
+	assert.Equal(t, "route", rec.Body.String())
+
+	e.SetVersionInfo(map[string]string{
+		"version":   "1.4.2",
+		"commit":    "3f2a9c1",
+		"buildTime": "2016-06-01T12:00:00Z",
+	})
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/version", nil), rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, MIMEApplicationJSONCharsetUTF8, rec.Header().Get(HeaderContentType))
+	assert.JSONEq(t, `{"version":"1.4.2","commit":"3f2a9c1","buildTime":"2016-06-01T12:00:00Z"}`, rec.Body.String())
+
+	e.SetVersionPath("/_version")
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/_version", nil), rec)
+	assert.JSONEq(t, `{"version":"1.4.2","commit":"3f2a9c1","buildTime":"2016-06-01T12:00:00Z"}`, rec.Body.String())
+}
+
+func TestLeegoDrainRoute(t *testing.T) {
+	e := New()
+	e.GET("/users/:id", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "user")
+	})
+	e.POST("/users/:id", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "updated")
+	})
+	e.GET("/orders", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "orders")
+	})
+	get := func(method, path string) *test.ResponseRecorder {
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(test.NewRequest(method, path, nil), rec)
+		return rec
+	}
+
+	e.DrainRoute(GET, "/users/:id")
+	rec := get(GET, "/users/1")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Status())
+	assert.Equal(t, "60", rec.Header().Get(HeaderRetryAfter))
+	assert.Equal(t, "updated", get(POST, "/users/1").Body.String())
+	assert.Equal(t, "orders", get(GET, "/orders").Body.String())
+
+	e.UndrainRoute(GET, "/users/:id")
+	rec = get(GET, "/users/1")
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "user", rec.Body.String())
+
+	// Concurrent with serving
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			e.DrainRoute(GET, "/orders")
+			e.UndrainRoute(GET, "/orders")
+		}()
+		go func() {
+			defer wg.Done()
+			get(GET, "/orders")
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, "orders", get(GET, "/orders").Body.String())
+}
+
+func TestLeegoAddIf(t *testing.T) {
+	e := New()
+	h := func(c Context) LeegoError {
+		return c.String(http.StatusOK, "debug")
+	}
+	assert.NotNil(t, e.AddIf(true, GET, "/debug/vars", h))
+	assert.Nil(t, e.AddIf(false, GET, "/debug/pprof", h))
+
+	assert.Contains(t, e.router.routes, GET+"/debug/vars")
+	assert.NotContains(t, e.router.routes, GET+"/debug/pprof")
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/debug/vars", nil), rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(GET, "/debug/pprof", nil), rec)
+	assert.Equal(t, http.StatusNotFound, rec.Status())
+}
+
+func namedMiddleware(next HandlerFunc) HandlerFunc {
+	return next
+}
+
+func TestLeegoMiddlewareStack(t *testing.T) {
+	e := New()
+	assert.Empty(t, e.MiddlewareStack())
+
+	e.Use(namedMiddleware)
+	e.Pre(func(next HandlerFunc) HandlerFunc {
+		return next
+	})
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return next
+	})
+	stack := e.MiddlewareStack()
+	if assert.Len(t, stack, 3) {
+		assert.Regexp(t, `^anonymous@leego_test\.go:\d+$`, stack[0])
+		assert.Equal(t, "github.com/go-wyvern/leego.namedMiddleware", stack[1])
+		assert.Regexp(t, `^anonymous@leego_test\.go:\d+$`, stack[2])
+		assert.NotEqual(t, stack[0], stack[2])
+	}
+}
+
+func TestLeegoReplaceHandler(t *testing.T) {
+	e := New()
+	e.GET("/users/:id", func(c Context) LeegoError {
+		return c.String(http.StatusOK, "v1 "+c.Param("id"))
+	}, func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			c.Response().Header().Set("X-Middleware", "ok")
+			return next(c)
+		}
+	})
+	get := func() *test.ResponseRecorder {
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(test.NewRequest(GET, "/users/1", nil), rec)
+		return rec
+	}
+	v2 := func(c Context) LeegoError {
+		return c.String(http.StatusOK, "v2 "+c.Param("id"))
+	}
+
+	// Debug mode only
+	assert.Error(t, e.ReplaceHandler(GET, "/users/:id", v2))
+	assert.Equal(t, "v1 1", get().Body.String())
+
+	e.SetDebug(true)
+	assert.Error(t, e.ReplaceHandler(GET, "/nope", v2))
+	assert.Error(t, e.ReplaceHandler(POST, "/users/:id", v2))
+	assert.Error(t, e.ReplaceHandler(GET, "/users/:id", nil))
+	assert.Equal(t, "v1 1", get().Body.String())
+	assert.NoError(t, e.ReplaceHandler(GET, "/users/:id", v2))
+	rec := get()
+	assert.Equal(t, "v2 1", rec.Body.String())
+	assert.Equal(t, "ok", rec.Header().Get("X-Middleware"))
+
+	// Concurrent with serving
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			e.ReplaceHandler(GET, "/users/:id", v2)
+		}()
+		go func() {
+			defer wg.Done()
+			assert.Equal(t, "v2 1", get().Body.String())
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// VersionConfig defines the config for APIVersion middleware.
+	VersionConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// VersionLookup is a string in the form of "<source>" or "<source>:<name>"
+		// that is used to extract the version from the request.
+		// Optional. Default value "accept".
+		// Possible values:
+		// - "accept" - version parameter of a vendor media type, e.g.
+		//   `Accept: application/vnd.api+json;version=2`
+		// - "header:<name>" - e.g. "header:X-API-Version"
+		// - "path" - leading path segment, e.g. `/v2/users`
+		VersionLookup string
+
+		// Supported lists the accepted versions. Requests for other versions are
+		// rejected with 406 for the "accept" lookup and 400 otherwise.
+		// Optional. All versions are accepted if empty.
+		Supported []string
+
+		// Default is used when the request doesn't specify a version.
+		// Optional. Requests without a version are rejected if empty.
+		Default string
+
+		// ContextKey is the key the version is stored under in the context.
+		// Optional. Default value "api_version".
+		ContextKey string
+	}
+
+	versionExtractor func(leego.Context) string
+)
+
+var (
+	// DefaultVersionConfig is the default APIVersion middleware config.
+	DefaultVersionConfig = VersionConfig{
+		Skipper:       defaultSkipper,
+		VersionLookup: "accept",
+		ContextKey:    "api_version",
+	}
+)
+
+// APIVersion returns a middleware which extracts the requested API version and
+// stores it in the context, so handlers can read it with
+// `c.Get("api_version")`.
+func APIVersion(config VersionConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultVersionConfig.Skipper
+	}
+	if config.VersionLookup == "" {
+		config.VersionLookup = DefaultVersionConfig.VersionLookup
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultVersionConfig.ContextKey
+	}
+
+	// Initialize
+	parts := strings.SplitN(config.VersionLookup, ":", 2)
+	var extractor versionExtractor
+	unsupported := leego.NewHTTPError(http.StatusBadRequest, "unsupported api version")
+	switch parts[0] {
+	case "accept":
+		extractor = versionFromAccept
+		unsupported = leego.NewHTTPError(http.StatusNotAcceptable, "unsupported api version")
+	case "header":
+		if len(parts) != 2 {
+			panic("leego: invalid version lookup " + config.VersionLookup)
+		}
+		extractor = versionFromHeader(parts[1])
+	case "path":
+		extractor = versionFromPath
+	default:
+		panic("leego: invalid version lookup " + config.VersionLookup)
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			v := extractor(c)
+			if v == "" {
+				if config.Default == "" {
+					return leego.NewHTTPError(http.StatusBadRequest, "missing api version")
+				}
+				v = config.Default
+			}
+			if len(config.Supported) > 0 && !containsString(config.Supported, v) {
+				return unsupported
+			}
+			c.Set(config.ContextKey, v)
+			return next(c)
+		}
+	}
+}
+
+// versionFromAccept returns the version parameter of the first vendor media
+// type in the `Accept` header.
+func versionFromAccept(c leego.Context) string {
+	for _, spec := range leego.ParseAccept(c.Request().Header().Get(leego.HeaderAccept)) {
+		if v, ok := spec.Params["version"]; ok && strings.HasPrefix(spec.Subtype, "vnd.") {
+			return v
+		}
+	}
+	return ""
+}
+
+// versionFromHeader returns a `versionExtractor` that reads the version from
+// the named request header.
+func versionFromHeader(header string) versionExtractor {
+	return func(c leego.Context) string {
+		return strings.TrimSpace(c.Request().Header().Get(header))
+	}
+}
+
+// versionFromPath returns the version from a leading `/v<version>` path segment.
+func versionFromPath(c leego.Context) string {
+	path := strings.TrimPrefix(c.Request().URL().Path(), "/")
+	if i := strings.IndexByte(path, '/'); i != -1 {
+		path = path[:i]
+	}
+	if len(path) < 2 || path[0] != 'v' || path[1] < '0' || path[1] > '9' {
+		return ""
+	}
+	return path[1:]
+}
+
+func containsString(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
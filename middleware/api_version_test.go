@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAPIVersion(t *testing.T) {
+	e := leego.New()
+	version := func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, c.Get("api_version").(string))
+	}
+
+	// Accept header
+	h := APIVersion(VersionConfig{Supported: []string{"1", "2"}})(version)
+	req := test.NewRequest(leego.GET, "/users", nil)
+	req.Header().Set(leego.HeaderAccept, "application/json;q=0.9, application/vnd.api+json;version=2")
+	rec := test.NewResponseRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h(c)) {
+		assert.Equal(t, "2", rec.Body.String())
+	}
+
+	req = test.NewRequest(leego.GET, "/users", nil)
+	req.Header().Set(leego.HeaderAccept, "application/vnd.api+json;version=3")
+	c = e.NewContext(req, test.NewResponseRecorder())
+	he := h(c).(*leego.HTTPError)
+	assert.Equal(t, http.StatusNotAcceptable, he.Code)
+
+	req = test.NewRequest(leego.GET, "/users", nil)
+	c = e.NewContext(req, test.NewResponseRecorder())
+	he = h(c).(*leego.HTTPError)
+	assert.Equal(t, http.StatusBadRequest, he.Code)
+
+	// Custom header
+	h = APIVersion(VersionConfig{VersionLookup: "header:X-API-Version", Default: "1"})(version)
+	req = test.NewRequest(leego.GET, "/users", nil)
+	req.Header().Set("X-API-Version", "3")
+	rec = test.NewResponseRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, h(c)) {
+		assert.Equal(t, "3", rec.Body.String())
+	}
+
+	req = test.NewRequest(leego.GET, "/users", nil)
+	rec = test.NewResponseRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, h(c)) {
+		assert.Equal(t, "1", rec.Body.String())
+	}
+
+	// Path prefix
+	h = APIVersion(VersionConfig{VersionLookup: "path", Supported: []string{"2"}})(version)
+	req = test.NewRequest(leego.GET, "/v2/users", nil)
+	rec = test.NewResponseRecorder()
+	c = e.NewContext(req, rec)
+	if assert.NoError(t, h(c)) {
+		assert.Equal(t, "2", rec.Body.String())
+	}
+
+	req = test.NewRequest(leego.GET, "/v1/users", nil)
+	c = e.NewContext(req, test.NewResponseRecorder())
+	he = h(c).(*leego.HTTPError)
+	assert.Equal(t, http.StatusBadRequest, he.Code)
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"fmt"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// AutoCacheHeadersConfig defines the config for AutoCacheHeaders middleware.
+	AutoCacheHeadersConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// MaxAge is the `max-age`, in seconds, of the successful GET and HEAD
+		// responses.
+		// Optional. Default value 60.
+		MaxAge int
+	}
+)
+
+var (
+	// DefaultAutoCacheHeadersConfig is the default AutoCacheHeaders middleware config.
+	DefaultAutoCacheHeadersConfig = AutoCacheHeadersConfig{
+		Skipper: defaultSkipper,
+		MaxAge:  60,
+	}
+)
+
+// AutoCacheHeaders returns a middleware which sets `Cache-Control` on responses
+// which don't have one yet, just before they're committed: `max-age` for
+// successful GET and HEAD responses, `no-store` for the other methods and for
+// error responses, so intermediaries don't cache sensitive data by accident.
+func AutoCacheHeaders() leego.MiddlewareFunc {
+	return AutoCacheHeadersWithConfig(DefaultAutoCacheHeadersConfig)
+}
+
+// AutoCacheHeadersWithConfig returns an AutoCacheHeaders middleware from config.
+// See `AutoCacheHeaders()`.
+func AutoCacheHeadersWithConfig(config AutoCacheHeadersConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultAutoCacheHeadersConfig.Skipper
+	}
+	if config.MaxAge == 0 {
+		config.MaxAge = DefaultAutoCacheHeadersConfig.MaxAge
+	}
+	if config.MaxAge < 0 {
+		panic("leego: auto cache headers middleware requires a non-negative max age")
+	}
+	maxAge := fmt.Sprintf("max-age=%d", config.MaxAge)
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			res := c.Response()
+			res.Before(func() {
+				if res.Header().Get(leego.HeaderCacheControl) != "" {
+					return
+				}
+				method := req.Method()
+				if (method == leego.GET || method == leego.HEAD) && res.Status() < 400 {
+					res.Header().Set(leego.HeaderCacheControl, maxAge)
+				} else {
+					res.Header().Set(leego.HeaderCacheControl, "no-store")
+				}
+			})
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoCacheHeaders(t *testing.T) {
+	e := leego.New()
+	e.Use(AutoCacheHeadersWithConfig(AutoCacheHeadersConfig{MaxAge: 300}))
+	ok := func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, "test")
+	}
+	fail := func(c leego.Context) leego.LeegoError {
+		return leego.NewHTTPError(http.StatusInternalServerError)
+	}
+	e.Match([]string{leego.GET, leego.POST}, "/ok", ok)
+	e.Match([]string{leego.GET, leego.POST}, "/fail", fail)
+	e.GET("/custom", func(c leego.Context) leego.LeegoError {
+		c.Response().Header().Set(leego.HeaderCacheControl, "public, max-age=3600")
+		return c.NoContent(http.StatusOK)
+	})
+
+	for _, tt := range []struct {
+		method, path, cacheControl string
+	}{
+		{leego.GET, "/ok", "max-age=300"},
+		{leego.POST, "/ok", "no-store"},
+		{leego.GET, "/fail", "no-store"},
+		{leego.POST, "/fail", "no-store"},
+		{leego.GET, "/custom", "public, max-age=3600"},
+	} {
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(test.NewRequest(tt.method, tt.path, nil), rec)
+		assert.Equal(t, tt.cacheControl, rec.Header().Get(leego.HeaderCacheControl), tt.method+" "+tt.path)
+	}
+}
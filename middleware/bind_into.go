@@ -0,0 +1,80 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// BindIntoConfig defines the config for BindInto middleware.
+	BindIntoConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Factory returns a new pointer to the struct the request is bound into.
+		// Required.
+		Factory func() interface{}
+
+		// ContextKey is the key the bound struct is stored under in the context.
+		// Optional. Default value `BindIntoKey`.
+		ContextKey string
+	}
+)
+
+// BindIntoKey is the default context key of the struct bound by BindInto.
+const BindIntoKey = "bound"
+
+var (
+	// DefaultBindIntoConfig is the default BindInto middleware config.
+	DefaultBindIntoConfig = BindIntoConfig{
+		Skipper:    defaultSkipper,
+		ContextKey: BindIntoKey,
+	}
+)
+
+// BindInto returns a middleware which binds the request into a new struct
+// created by factory, validates it with `leego.Validate()`, and
+// stores it in the context so handlers can read it with `c.Get(BindIntoKey)`.
+// Binding and validation errors are returned as 400.
+func BindInto(factory func() interface{}) leego.MiddlewareFunc {
+	c := DefaultBindIntoConfig
+	c.Factory = factory
+	return BindIntoWithConfig(c)
+}
+
+// BindIntoWithConfig returns a BindInto middleware from config.
+// See `BindInto()`.
+func BindIntoWithConfig(config BindIntoConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Factory == nil {
+		panic("leego: bind into middleware requires a factory")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultBindIntoConfig.Skipper
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultBindIntoConfig.ContextKey
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			i := config.Factory()
+			if err := c.Bind(i); err != nil {
+				if he, ok := err.(*leego.HTTPError); ok {
+					return he
+				}
+				return leego.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			if err := leego.Validate(i); err != nil {
+				return leego.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			c.Set(config.ContextKey, i)
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type signup struct {
+	Email string `json:"email"`
+}
+
+func (s *signup) Validate() error {
+	if !strings.Contains(s.Email, "@") {
+		return errors.New("invalid email")
+	}
+	return nil
+}
+
+func TestBindInto(t *testing.T) {
+	e := leego.New()
+	h := BindInto(func() interface{} {
+		return new(signup)
+	})(func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, c.Get(BindIntoKey).(*signup).Email)
+	})
+
+	req := test.NewRequest(leego.POST, "/", strings.NewReader(`{"email":"jon@example.com"}`))
+	req.Header().Set(leego.HeaderContentType, leego.MIMEApplicationJSON)
+	rec := test.NewResponseRecorder()
+	c := e.NewContext(req, rec)
+	if assert.NoError(t, h(c)) {
+		assert.Equal(t, "jon@example.com", rec.Body.String())
+	}
+
+	// Validation error
+	req = test.NewRequest(leego.POST, "/", strings.NewReader(`{"email":"jon"}`))
+	req.Header().Set(leego.HeaderContentType, leego.MIMEApplicationJSON)
+	c = e.NewContext(req, test.NewResponseRecorder())
+	he, ok := h(c).(*leego.HTTPError)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+		assert.Equal(t, "invalid email", he.Message)
+	}
+
+	// Bind error
+	req = test.NewRequest(leego.POST, "/", strings.NewReader(`{"email":`))
+	req.Header().Set(leego.HeaderContentType, leego.MIMEApplicationJSON)
+	c = e.NewContext(req, test.NewResponseRecorder())
+	he, ok = h(c).(*leego.HTTPError)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// BodyLimitConfig defines the config for BodyLimit middleware.
+	BodyLimitConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Limit is the maximum allowed size of the request body, as a number of
+		// bytes with an optional unit, e.g. "4096", "10K", "2M" or "1G". Units are
+		// powers of 1024. Required.
+		Limit string `json:"limit"`
+	}
+
+	limitedReader struct {
+		reader    io.Reader
+		remaining int64
+	}
+)
+
+var (
+	// DefaultBodyLimitConfig is the default BodyLimit middleware config.
+	DefaultBodyLimitConfig = BodyLimitConfig{
+		Skipper: defaultSkipper,
+	}
+
+	bodyLimitUnits = map[string]int64{
+		"":  1,
+		"K": 1 << 10,
+		"M": 1 << 20,
+		"G": 1 << 30,
+		"T": 1 << 40,
+	}
+)
+
+// BodyLimit returns a middleware which limits the size of the request body to
+// limit, e.g. "2M". A request whose `Content-Length` is over the limit is
+// rejected with 413 before its body is read, while reading past the limit of a
+// body of unknown length fails with `leego.ErrStatusRequestEntityTooLarge`, so
+// e.g. `Context#Bind()` returns 413.
+func BodyLimit(limit string) leego.MiddlewareFunc {
+	c := DefaultBodyLimitConfig
+	c.Limit = limit
+	return BodyLimitWithConfig(c)
+}
+
+// BodyLimitWithConfig returns a BodyLimit middleware from config.
+// See `BodyLimit()`.
+func BodyLimitWithConfig(config BodyLimitConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultBodyLimitConfig.Skipper
+	}
+	limit, err := parseBodyLimit(config.Limit)
+	if err != nil {
+		panic("leego: body limit middleware: " + err.Error())
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			if req.ContentLength() > limit {
+				return leego.ErrStatusRequestEntityTooLarge
+			}
+			if body := req.Body(); body != nil {
+				req.SetBody(&limitedReader{reader: body, remaining: limit})
+			}
+			return next(c)
+		}
+	}
+}
+
+func (r *limitedReader) Read(b []byte) (n int, err error) {
+	if r.remaining < 0 {
+		return 0, leego.ErrStatusRequestEntityTooLarge
+	}
+	// Reads one byte over the limit to tell a body of exactly the limit from a
+	// larger one
+	if int64(len(b)) > r.remaining+1 {
+		b = b[:r.remaining+1]
+	}
+	n, err = r.reader.Read(b)
+	r.remaining -= int64(n)
+	if r.remaining < 0 {
+		return n + int(r.remaining), leego.ErrStatusRequestEntityTooLarge
+	}
+	return
+}
+
+// parseBodyLimit parses a size such as "2M" into a number of bytes.
+func parseBodyLimit(limit string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(limit))
+	s = strings.TrimSuffix(s, "B")
+	i := strings.IndexFunc(s, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(s)
+	}
+	unit, ok := bodyLimitUnits[s[i:]]
+	if !ok {
+		return 0, fmt.Errorf("invalid limit %q", limit)
+	}
+	n, err := strconv.ParseFloat(s[:i], 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid limit %q", limit)
+	}
+	return int64(n * float64(unit)), nil
+}
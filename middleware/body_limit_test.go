@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBodyLimit(t *testing.T) {
+	e := leego.New()
+	e.Use(BodyLimit("1M"))
+	e.POST("/", func(c leego.Context) leego.LeegoError {
+		var v map[string]string
+		if err := c.Bind(&v); err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, v["data"])
+	})
+	body := func(size int) []byte {
+		return []byte(`{"data":"` + string(bytes.Repeat([]byte("a"), size)) + `"}`)
+	}
+	post := func(b []byte, unknownLength bool) *test.ResponseRecorder {
+		var r io.Reader = bytes.NewReader(b)
+		if unknownLength {
+			// Hides the length from http.NewRequest
+			r = struct{ io.Reader }{r}
+		}
+		req := test.NewRequest(leego.POST, "/", r)
+		req.Header().Set(leego.HeaderContentType, leego.MIMEApplicationJSON)
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec
+	}
+
+	// Content-Length over the limit
+	rec := post(body(3<<20), false)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Status())
+
+	// Unknown length, read past the limit
+	rec = post(body(3<<20), true)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Status())
+
+	rec = post(body(1<<10), true)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Len(t, rec.Body.String(), 1<<10)
+}
+
+func TestLimitedReader(t *testing.T) {
+	r := &limitedReader{reader: bytes.NewReader([]byte("hello")), remaining: 5}
+	b, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+
+	r = &limitedReader{reader: bytes.NewReader([]byte("hello!")), remaining: 5}
+	b, err = ioutil.ReadAll(r)
+	assert.Equal(t, leego.ErrStatusRequestEntityTooLarge, err)
+	assert.Equal(t, "hello", string(b))
+
+	// The error is latched
+	n, err := r.Read(make([]byte, 8))
+	assert.Equal(t, 0, n)
+	assert.Equal(t, leego.ErrStatusRequestEntityTooLarge, err)
+}
+
+func TestParseBodyLimit(t *testing.T) {
+	for s, n := range map[string]int64{
+		"512":  512,
+		"10K":  10 << 10,
+		"2M":   2 << 20,
+		"2mb":  2 << 20,
+		"1.5K": 1536,
+		"1G":   1 << 30,
+	} {
+		got, err := parseBodyLimit(s)
+		assert.NoError(t, err, s)
+		assert.Equal(t, n, got, s)
+	}
+	for _, s := range []string{"", "M", "-1M", "2X", "0"} {
+		_, err := parseBodyLimit(s)
+		assert.Error(t, err, s)
+	}
+	assert.Panics(t, func() { BodyLimit("lots") })
+}
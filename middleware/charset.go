@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/transform"
+)
+
+type (
+	// CharsetConfig defines the config for Charset middleware.
+	CharsetConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Default is the charset appended to text-like response content types
+		// which don't declare one.
+		// Optional. Default value "utf-8".
+		Default string
+
+		// Charsets maps the lowercase names of the request charsets which are
+		// transcoded to UTF-8 to their encodings. Request bodies in other charsets
+		// are passed through unchanged.
+		// Optional. Default value `DefaultCharsets`.
+		Charsets map[string]encoding.Encoding
+	}
+)
+
+var (
+	// DefaultCharsets are the request charsets transcoded by default.
+	DefaultCharsets = map[string]encoding.Encoding{
+		"iso-8859-1":   charmap.ISO8859_1,
+		"latin1":       charmap.ISO8859_1,
+		"iso-8859-15":  charmap.ISO8859_15,
+		"windows-1252": charmap.Windows1252,
+	}
+
+	// DefaultCharsetConfig is the default Charset middleware config.
+	DefaultCharsetConfig = CharsetConfig{
+		Skipper:  defaultSkipper,
+		Default:  "utf-8",
+		Charsets: DefaultCharsets,
+	}
+)
+
+// Charset returns a middleware which normalizes the charset of text-like
+// request and response bodies. Request bodies declaring one of the convertible
+// charsets are transcoded to UTF-8 before the handler binds them, and response
+// content types without a charset get `; charset=<def>` appended. Binary
+// content types are left alone.
+func Charset(def string) leego.MiddlewareFunc {
+	c := DefaultCharsetConfig
+	c.Default = def
+	return CharsetWithConfig(c)
+}
+
+// CharsetWithConfig returns a Charset middleware from config.
+// See `Charset()`.
+func CharsetWithConfig(config CharsetConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultCharsetConfig.Skipper
+	}
+	if config.Default == "" {
+		config.Default = DefaultCharsetConfig.Default
+	}
+	if config.Charsets == nil {
+		config.Charsets = DefaultCharsetConfig.Charsets
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			if mt, params, err := mime.ParseMediaType(req.Header().Get(leego.HeaderContentType)); err == nil && isText(mt) {
+				if enc, ok := config.Charsets[strings.ToLower(params["charset"])]; ok {
+					req.SetBody(transform.NewReader(req.Body(), enc.NewDecoder()))
+					params["charset"] = "utf-8"
+					req.Header().Set(leego.HeaderContentType, mime.FormatMediaType(mt, params))
+				}
+			}
+
+			res := c.Response()
+			res.Before(func() {
+				ct := res.Header().Get(leego.HeaderContentType)
+				mt, params, err := mime.ParseMediaType(ct)
+				if err != nil || !isText(mt) || params["charset"] != "" {
+					return
+				}
+				res.Header().Set(leego.HeaderContentType, ct+"; charset="+config.Default)
+			})
+			return next(c)
+		}
+	}
+}
+
+// isText returns true if the media type carries text, i.e. its body is subject
+// to a charset.
+func isText(mt string) bool {
+	switch {
+	case strings.HasPrefix(mt, "text/"),
+		strings.HasSuffix(mt, "+json"), strings.HasSuffix(mt, "+xml"):
+		return true
+	}
+	switch mt {
+	case leego.MIMEApplicationJSON, leego.MIMEApplicationJavaScript,
+		leego.MIMEApplicationXML, leego.MIMEApplicationForm:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCharset(t *testing.T) {
+	e := leego.New()
+	h := Charset("utf-8")(func(c leego.Context) leego.LeegoError {
+		u := new(struct {
+			Name string `json:"name"`
+		})
+		if err := c.Bind(u); err != nil {
+			return err
+		}
+		c.Response().Header().Set(leego.HeaderContentType, leego.MIMETextPlain)
+		c.Response().WriteHeader(http.StatusOK)
+		_, err := c.Response().Write([]byte(u.Name))
+		return err
+	})
+
+	// "José" in Latin-1
+	body := []byte("{\"name\":\"Jos\xe9\"}")
+	req := test.NewRequest(leego.POST, "/", bytes.NewReader(body))
+	req.Header().Set(leego.HeaderContentType, "application/json; charset=ISO-8859-1")
+	rec := test.NewResponseRecorder()
+	if assert.NoError(t, h(e.NewContext(req, rec))) {
+		assert.Equal(t, "José", rec.Body.String())
+		assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get(leego.HeaderContentType))
+		assert.Equal(t, "application/json; charset=utf-8", req.Header().Get(leego.HeaderContentType))
+	}
+
+	// Binary types are left alone
+	h = Charset("utf-8")(func(c leego.Context) leego.LeegoError {
+		c.Response().Header().Set(leego.HeaderContentType, leego.MIMEOctetStream)
+		return c.NoContent(http.StatusOK)
+	})
+	rec = test.NewResponseRecorder()
+	h(e.NewContext(test.NewRequest(leego.GET, "/", nil), rec))
+	assert.Equal(t, leego.MIMEOctetStream, rec.Header().Get(leego.HeaderContentType))
+}
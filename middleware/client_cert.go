@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"crypto/x509"
+	"net/http"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// ClientCertConfig defines the config for ClientCert middleware.
+	ClientCertConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Subjects lists the allowed subject common names of the client
+		// certificate.
+		// Optional. Any subject is allowed if empty.
+		Subjects []string
+
+		// Issuers lists the allowed issuer common names of the client certificate.
+		// Optional. Any issuer is allowed if empty.
+		Issuers []string
+
+		// SANs lists the allowed subject alternative names, of which the client
+		// certificate must carry at least one DNS name, email address or URI.
+		// Optional. Any names are allowed if empty.
+		SANs []string
+
+		// ContextKey is the key the client's `*x509.Certificate` is stored under in
+		// the context.
+		// Optional. Default value "client_cert".
+		ContextKey string
+	}
+)
+
+var (
+	// DefaultClientCertConfig is the default ClientCert middleware config.
+	DefaultClientCertConfig = ClientCertConfig{
+		Skipper:    defaultSkipper,
+		ContextKey: "client_cert",
+	}
+)
+
+// ClientCert returns a middleware which authorizes requests by the client
+// certificate of a mutual TLS connection. The server must request and verify
+// client certificates, e.g. with `tls.Config#ClientAuth` set to
+// `tls.VerifyClientCertIfGiven`, as only verified chains are considered.
+//
+// It returns 401 if no verified client certificate is presented and 403 if the
+// certificate doesn't match the configured rules. Otherwise the certificate is
+// stored in the context for the handlers to identify the client.
+func ClientCert(config ClientCertConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultClientCertConfig.Skipper
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultClientCertConfig.ContextKey
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			cs := c.Request().ConnectionState()
+			if cs == nil || len(cs.VerifiedChains) == 0 || len(cs.VerifiedChains[0]) == 0 {
+				return leego.ErrUnauthorized
+			}
+			cert := cs.VerifiedChains[0][0]
+			if !config.allowed(cert) {
+				return leego.NewHTTPError(http.StatusForbidden)
+			}
+			c.Set(config.ContextKey, cert)
+			return next(c)
+		}
+	}
+}
+
+func (config *ClientCertConfig) allowed(cert *x509.Certificate) bool {
+	if len(config.Subjects) > 0 && !containsString(config.Subjects, cert.Subject.CommonName) {
+		return false
+	}
+	if len(config.Issuers) > 0 && !containsString(config.Issuers, cert.Issuer.CommonName) {
+		return false
+	}
+	if len(config.SANs) == 0 {
+		return true
+	}
+	names := append([]string{}, cert.DNSNames...)
+	names = append(names, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		names = append(names, u.String())
+	}
+	for _, n := range names {
+		if containsString(config.SANs, n) {
+			return true
+		}
+	}
+	return false
+}
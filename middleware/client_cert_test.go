@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientCert(t *testing.T) {
+	e := leego.New()
+	h := ClientCert(ClientCertConfig{
+		Issuers: []string{"Internal CA"},
+		SANs:    []string{"billing.internal"},
+	})(func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, c.Get("client_cert").(*x509.Certificate).Subject.CommonName)
+	})
+	serve := func(cert *x509.Certificate) (leego.LeegoError, *test.ResponseRecorder) {
+		req := test.NewRequest(leego.GET, "/", nil)
+		if cert != nil {
+			req.(*test.Request).SetConnectionState(&tls.ConnectionState{
+				VerifiedChains: [][]*x509.Certificate{{cert}},
+			})
+		}
+		rec := test.NewResponseRecorder()
+		return h(e.NewContext(req, rec)), rec
+	}
+	cert := &x509.Certificate{
+		Subject:  pkix.Name{CommonName: "billing"},
+		Issuer:   pkix.Name{CommonName: "Internal CA"},
+		DNSNames: []string{"billing.internal"},
+	}
+
+	err, rec := serve(cert)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "billing", rec.Body.String())
+	}
+
+	// No client certificate
+	err, _ = serve(nil)
+	assert.Equal(t, leego.ErrUnauthorized, err)
+
+	// Not matching the rules
+	cert.DNSNames = []string{"reports.internal"}
+	err, _ = serve(cert)
+	if he, ok := err.(*leego.HTTPError); assert.True(t, ok) {
+		assert.Equal(t, http.StatusForbidden, he.Code)
+	}
+}
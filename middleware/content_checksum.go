@@ -0,0 +1,118 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// ContentChecksumConfig defines the config for ContentChecksum middleware.
+	ContentChecksumConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Algorithm is the checksum algorithm, `MD5` or `SHA-256`, as named in
+		// the `Digest` header. The `MD5` checksum is also read from the
+		// `Content-MD5` header.
+		// Optional. Default value "MD5".
+		Algorithm string
+	}
+)
+
+var (
+	// DefaultContentChecksumConfig is the default ContentChecksum middleware config.
+	DefaultContentChecksumConfig = ContentChecksumConfig{
+		Skipper:   defaultSkipper,
+		Algorithm: "MD5",
+	}
+
+	checksumAlgorithms = map[string]func() hash.Hash{
+		"MD5":     md5.New,
+		"SHA-256": sha256.New,
+	}
+)
+
+// ContentMD5 returns a middleware which verifies the request body against the
+// MD5 checksum of its `Content-MD5` or `Digest` header, if any, rejecting the
+// request with 400 on mismatch. The body is hashed while read into memory, for
+// the handler to read it again.
+func ContentMD5() leego.MiddlewareFunc {
+	return ContentChecksumWithConfig(DefaultContentChecksumConfig)
+}
+
+// ContentSHA256 returns a middleware which verifies the request body against
+// the SHA-256 checksum of its `Digest` header, if any. See `ContentMD5()`.
+func ContentSHA256() leego.MiddlewareFunc {
+	c := DefaultContentChecksumConfig
+	c.Algorithm = "SHA-256"
+	return ContentChecksumWithConfig(c)
+}
+
+// ContentChecksumWithConfig returns a ContentChecksum middleware from config.
+// See `ContentMD5()`.
+func ContentChecksumWithConfig(config ContentChecksumConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultContentChecksumConfig.Skipper
+	}
+	if config.Algorithm == "" {
+		config.Algorithm = DefaultContentChecksumConfig.Algorithm
+	}
+	newHash, ok := checksumAlgorithms[strings.ToUpper(config.Algorithm)]
+	if !ok {
+		panic("leego: content checksum middleware doesn't support algorithm " + config.Algorithm)
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			sum := digestValue(req.Header().Get("Digest"), config.Algorithm)
+			if sum == "" && strings.EqualFold(config.Algorithm, "MD5") {
+				sum = req.Header().Get("Content-MD5")
+			}
+			if sum == "" || req.Body() == nil {
+				return next(c)
+			}
+			expected, err := base64.StdEncoding.DecodeString(sum)
+			if err != nil {
+				return leego.NewHTTPError(http.StatusBadRequest, "invalid "+config.Algorithm+" checksum")
+			}
+
+			h := newHash()
+			buf := new(bytes.Buffer)
+			if _, err := io.Copy(buf, io.TeeReader(req.Body(), h)); err != nil {
+				return err
+			}
+			if subtle.ConstantTimeCompare(h.Sum(nil), expected) != 1 {
+				return leego.NewHTTPError(http.StatusBadRequest, config.Algorithm+" checksum mismatch")
+			}
+			req.SetBody(bytes.NewReader(buf.Bytes()))
+			return next(c)
+		}
+	}
+}
+
+// digestValue returns the value of the algorithm in a `Digest` header, e.g.
+// `SHA-256=X48E9qOokqqrvdts8nOJRJN3OWDUoyWxBf7kbu9DBPE=,MD5=...`.
+func digestValue(digest, algorithm string) string {
+	for _, d := range strings.Split(digest, ",") {
+		kv := strings.SplitN(strings.TrimSpace(d), "=", 2)
+		if len(kv) == 2 && strings.EqualFold(kv[0], algorithm) {
+			return kv[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentChecksum(t *testing.T) {
+	const body = "hello, world"
+	md5Sum := md5.Sum([]byte(body))
+	sha256Sum := sha256.Sum256([]byte(body))
+	b64 := base64.StdEncoding.EncodeToString
+
+	serve := func(mw leego.MiddlewareFunc, headers map[string]string, payload string) *test.ResponseRecorder {
+		e := leego.New()
+		e.Use(mw)
+		e.POST("/", func(c leego.Context) leego.LeegoError {
+			b, _ := ioutil.ReadAll(c.Request().Body())
+			return c.String(http.StatusOK, string(b))
+		})
+		req := test.NewRequest(leego.POST, "/", strings.NewReader(payload))
+		for k, v := range headers {
+			req.Header().Set(k, v)
+		}
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec
+	}
+
+	// Matching, the handler still reads the body
+	rec := serve(ContentMD5(), map[string]string{"Content-MD5": b64(md5Sum[:])}, body)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, body, rec.Body.String())
+
+	rec = serve(ContentSHA256(), map[string]string{"Digest": "MD5=" + b64(md5Sum[:]) + ", sha-256=" + b64(sha256Sum[:])}, body)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, body, rec.Body.String())
+
+	// Mismatched
+	rec = serve(ContentMD5(), map[string]string{"Content-MD5": b64(md5Sum[:])}, body+"!")
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+	assert.Equal(t, "MD5 checksum mismatch", rec.Body.String())
+
+	rec = serve(ContentSHA256(), map[string]string{"Digest": "SHA-256=" + b64(md5Sum[:])}, body)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+
+	rec = serve(ContentMD5(), map[string]string{"Content-MD5": "not base64!"}, body)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+
+	// No checksum
+	rec = serve(ContentSHA256(), map[string]string{"Content-MD5": b64(md5Sum[:])}, body+"!")
+	assert.Equal(t, http.StatusOK, rec.Status())
+}
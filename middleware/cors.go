@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// CORSConfig defines the config for CORS middleware.
+	CORSConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// AllowOrigins lists the origins which may access the resource, `*`
+		// allowing any.
+		// Optional. Default value []string{"*"}.
+		AllowOrigins []string `json:"allow_origins"`
+
+		// AllowMethods lists the methods allowed when accessing the resource,
+		// sent in response to a preflight request.
+		// Optional. Default value is the methods registered for the request path,
+		// see `Router#AllowedMethods()`, a preflight request for a path without
+		// any getting 404.
+		AllowMethods []string `json:"allow_methods"`
+
+		// AllowHeaders lists the request headers which can be used when making
+		// the actual request, sent in response to a preflight request.
+		// Optional. Default value is the headers requested by the preflight
+		// request.
+		AllowHeaders []string `json:"allow_headers"`
+
+		// AllowCredentials tells whether the response to the request can be
+		// exposed when the credentials flag is true.
+		// Optional. Default value false.
+		AllowCredentials bool `json:"allow_credentials"`
+
+		// ExposeHeaders lists the response headers clients are allowed to access.
+		// Optional. Default value []string{}.
+		ExposeHeaders []string `json:"expose_headers"`
+
+		// MaxAge tells how long, in seconds, the results of a preflight request
+		// can be cached.
+		// Optional. Default value 0, which sends no `Access-Control-Max-Age`.
+		MaxAge int `json:"max_age"`
+	}
+)
+
+var (
+	// DefaultCORSConfig is the default CORS middleware config.
+	DefaultCORSConfig = CORSConfig{
+		Skipper:      defaultSkipper,
+		AllowOrigins: []string{"*"},
+	}
+)
+
+// CORS returns a Cross-Origin Resource Sharing (CORS) middleware, which answers
+// the preflight requests itself, with 204.
+//
+// Usage `Leego#Use(CORS())`.
+// See https://developer.mozilla.org/en/docs/Web/HTTP/Access_control_CORS
+func CORS() leego.MiddlewareFunc {
+	return CORSWithConfig(DefaultCORSConfig)
+}
+
+// CORSWithConfig returns a CORS middleware from config.
+// See `CORS()`.
+func CORSWithConfig(config CORSConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultCORSConfig.Skipper
+	}
+	if len(config.AllowOrigins) == 0 {
+		config.AllowOrigins = DefaultCORSConfig.AllowOrigins
+	}
+	allowMethods := strings.Join(config.AllowMethods, ",")
+	allowHeaders := strings.Join(config.AllowHeaders, ",")
+	exposeHeaders := strings.Join(config.ExposeHeaders, ",")
+	maxAge := strconv.Itoa(config.MaxAge)
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			header := c.Response().Header()
+			origin := req.Header().Get(leego.HeaderOrigin)
+			allowOrigin := ""
+			if origin != "" {
+				for _, o := range config.AllowOrigins {
+					if o == "*" {
+						// Browsers reject `*` for requests with credentials
+						allowOrigin = "*"
+						if config.AllowCredentials {
+							allowOrigin = origin
+						}
+						break
+					}
+					if o == origin {
+						allowOrigin = o
+						break
+					}
+				}
+			}
+
+			// Simple request
+			if req.Method() != leego.OPTIONS || req.Header().Get(leego.HeaderAccessControlRequestMethod) == "" {
+				c.AppendVary(leego.HeaderOrigin)
+				if allowOrigin == "" {
+					return next(c)
+				}
+				header.Set(leego.HeaderAccessControlAllowOrigin, allowOrigin)
+				if config.AllowCredentials {
+					header.Set(leego.HeaderAccessControlAllowCredentials, "true")
+				}
+				if exposeHeaders != "" {
+					header.Set(leego.HeaderAccessControlExposeHeaders, exposeHeaders)
+				}
+				return next(c)
+			}
+
+			// Preflight request
+			c.AppendVary(leego.HeaderOrigin)
+			c.AppendVary(leego.HeaderAccessControlRequestMethod)
+			c.AppendVary(leego.HeaderAccessControlRequestHeaders)
+			if allowOrigin == "" {
+				return next(c)
+			}
+			methods := allowMethods
+			if methods == "" {
+				allowed := c.Leego().Router().AllowedMethods(req.URL().Path())
+				if len(allowed) == 0 {
+					// No route, so a 404
+					return next(c)
+				}
+				methods = strings.Join(allowed, ",")
+			}
+			header.Set(leego.HeaderAccessControlAllowOrigin, allowOrigin)
+			header.Set(leego.HeaderAccessControlAllowMethods, methods)
+			if config.AllowCredentials {
+				header.Set(leego.HeaderAccessControlAllowCredentials, "true")
+			}
+			if allowHeaders != "" {
+				header.Set(leego.HeaderAccessControlAllowHeaders, allowHeaders)
+			} else if h := req.Header().Get(leego.HeaderAccessControlRequestHeaders); h != "" {
+				header.Set(leego.HeaderAccessControlAllowHeaders, h)
+			}
+			if config.MaxAge > 0 {
+				header.Set(leego.HeaderAccessControlMaxAge, maxAge)
+			}
+			return c.AbortWithStatus(http.StatusNoContent)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS(t *testing.T) {
+	serve := func(mw leego.MiddlewareFunc, method, origin string, headers map[string]string) *test.ResponseRecorder {
+		e := leego.New()
+		e.Use(mw)
+		h := func(c leego.Context) leego.LeegoError {
+			return c.String(http.StatusOK, "test")
+		}
+		e.GET("/users", h)
+		e.POST("/users", h)
+		req := test.NewRequest(method, "/users", nil)
+		if origin != "" {
+			req.Header().Set(leego.HeaderOrigin, origin)
+		}
+		for k, v := range headers {
+			req.Header().Set(k, v)
+		}
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec
+	}
+	preflight := map[string]string{
+		leego.HeaderAccessControlRequestMethod:  leego.POST,
+		leego.HeaderAccessControlRequestHeaders: "X-Token",
+	}
+
+	// Wildcard origin
+	rec := serve(CORS(), leego.GET, "http://example.com", nil)
+	assert.Equal(t, "test", rec.Body.String())
+	assert.Equal(t, "*", rec.Header().Get(leego.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, leego.HeaderOrigin, rec.Header().Get(leego.HeaderVary))
+
+	// No origin
+	rec = serve(CORS(), leego.GET, "", nil)
+	assert.Equal(t, "test", rec.Body.String())
+	assert.Empty(t, rec.Header().Get(leego.HeaderAccessControlAllowOrigin))
+
+	// Preflight with the registered methods
+	rec = serve(CORS(), leego.OPTIONS, "http://example.com", preflight)
+	assert.Equal(t, http.StatusNoContent, rec.Status())
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, "*", rec.Header().Get(leego.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "GET,POST", rec.Header().Get(leego.HeaderAccessControlAllowMethods))
+	assert.Equal(t, "X-Token", rec.Header().Get(leego.HeaderAccessControlAllowHeaders))
+	assert.Empty(t, rec.Header().Get(leego.HeaderAccessControlMaxAge))
+
+	// Preflight without route
+	e := leego.New()
+	e.Use(CORS())
+	req := test.NewRequest(leego.OPTIONS, "/none", nil)
+	req.Header().Set(leego.HeaderOrigin, "http://example.com")
+	req.Header().Set(leego.HeaderAccessControlRequestMethod, leego.POST)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusNotFound, rec.Status())
+	assert.Empty(t, rec.Header().Get(leego.HeaderAccessControlAllowOrigin))
+	assert.Empty(t, rec.Header().Get(leego.HeaderAccessControlAllowMethods))
+
+	mw := CORSWithConfig(CORSConfig{
+		AllowOrigins:     []string{"http://example.com"},
+		AllowMethods:     []string{leego.GET, leego.PUT},
+		AllowHeaders:     []string{"X-Token", "X-Trace"},
+		AllowCredentials: true,
+		ExposeHeaders:    []string{"X-Total"},
+		MaxAge:           3600,
+	})
+	rec = serve(mw, leego.GET, "http://example.com", nil)
+	assert.Equal(t, "http://example.com", rec.Header().Get(leego.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "true", rec.Header().Get(leego.HeaderAccessControlAllowCredentials))
+	assert.Equal(t, "X-Total", rec.Header().Get(leego.HeaderAccessControlExposeHeaders))
+
+	rec = serve(mw, leego.OPTIONS, "http://example.com", preflight)
+	assert.Equal(t, http.StatusNoContent, rec.Status())
+	assert.Equal(t, "GET,PUT", rec.Header().Get(leego.HeaderAccessControlAllowMethods))
+	assert.Equal(t, "X-Token,X-Trace", rec.Header().Get(leego.HeaderAccessControlAllowHeaders))
+	assert.Equal(t, "3600", rec.Header().Get(leego.HeaderAccessControlMaxAge))
+	assert.Equal(t, "Origin, Access-Control-Request-Method, Access-Control-Request-Headers", rec.Header().Get(leego.HeaderVary))
+
+	// Origin not allowed
+	rec = serve(mw, leego.GET, "http://evil.com", nil)
+	assert.Equal(t, "test", rec.Body.String())
+	assert.Empty(t, rec.Header().Get(leego.HeaderAccessControlAllowOrigin))
+	rec = serve(mw, leego.OPTIONS, "http://evil.com", preflight)
+	assert.NotEqual(t, http.StatusNoContent, rec.Status())
+	assert.Empty(t, rec.Header().Get(leego.HeaderAccessControlAllowOrigin))
+
+	// Wildcard with credentials echoes the origin
+	mw = CORSWithConfig(CORSConfig{AllowCredentials: true})
+	rec = serve(mw, leego.GET, "http://example.com", nil)
+	assert.Equal(t, "http://example.com", rec.Header().Get(leego.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, "true", rec.Header().Get(leego.HeaderAccessControlAllowCredentials))
+}
+
+func TestCORSPre(t *testing.T) {
+	e := leego.New()
+	e.Pre(CORS())
+	e.GET("/users/:id", func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, "test")
+	})
+
+	// Answered ahead of the router, which has no OPTIONS route
+	req := test.NewRequest(leego.OPTIONS, "/users/1", nil)
+	req.Header().Set(leego.HeaderOrigin, "http://example.com")
+	req.Header().Set(leego.HeaderAccessControlRequestMethod, leego.GET)
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusNoContent, rec.Status())
+	assert.Equal(t, "*", rec.Header().Get(leego.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, leego.GET, rec.Header().Get(leego.HeaderAccessControlAllowMethods))
+}
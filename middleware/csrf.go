@@ -0,0 +1,230 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/engine/standard"
+)
+
+type (
+	// CSRFConfig defines the config for CSRF middleware.
+	CSRFConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// TokenLength is the length of the generated token.
+		// Optional. Default value 32.
+		TokenLength uint8 `json:"token_length"`
+
+		// TokenLookup is a string in the form of "<source>:<key>" that is used
+		// to extract the token from the request.
+		// Optional. Default value "header:X-CSRF-Token".
+		// Possible values:
+		// - "header:<name>"
+		// - "form:<name>"
+		// - "query:<name>"
+		TokenLookup string `json:"token_lookup"`
+
+		// ContextKey is the key the token is stored under in the context.
+		// Optional. Default value "csrf".
+		ContextKey string `json:"context_key"`
+
+		// CookieName is the name of the CSRF cookie.
+		// Optional. Default value "_csrf".
+		CookieName string `json:"cookie_name"`
+
+		// CookieDomain is the domain of the CSRF cookie.
+		// Optional. Default value none.
+		CookieDomain string `json:"cookie_domain"`
+
+		// CookiePath is the path of the CSRF cookie.
+		// Optional. Default value none.
+		CookiePath string `json:"cookie_path"`
+
+		// CookieMaxAge is the max age, in seconds, of the CSRF cookie.
+		// Optional. Default value 86400 (24 hours).
+		CookieMaxAge int `json:"cookie_max_age"`
+
+		// CookieSecure indicates if the CSRF cookie is secure.
+		// Optional. Default value false.
+		CookieSecure bool `json:"cookie_secure"`
+
+		// CookieHTTPOnly indicates if the CSRF cookie is HTTP only.
+		// Optional. Default value false.
+		CookieHTTPOnly bool `json:"cookie_http_only"`
+	}
+
+	// csrfTokenExtractor defines a function that takes `leego.Context` and
+	// returns either a token or an error.
+	csrfTokenExtractor func(leego.Context) (string, error)
+)
+
+const csrfTokenChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+var (
+	// DefaultCSRFConfig is the default CSRF middleware config.
+	DefaultCSRFConfig = CSRFConfig{
+		Skipper:      defaultSkipper,
+		TokenLength:  32,
+		TokenLookup:  "header:" + leego.HeaderXCSRFToken,
+		ContextKey:   "csrf",
+		CookieName:   "_csrf",
+		CookieMaxAge: 86400,
+	}
+)
+
+// CSRF returns a Cross-Site Request Forgery (CSRF) middleware, implementing the
+// double submit cookie pattern.
+//
+// Requests with a safe method, GET, HEAD, OPTIONS or TRACE, get a token, kept
+// in a cookie and stored in the context, for handlers to e.g. render it in a
+// form. Requests with another method must submit the token of the cookie, or
+// get 400 if they don't and 403 if it doesn't match.
+//
+// See: https://en.wikipedia.org/wiki/Cross-site_request_forgery
+func CSRF() leego.MiddlewareFunc {
+	return CSRFWithConfig(DefaultCSRFConfig)
+}
+
+// CSRFWithConfig returns a CSRF middleware from config.
+// See `CSRF()`.
+func CSRFWithConfig(config CSRFConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultCSRFConfig.Skipper
+	}
+	if config.TokenLength == 0 {
+		config.TokenLength = DefaultCSRFConfig.TokenLength
+	}
+	if config.TokenLookup == "" {
+		config.TokenLookup = DefaultCSRFConfig.TokenLookup
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultCSRFConfig.ContextKey
+	}
+	if config.CookieName == "" {
+		config.CookieName = DefaultCSRFConfig.CookieName
+	}
+	if config.CookieMaxAge == 0 {
+		config.CookieMaxAge = DefaultCSRFConfig.CookieMaxAge
+	}
+
+	// Initialize
+	parts := strings.SplitN(config.TokenLookup, ":", 2)
+	if len(parts) != 2 {
+		panic("leego: csrf middleware: invalid token lookup " + config.TokenLookup)
+	}
+	var extractor csrfTokenExtractor
+	switch parts[0] {
+	case "header":
+		extractor = csrfTokenFromHeader(parts[1])
+	case "form":
+		extractor = csrfTokenFromForm(parts[1])
+	case "query":
+		extractor = csrfTokenFromQuery(parts[1])
+	default:
+		panic("leego: csrf middleware: invalid token lookup " + config.TokenLookup)
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			token := ""
+			if k, err := c.Cookie(config.CookieName); err == nil && k.Value() != "" {
+				token = k.Value()
+			}
+
+			switch c.Request().Method() {
+			case leego.GET, leego.HEAD, leego.OPTIONS, leego.TRACE:
+				if token == "" {
+					token = randomCSRFToken(config.TokenLength)
+				}
+			default:
+				clientToken, err := extractor(c)
+				if err != nil {
+					return leego.NewHTTPError(http.StatusBadRequest, err.Error())
+				}
+				if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(clientToken)) != 1 {
+					return leego.NewHTTPError(http.StatusForbidden, "invalid csrf token")
+				}
+			}
+
+			// Set or refresh the cookie
+			c.SetCookie(&standard.Cookie{Cookie: &http.Cookie{
+				Name:     config.CookieName,
+				Value:    token,
+				Path:     config.CookiePath,
+				Domain:   config.CookieDomain,
+				Expires:  time.Now().Add(time.Duration(config.CookieMaxAge) * time.Second),
+				Secure:   config.CookieSecure,
+				HttpOnly: config.CookieHTTPOnly,
+			}})
+
+			// Store the token in the context
+			c.Set(config.ContextKey, token)
+
+			// Protect clients from caching the response
+			c.AppendVary(leego.HeaderCookie)
+
+			return next(c)
+		}
+	}
+}
+
+// csrfTokenFromHeader returns a `csrfTokenExtractor` that extracts token from
+// the provided request header.
+func csrfTokenFromHeader(header string) csrfTokenExtractor {
+	return func(c leego.Context) (string, error) {
+		token := c.Request().Header().Get(header)
+		if token == "" {
+			return "", errors.New("missing csrf token in header")
+		}
+		return token, nil
+	}
+}
+
+// csrfTokenFromForm returns a `csrfTokenExtractor` that extracts token from the
+// provided form parameter.
+func csrfTokenFromForm(param string) csrfTokenExtractor {
+	return func(c leego.Context) (string, error) {
+		token := c.FormValue(param)
+		if token == "" {
+			return "", errors.New("missing csrf token in form param")
+		}
+		return token, nil
+	}
+}
+
+// csrfTokenFromQuery returns a `csrfTokenExtractor` that extracts token from
+// the provided query parameter.
+func csrfTokenFromQuery(param string) csrfTokenExtractor {
+	return func(c leego.Context) (string, error) {
+		token := c.QueryParam(param)
+		if token == "" {
+			return "", errors.New("missing csrf token in query param")
+		}
+		return token, nil
+	}
+}
+
+// randomCSRFToken returns a random alphanumeric token of length n.
+func randomCSRFToken(n uint8) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		panic("leego: failed to generate csrf token: " + err.Error())
+	}
+	for i := range b {
+		// The bias of the modulo is negligible for a 62-character alphabet
+		b[i] = csrfTokenChars[int(b[i])%len(csrfTokenChars)]
+	}
+	return string(b)
+}
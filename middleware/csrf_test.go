@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSRF(t *testing.T) {
+	e := leego.New()
+	e.Use(CSRF())
+	var token interface{}
+	h := func(c leego.Context) leego.LeegoError {
+		token = c.Get("csrf")
+		return c.String(http.StatusOK, "test")
+	}
+	e.GET("/", h)
+	e.POST("/", h)
+
+	// Issue
+	req := test.NewRequest(leego.GET, "/", nil)
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	issued, ok := token.(string)
+	if assert.True(t, ok) {
+		assert.Len(t, issued, 32)
+	}
+	assert.Contains(t, rec.Header().Get(leego.HeaderSetCookie), "_csrf="+issued)
+	assert.Equal(t, leego.HeaderCookie, rec.Header().Get(leego.HeaderVary))
+
+	// Existing cookie is kept
+	req = test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderCookie, "_csrf="+issued)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, issued, token)
+
+	// Submit
+	req = test.NewRequest(leego.POST, "/", nil)
+	req.Header().Set(leego.HeaderCookie, "_csrf="+issued)
+	req.Header().Set(leego.HeaderXCSRFToken, issued)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+
+	// Tampered
+	req = test.NewRequest(leego.POST, "/", nil)
+	req.Header().Set(leego.HeaderCookie, "_csrf="+issued)
+	req.Header().Set(leego.HeaderXCSRFToken, issued[1:]+"x")
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusForbidden, rec.Status())
+
+	// No cookie
+	req = test.NewRequest(leego.POST, "/", nil)
+	req.Header().Set(leego.HeaderXCSRFToken, issued)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusForbidden, rec.Status())
+
+	// Missing token
+	req = test.NewRequest(leego.POST, "/", nil)
+	req.Header().Set(leego.HeaderCookie, "_csrf="+issued)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+}
+
+func TestCSRFTokenFromForm(t *testing.T) {
+	e := leego.New()
+	e.Use(CSRFWithConfig(CSRFConfig{
+		TokenLookup:    "form:_csrf",
+		CookieName:     "token",
+		CookieHTTPOnly: true,
+	}))
+	e.POST("/", func(c leego.Context) leego.LeegoError {
+		return c.NoContent(http.StatusOK)
+	})
+
+	form := "_csrf=abc"
+	req := test.NewRequest(leego.POST, "/", strings.NewReader(form))
+	req.Header().Set(leego.HeaderContentType, leego.MIMEApplicationForm)
+	req.Header().Set(leego.HeaderCookie, "token=abc")
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Contains(t, rec.Header().Get(leego.HeaderSetCookie), "HttpOnly")
+
+	assert.Panics(t, func() {
+		CSRFWithConfig(CSRFConfig{TokenLookup: "body:_csrf"})
+	})
+}
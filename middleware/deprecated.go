@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// DeprecationConfig defines the config for Deprecated middleware.
+	DeprecationConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// MetaKey is the route metadata key marking the deprecated routes, set
+		// with `Route#SetMeta(key, true)`, when the middleware is used globally.
+		// Optional. Default value "", which deprecates all the routes the
+		// middleware applies to, e.g. when passed at route level.
+		MetaKey string
+
+		// Date is the date the routes were deprecated at, sent in the
+		// `Deprecation` header.
+		// Optional. Default value is the zero time, which sends `true`.
+		Date time.Time
+
+		// Sunset is the date the routes are expected to stop responding at, sent
+		// in the `Sunset` header.
+		// Optional.
+		Sunset time.Time
+
+		// Link is the URL of the migration docs, sent in the `Link` header.
+		// Optional.
+		Link string
+
+		// OnUse is called on each request to a deprecated route, e.g. to track
+		// migration progress.
+		// Optional. Default value logs a warning with the Leego logger, if any.
+		OnUse func(leego.Context)
+	}
+)
+
+var (
+	// DefaultDeprecationConfig is the default Deprecated middleware config.
+	DefaultDeprecationConfig = DeprecationConfig{
+		Skipper: defaultSkipper,
+		OnUse:   logDeprecatedUse,
+	}
+)
+
+// Deprecated returns a middleware which adds the `Deprecation`, and optionally
+// `Sunset` and `Link`, headers to the responses of deprecated routes, and reports
+// their usage, so old APIs can be retired gracefully.
+func Deprecated(config DeprecationConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultDeprecationConfig.Skipper
+	}
+	if config.OnUse == nil {
+		config.OnUse = DefaultDeprecationConfig.OnUse
+	}
+	deprecation := "true"
+	if !config.Date.IsZero() {
+		deprecation = "@" + strconv.FormatInt(config.Date.Unix(), 10)
+	}
+	sunset := ""
+	if !config.Sunset.IsZero() {
+		sunset = config.Sunset.UTC().Format(http.TimeFormat)
+	}
+	link := ""
+	if config.Link != "" {
+		link = "<" + config.Link + `>; rel="deprecation"`
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+			if config.MetaKey != "" {
+				if deprecated, _ := c.RouteMeta()[config.MetaKey].(bool); !deprecated {
+					return next(c)
+				}
+			}
+
+			h := c.Response().Header()
+			h.Set("Deprecation", deprecation)
+			if sunset != "" {
+				h.Set("Sunset", sunset)
+			}
+			if link != "" {
+				h.Add("Link", link)
+			}
+			config.OnUse(c)
+			return next(c)
+		}
+	}
+}
+
+func logDeprecatedUse(c leego.Context) {
+	if l := c.Logger(); l != nil {
+		l.Warnf("deprecated route %s %s called by %s", c.Request().Method(), c.Path(), remoteIP(c))
+	}
+}
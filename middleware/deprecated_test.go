@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeprecated(t *testing.T) {
+	e := leego.New()
+	used := []string{}
+	e.Use(Deprecated(DeprecationConfig{
+		MetaKey: "deprecated",
+		Date:    time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:  time.Date(2016, 12, 31, 23, 59, 59, 0, time.UTC),
+		Link:    "https://example.com/migrate",
+		OnUse: func(c leego.Context) {
+			used = append(used, c.Path())
+		},
+	}))
+	h := func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, "test")
+	}
+	e.GET("/v1/users", h).SetMeta("deprecated", true)
+	e.GET("/v2/users", h)
+	e.GET("/v1/legacy", h, Deprecated(DeprecationConfig{OnUse: func(c leego.Context) {}}))
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.GET, "/v1/users", nil), rec)
+	assert.Equal(t, "test", rec.Body.String())
+	assert.Equal(t, "@1451606400", rec.Header().Get("Deprecation"))
+	assert.Equal(t, "Sat, 31 Dec 2016 23:59:59 GMT", rec.Header().Get("Sunset"))
+	assert.Equal(t, `<https://example.com/migrate>; rel="deprecation"`, rec.Header().Get("Link"))
+
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.GET, "/v2/users", nil), rec)
+	assert.Empty(t, rec.Header().Get("Deprecation"))
+	assert.Empty(t, rec.Header().Get("Sunset"))
+
+	// Route level
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.GET, "/v1/legacy", nil), rec)
+	assert.Equal(t, "true", rec.Header().Get("Deprecation"))
+	assert.Empty(t, rec.Header().Get("Sunset"))
+
+	assert.Equal(t, []string{"/v1/users"}, used)
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"hash/fnv"
+	"net"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// FeatureFlagsConfig defines the config for FeatureFlags middleware.
+	FeatureFlagsConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Provider evaluates the flags for a request. Required.
+		Provider FlagProvider
+
+		// KeyExtractor returns the stable key requests are bucketed by, e.g. a
+		// user ID.
+		// Optional. Default value is the client IP.
+		KeyExtractor func(leego.Context) string
+
+		// ContextKey is the key the `Flags` are stored under in the context.
+		// Optional. Default value "flags".
+		ContextKey string
+	}
+
+	// FlagProvider is the interface that wraps the Flags method.
+	//
+	// Flags returns the flags evaluated for the bucketing key. It allows
+	// integration with external feature flag services.
+	FlagProvider interface {
+		Flags(key string, c leego.Context) Flags
+	}
+
+	// Flags is the set of flags evaluated for a request.
+	Flags map[string]bool
+
+	// PercentageFlags is a `FlagProvider` which enables each flag for the given
+	// percentage (0-100) of keys, using `Bucket()`.
+	PercentageFlags map[string]int
+)
+
+var (
+	// DefaultFeatureFlagsConfig is the default FeatureFlags middleware config.
+	DefaultFeatureFlagsConfig = FeatureFlagsConfig{
+		Skipper:      defaultSkipper,
+		KeyExtractor: remoteIP,
+		ContextKey:   "flags",
+	}
+)
+
+// Enabled returns true if the named flag is enabled.
+func (f Flags) Enabled(name string) bool {
+	return f[name]
+}
+
+// Flags implements `FlagProvider#Flags` function.
+func (p PercentageFlags) Flags(key string, c leego.Context) Flags {
+	flags := make(Flags, len(p))
+	for name, percent := range p {
+		flags[name] = Bucket(name, key) < percent
+	}
+	return flags
+}
+
+// Bucket deterministically assigns key to a bucket between 0 and 99 for the
+// named flag. The same key always lands in the same bucket for a flag, while
+// buckets are independent across flags.
+func Bucket(flag, key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(flag))
+	h.Write([]byte{0})
+	h.Write([]byte(key))
+	return int(h.Sum32() % 100)
+}
+
+// FeatureFlags returns a middleware which evaluates feature flags per request
+// and stores the resulting `Flags` in the context, so handlers can branch on
+// `c.Get("flags").(middleware.Flags).Enabled("new-checkout")`.
+func FeatureFlags(provider FlagProvider) leego.MiddlewareFunc {
+	c := DefaultFeatureFlagsConfig
+	c.Provider = provider
+	return FeatureFlagsWithConfig(c)
+}
+
+// FeatureFlagsWithConfig returns a FeatureFlags middleware from config.
+// See `FeatureFlags()`.
+func FeatureFlagsWithConfig(config FeatureFlagsConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Provider == nil {
+		panic("leego: feature flags middleware requires a provider")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultFeatureFlagsConfig.Skipper
+	}
+	if config.KeyExtractor == nil {
+		config.KeyExtractor = DefaultFeatureFlagsConfig.KeyExtractor
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultFeatureFlagsConfig.ContextKey
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			c.Set(config.ContextKey, config.Provider.Flags(config.KeyExtractor(c), c))
+			return next(c)
+		}
+	}
+}
+
+func remoteIP(c leego.Context) string {
+	ra := c.Request().RemoteAddress()
+	if ip, _, err := net.SplitHostPort(ra); err == nil {
+		return ip
+	}
+	return ra
+}
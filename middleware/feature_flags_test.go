@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeatureFlags(t *testing.T) {
+	e := leego.New()
+	var flags Flags
+	h := FeatureFlagsWithConfig(FeatureFlagsConfig{
+		Provider: PercentageFlags{"new-checkout": 30, "everyone": 100, "nobody": 0},
+		KeyExtractor: func(c leego.Context) string {
+			return c.Request().Header().Get("X-User-ID")
+		},
+	})(func(c leego.Context) leego.LeegoError {
+		flags = c.Get("flags").(Flags)
+		return nil
+	})
+	evaluate := func(id string) Flags {
+		req := test.NewRequest(leego.GET, "/", nil)
+		req.Header().Set("X-User-ID", id)
+		h(e.NewContext(req, test.NewResponseRecorder()))
+		return flags
+	}
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		id := strconv.Itoa(i)
+		f := evaluate(id)
+		// Assignment is consistent across requests
+		assert.Equal(t, f, evaluate(id))
+		assert.True(t, f.Enabled("everyone"))
+		assert.False(t, f.Enabled("nobody"))
+		if f.Enabled("new-checkout") {
+			enabled++
+		}
+	}
+	assert.InDelta(t, 300, enabled, 60)
+}
@@ -0,0 +1,134 @@
+package middleware
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// GzipConfig defines the config for Gzip middleware.
+	GzipConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Level is the gzip compression level, between -2 and 9.
+		// Optional. Default value -1, `gzip.DefaultCompression`.
+		Level int `json:"level"`
+	}
+
+	// gzipWriter compresses the response body, starting the gzip stream on the
+	// first write or flush, so a response without body isn't sent an empty
+	// stream.
+	gzipWriter struct {
+		io.Writer
+		gw   *gzip.Writer
+		pool *sync.Pool
+	}
+)
+
+var (
+	// DefaultGzipConfig is the default Gzip middleware config.
+	DefaultGzipConfig = GzipConfig{
+		Skipper: defaultSkipper,
+		Level:   gzip.DefaultCompression,
+	}
+)
+
+// Gzip returns a middleware which compresses the response with gzip if the
+// client accepts it. Responses without body or already encoded by the handler,
+// e.g. pre-compressed files, are sent as is.
+func Gzip() leego.MiddlewareFunc {
+	return GzipWithConfig(DefaultGzipConfig)
+}
+
+// GzipWithConfig returns a Gzip middleware from config.
+// See `Gzip()`.
+func GzipWithConfig(config GzipConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultGzipConfig.Skipper
+	}
+	if config.Level == 0 {
+		config.Level = DefaultGzipConfig.Level
+	}
+	if _, err := gzip.NewWriterLevel(ioutil.Discard, config.Level); err != nil {
+		panic("leego: gzip middleware requires a valid compression level")
+	}
+	pool := sync.Pool{
+		New: func() interface{} {
+			w, _ := gzip.NewWriterLevel(ioutil.Discard, config.Level)
+			return w
+		},
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			c.AppendVary(leego.HeaderAcceptEncoding)
+			if !leego.AcceptsEncoding(c.Request().Header().Get(leego.HeaderAcceptEncoding), "gzip") {
+				return next(c)
+			}
+
+			res := c.Response()
+			// Decided when the response is committed, so the body is compressed
+			// whatever writes it, including the error handler
+			res.Before(func() {
+				header := res.Header()
+				status := res.Status()
+				if header.Get(leego.HeaderContentEncoding) != "" ||
+					status == http.StatusNoContent || status == http.StatusNotModified {
+					return
+				}
+				gw := &gzipWriter{Writer: res.Writer(), pool: &pool}
+				header.Set(leego.HeaderContentEncoding, "gzip")
+				header.Del(leego.HeaderContentLength)
+				res.SetWriter(gw)
+				c.Defer(func() {
+					if !gw.close() {
+						// Nothing written, so the header isn't sent yet
+						header.Del(leego.HeaderContentEncoding)
+					}
+				})
+			})
+			return next(c)
+		}
+	}
+}
+
+func (w *gzipWriter) Write(b []byte) (int, error) {
+	w.start()
+	return w.gw.Write(b)
+}
+
+// Flush sends the data compressed so far.
+func (w *gzipWriter) Flush() error {
+	w.start()
+	return w.gw.Flush()
+}
+
+// start starts the gzip stream, once the header is sent.
+func (w *gzipWriter) start() {
+	if w.gw == nil {
+		w.gw = w.pool.Get().(*gzip.Writer)
+		w.gw.Reset(w.Writer)
+	}
+}
+
+// close ends the gzip stream, if started, and returns whether it was.
+func (w *gzipWriter) close() bool {
+	if w.gw == nil {
+		return false
+	}
+	w.gw.Close()
+	w.pool.Put(w.gw)
+	w.gw = nil
+	return true
+}
@@ -0,0 +1,196 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/engine/standard"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGzip(t *testing.T) {
+	e := leego.New()
+	e.Use(Gzip())
+	e.POST("/echo", func(c leego.Context) leego.LeegoError {
+		b, err := ioutil.ReadAll(c.Request().Body())
+		if err != nil {
+			return err
+		}
+		c.Response().Header().Set(leego.HeaderContentLength, "102400")
+		return c.String(http.StatusOK, string(b))
+	})
+	e.GET("/empty", func(c leego.Context) leego.LeegoError {
+		return c.NoContent(http.StatusNoContent)
+	})
+	e.GET("/encoded", func(c leego.Context) leego.LeegoError {
+		c.Response().Header().Set(leego.HeaderContentEncoding, "br")
+		return c.String(http.StatusOK, "brotli")
+	})
+	e.GET("/fail", func(c leego.Context) leego.LeegoError {
+		return leego.NewHTTPError(http.StatusTeapot, "teapot")
+	})
+	body := make([]byte, 100<<10)
+	rand.New(rand.NewSource(1)).Read(body)
+	for i := range body {
+		body[i] = 'a' + body[i]%16
+	}
+	serve := func(method, path string, gzipped bool) *test.ResponseRecorder {
+		req := test.NewRequest(method, path, bytes.NewReader(body))
+		if gzipped {
+			req.Header().Set(leego.HeaderAcceptEncoding, "gzip, deflate")
+		}
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec
+	}
+
+	rec := serve(leego.POST, "/echo", true)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "gzip", rec.Header().Get(leego.HeaderContentEncoding))
+	assert.Equal(t, leego.HeaderAcceptEncoding, rec.Header().Get(leego.HeaderVary))
+	assert.Empty(t, rec.Header().Get(leego.HeaderContentLength))
+	assert.True(t, rec.Body.Len() < len(body))
+	r, err := gzip.NewReader(rec.Body)
+	if assert.NoError(t, err) {
+		b, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, body, b)
+	}
+
+	// Not accepted
+	rec = serve(leego.POST, "/echo", false)
+	assert.Empty(t, rec.Header().Get(leego.HeaderContentEncoding))
+	assert.Equal(t, leego.HeaderAcceptEncoding, rec.Header().Get(leego.HeaderVary))
+	assert.Equal(t, body, rec.Body.Bytes())
+
+	rec = serve(leego.GET, "/empty", true)
+	assert.Equal(t, http.StatusNoContent, rec.Status())
+	assert.Empty(t, rec.Header().Get(leego.HeaderContentEncoding))
+	assert.Zero(t, rec.Body.Len())
+
+	rec = serve(leego.GET, "/encoded", true)
+	assert.Equal(t, "br", rec.Header().Get(leego.HeaderContentEncoding))
+	assert.Equal(t, "brotli", rec.Body.String())
+
+	// The error handler's response is compressed too
+	rec = serve(leego.GET, "/fail", true)
+	assert.Equal(t, http.StatusTeapot, rec.Status())
+	r, err = gzip.NewReader(rec.Body)
+	if assert.NoError(t, err) {
+		b, _ := ioutil.ReadAll(r)
+		assert.Equal(t, "teapot", string(b))
+	}
+}
+
+func TestGzipFlush(t *testing.T) {
+	e := leego.New()
+	e.Use(Gzip())
+	flushed := make(chan struct{})
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		c.Response().Write([]byte("event: 1\n"))
+		if err := c.Flush(); err != nil {
+			return err
+		}
+		<-flushed
+		c.Response().Write([]byte("event: 2\n"))
+		return nil
+	})
+	s := standard.New("")
+	s.SetHandler(e)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	req, _ := http.NewRequest(leego.GET, ts.URL, nil)
+	req.Header.Set(leego.HeaderAcceptEncoding, "gzip")
+	res, err := http.DefaultTransport.RoundTrip(req)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer res.Body.Close()
+	assert.Equal(t, "gzip", res.Header.Get(leego.HeaderContentEncoding))
+	r, err := gzip.NewReader(res.Body)
+	if !assert.NoError(t, err) {
+		return
+	}
+	br := bufio.NewReader(r)
+	line, err := br.ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "event: 1\n", line)
+	close(flushed)
+	line, _ = br.ReadString('\n')
+	assert.Equal(t, "event: 2\n", line)
+}
+
+func TestGzipNoBody(t *testing.T) {
+	e := leego.New()
+	e.Use(Gzip())
+	e.GET("/redirect", func(c leego.Context) leego.LeegoError {
+		return c.Redirect(http.StatusFound, "/x")
+	})
+	e.GET("/fail", func(c leego.Context) leego.LeegoError {
+		return c.NoContent(http.StatusInternalServerError)
+	})
+	s := standard.New("")
+	s.SetHandler(e)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	for path, status := range map[string]int{
+		"/redirect": http.StatusFound,
+		"/fail":     http.StatusInternalServerError,
+	} {
+		req, _ := http.NewRequest(leego.GET, ts.URL+path, nil)
+		req.Header.Set(leego.HeaderAcceptEncoding, "gzip")
+		res, err := http.DefaultTransport.RoundTrip(req)
+		if !assert.NoError(t, err) {
+			continue
+		}
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, status, res.StatusCode, path)
+		assert.Empty(t, res.Header.Get(leego.HeaderContentEncoding), path)
+		assert.Empty(t, b, path)
+	}
+}
+
+func TestGzipEncryption(t *testing.T) {
+	e := leego.New()
+	e.Use(Gzip())
+	// Reversing the bytes, so a corrupt gzip stream doesn't decode
+	reverse := func(b []byte) []byte {
+		out := make([]byte, len(b))
+		for i := range b {
+			out[len(b)-1-i] = b[i]
+		}
+		return out
+	}
+	e.SetResponseEncryptor(func(b []byte, c leego.Context) ([]byte, error) {
+		return reverse(b), nil
+	})
+	body := bytes.Repeat([]byte("leego "), 100)
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, string(body))
+	})
+
+	req := test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderAcceptEncoding, "gzip")
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "gzip", rec.Header().Get(leego.HeaderContentEncoding))
+	// The whole gzip stream, trailer included, is encrypted
+	r, err := gzip.NewReader(bytes.NewReader(reverse(rec.Body.Bytes())))
+	if assert.NoError(t, err) {
+		b, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, body, b)
+	}
+}
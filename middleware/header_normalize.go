@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// HeaderNormalizeConfig defines the config for HeaderNormalize middleware.
+	HeaderNormalizeConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Headers lists the request headers which must not repeat.
+		// Optional. Default value is `Content-Length` and `Host`.
+		Headers []string
+
+		// KeepFirst tells to keep the first value of a repeated header instead of
+		// rejecting the request with 400. Repeated identical values are always
+		// collapsed.
+		// Optional. Default value false.
+		KeepFirst bool
+	}
+)
+
+var (
+	// DefaultHeaderNormalizeConfig is the default HeaderNormalize middleware config.
+	DefaultHeaderNormalizeConfig = HeaderNormalizeConfig{
+		Skipper: defaultSkipper,
+		Headers: []string{leego.HeaderContentLength, "Host"},
+	}
+)
+
+// HeaderNormalize returns a middleware which collapses or rejects the request
+// headers which must not repeat, as servers and proxies may each pick a
+// different value, which allows request smuggling. Register it with
+// `Leego#Pre()` so it runs ahead of everything else.
+//
+// Note that the default headers only have an effect on engines which don't
+// validate them before the middleware runs: with the standard engine, `net/http`
+// already rejects repeated `Host` and conflicting `Content-Length` headers, and
+// collapses identical `Content-Length` ones. Other headers listed in `Headers`,
+// e.g. `X-Forwarded-Host`, are handled on every engine.
+func HeaderNormalize(config HeaderNormalizeConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultHeaderNormalizeConfig.Skipper
+	}
+	if len(config.Headers) == 0 {
+		config.Headers = DefaultHeaderNormalizeConfig.Headers
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			h := c.Request().Header()
+			for _, name := range config.Headers {
+				values := h.Values(name)
+				if len(values) < 2 {
+					continue
+				}
+				if !config.KeepFirst {
+					for _, v := range values[1:] {
+						if v != values[0] {
+							return leego.NewHTTPError(http.StatusBadRequest, "conflicting "+name+" headers")
+						}
+					}
+				}
+				h.Set(name, values[0])
+			}
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/engine/standard"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaderNormalize(t *testing.T) {
+	serve := func(config HeaderNormalizeConfig, headers map[string][]string) (*test.ResponseRecorder, http.Header) {
+		e := leego.New()
+		e.Pre(HeaderNormalize(config))
+		seen := http.Header{}
+		e.POST("/", func(c leego.Context) leego.LeegoError {
+			h := c.Request().Header()
+			for _, k := range h.Keys() {
+				seen[k] = h.Values(k)
+			}
+			return c.String(http.StatusOK, "test")
+		})
+		req := test.NewRequest(leego.POST, "/", nil)
+		for k, vs := range headers {
+			for _, v := range vs {
+				req.Header().Add(k, v)
+			}
+		}
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec, seen
+	}
+
+	// Rejected
+	rec, _ := serve(HeaderNormalizeConfig{}, map[string][]string{
+		leego.HeaderContentLength: {"5", "10"},
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+	rec, _ = serve(HeaderNormalizeConfig{}, map[string][]string{
+		"Host": {"example.com", "evil.com"},
+	})
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+
+	// Identical values collapsed
+	rec, h := serve(HeaderNormalizeConfig{}, map[string][]string{
+		leego.HeaderContentLength: {"5", "5"},
+	})
+	if assert.Equal(t, http.StatusOK, rec.Status()) {
+		assert.Equal(t, []string{"5"}, h[leego.HeaderContentLength])
+	}
+
+	// First kept
+	rec, h = serve(HeaderNormalizeConfig{KeepFirst: true}, map[string][]string{
+		leego.HeaderContentLength: {"5", "10"},
+		"Host":                    {"example.com", "evil.com"},
+		"X-Forwarded-For":         {"1.1.1.1", "2.2.2.2"},
+	})
+	if assert.Equal(t, http.StatusOK, rec.Status()) {
+		assert.Equal(t, []string{"5"}, h[leego.HeaderContentLength])
+		assert.Equal(t, []string{"example.com"}, h["Host"])
+		assert.Len(t, h["X-Forwarded-For"], 2)
+	}
+}
+
+func TestHeaderNormalizeStandard(t *testing.T) {
+	e := leego.New()
+	e.Pre(HeaderNormalize(HeaderNormalizeConfig{Headers: []string{"X-Forwarded-Host"}}))
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, "test")
+	})
+	s := standard.New("")
+	s.SetHandler(e)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	// Passed on by `net/http`, unlike repeated `Host` headers
+	req, _ := http.NewRequest(leego.GET, ts.URL, nil)
+	req.Header.Add("X-Forwarded-Host", "example.com")
+	req.Header.Add("X-Forwarded-Host", "evil.com")
+	res, err := http.DefaultClient.Do(req)
+	if assert.NoError(t, err) {
+		res.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+	}
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+type (
+	// JSONSchemaConfig defines the config for JSONSchema middleware.
+	JSONSchemaConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Schema is the JSON Schema document request bodies are validated against.
+		// Required.
+		Schema []byte
+	}
+)
+
+var (
+	// DefaultJSONSchemaConfig is the default JSONSchema middleware config.
+	DefaultJSONSchemaConfig = JSONSchemaConfig{
+		Skipper: defaultSkipper,
+	}
+)
+
+// JSONSchema returns a middleware which validates JSON request bodies against
+// the provided JSON Schema. Requests violating the schema are rejected with a
+// 400 listing the violations; the body is restored so the handler can still
+// bind it.
+//
+// It can be used globally or as route-level middleware to validate each route
+// against its own schema.
+func JSONSchema(schema []byte) leego.MiddlewareFunc {
+	c := DefaultJSONSchemaConfig
+	c.Schema = schema
+	return JSONSchemaWithConfig(c)
+}
+
+// JSONSchemaWithConfig returns a JSONSchema middleware from config.
+// See `JSONSchema()`.
+func JSONSchemaWithConfig(config JSONSchemaConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultJSONSchemaConfig.Skipper
+	}
+	schema, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(config.Schema))
+	if err != nil {
+		panic("leego: invalid json schema: " + err.Error())
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			if !strings.HasPrefix(req.Header().Get(leego.HeaderContentType), leego.MIMEApplicationJSON) || req.Body() == nil {
+				return next(c)
+			}
+
+			body, err := ioutil.ReadAll(req.Body())
+			if err != nil {
+				return leego.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			// Restore the body for the handler
+			req.SetBody(bytes.NewReader(body))
+
+			result, err := schema.Validate(gojsonschema.NewBytesLoader(body))
+			if err != nil {
+				return leego.NewHTTPError(http.StatusBadRequest, err.Error())
+			}
+			if !result.Valid() {
+				violations := make([]string, len(result.Errors()))
+				for i, e := range result.Errors() {
+					violations[i] = e.String()
+				}
+				return leego.NewHTTPError(http.StatusBadRequest, strings.Join(violations, "; "))
+			}
+			return next(c)
+		}
+	}
+}
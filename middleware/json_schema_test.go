@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJSONSchema(t *testing.T) {
+	e := leego.New()
+	schema := []byte(`{
+		"type": "object",
+		"properties": {"name": {"type": "string"}},
+		"required": ["name"]
+	}`)
+	h := JSONSchema(schema)(func(c leego.Context) leego.LeegoError {
+		u := struct {
+			Name string `json:"name"`
+		}{}
+		if err := c.Bind(&u); err != nil {
+			return err
+		}
+		return c.String(http.StatusOK, u.Name)
+	})
+
+	// Valid
+	req := test.NewRequest(leego.POST, "/", strings.NewReader(`{"name":"jon"}`))
+	req.Header().Set(leego.HeaderContentType, leego.MIMEApplicationJSON)
+	rec := test.NewResponseRecorder()
+	c := e.NewContext(req, rec)
+	if assert.Nil(t, h(c)) {
+		assert.Equal(t, http.StatusOK, rec.Status())
+		assert.Equal(t, "jon", rec.Body.String())
+	}
+
+	// Missing required field
+	req = test.NewRequest(leego.POST, "/", strings.NewReader(`{"age":30}`))
+	req.Header().Set(leego.HeaderContentType, leego.MIMEApplicationJSON)
+	rec = test.NewResponseRecorder()
+	c = e.NewContext(req, rec)
+	he, ok := h(c).(*leego.HTTPError)
+	if assert.True(t, ok) {
+		assert.Equal(t, http.StatusBadRequest, he.Code)
+		assert.Contains(t, he.Message, "name")
+	}
+}
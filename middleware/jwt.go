@@ -0,0 +1,321 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA-256
+	_ "crypto/sha512" // registers SHA-384 and SHA-512
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// JWTConfig defines the config for JWT middleware.
+	JWTConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// SigningKey is the key the token signature is verified with: a []byte
+		// for the HMAC methods, an *rsa.PublicKey for the RSA ones and an
+		// *ecdsa.PublicKey for the ECDSA ones. Required.
+		SigningKey interface{}
+
+		// SigningMethod is the signing method the tokens must use, among HS256,
+		// HS384, HS512, RS256, RS384, RS512, ES256, ES384 and ES512.
+		// Optional. Default value HS256.
+		SigningMethod string `json:"signing_method"`
+
+		// TokenLookup is a string in the form of "<source>:<name>" that is used
+		// to extract the token from the request.
+		// Optional. Default value "header:Authorization".
+		// Possible values:
+		// - "header:<name>", where a "Bearer " scheme prefix is removed
+		// - "query:<name>"
+		// - "cookie:<name>"
+		TokenLookup string `json:"token_lookup"`
+
+		// ContextKey is the key the claims are stored under in the context.
+		// Optional. Default value "user".
+		ContextKey string `json:"context_key"`
+
+		// Claims is a pointer to a struct of the type the claims are decoded
+		// into, e.g. `&MyClaims{}`. A new one is created for every request.
+		// Optional. Default value nil, which decodes them into a `JWTClaims`.
+		Claims interface{}
+	}
+
+	// JWTClaims are the claims of a token as decoded from JSON.
+	JWTClaims map[string]interface{}
+
+	jwtExtractor func(leego.Context) string
+
+	// jwtMethod is a JWT signing method.
+	jwtMethod struct {
+		hash crypto.Hash
+		// size is the byte length of each of r and s of ECDSA signatures.
+		size int
+	}
+
+	// jwtHeader is the JOSE header of a token.
+	jwtHeader struct {
+		Alg string `json:"alg"`
+	}
+
+	// jwtTimes are the time claims of a token, in seconds since the epoch.
+	jwtTimes struct {
+		Exp *json.Number `json:"exp"`
+		Nbf *json.Number `json:"nbf"`
+	}
+)
+
+const bearer = "Bearer"
+
+var (
+	// DefaultJWTConfig is the default JWT middleware config.
+	DefaultJWTConfig = JWTConfig{
+		Skipper:       defaultSkipper,
+		SigningMethod: "HS256",
+		TokenLookup:   "header:" + leego.HeaderAuthorization,
+		ContextKey:    "user",
+	}
+
+	jwtMethods = map[string]jwtMethod{
+		"HS256": {hash: crypto.SHA256},
+		"HS384": {hash: crypto.SHA384},
+		"HS512": {hash: crypto.SHA512},
+		"RS256": {hash: crypto.SHA256},
+		"RS384": {hash: crypto.SHA384},
+		"RS512": {hash: crypto.SHA512},
+		"ES256": {hash: crypto.SHA256, size: 32},
+		"ES384": {hash: crypto.SHA384, size: 48},
+		"ES512": {hash: crypto.SHA512, size: 66},
+	}
+
+	errInvalidJWT = errors.New("invalid jwt")
+)
+
+// JWT returns a JSON Web Token (JWT) auth middleware.
+//
+// For a valid token, it stores the claims in the context under the "user" key,
+// read with `Context#Get()`. For a missing or invalid token, including an
+// expired one or one not signed with the configured method, it returns
+// `leego.ErrUnauthorized`.
+//
+// See: https://jwt.io/introduction
+func JWT(key interface{}) leego.MiddlewareFunc {
+	c := DefaultJWTConfig
+	c.SigningKey = key
+	return JWTWithConfig(c)
+}
+
+// JWTWithConfig returns a JWT auth middleware from config.
+// See: `JWT()`.
+func JWTWithConfig(config JWTConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultJWTConfig.Skipper
+	}
+	if config.SigningKey == nil {
+		panic("leego: jwt middleware requires signing key")
+	}
+	if config.SigningMethod == "" {
+		config.SigningMethod = DefaultJWTConfig.SigningMethod
+	}
+	if config.TokenLookup == "" {
+		config.TokenLookup = DefaultJWTConfig.TokenLookup
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultJWTConfig.ContextKey
+	}
+	method, ok := jwtMethods[config.SigningMethod]
+	if !ok {
+		panic("leego: jwt middleware: unsupported signing method " + config.SigningMethod)
+	}
+	if !jwtKeyValid(config.SigningMethod, config.SigningKey) {
+		panic("leego: jwt middleware: invalid signing key for " + config.SigningMethod)
+	}
+	var claimsType reflect.Type
+	if config.Claims != nil {
+		claimsType = reflect.TypeOf(config.Claims)
+		if claimsType.Kind() != reflect.Ptr {
+			panic("leego: jwt middleware requires claims to be a pointer")
+		}
+		claimsType = claimsType.Elem()
+	}
+
+	// Initialize
+	parts := strings.SplitN(config.TokenLookup, ":", 2)
+	if len(parts) != 2 {
+		panic("leego: jwt middleware: invalid token lookup " + config.TokenLookup)
+	}
+	var extractor jwtExtractor
+	switch parts[0] {
+	case "header":
+		extractor = jwtFromHeader(parts[1])
+	case "query":
+		extractor = jwtFromQuery(parts[1])
+	case "cookie":
+		extractor = jwtFromCookie(parts[1])
+	default:
+		panic("leego: jwt middleware: invalid token lookup " + config.TokenLookup)
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			token := extractor(c)
+			if token == "" {
+				return leego.ErrUnauthorized
+			}
+			var claims interface{} = &JWTClaims{}
+			if claimsType != nil {
+				claims = reflect.New(claimsType).Interface()
+			}
+			if err := parseJWT(token, config.SigningMethod, method, config.SigningKey, claims, time.Now()); err != nil {
+				return leego.ErrUnauthorized
+			}
+			if claimsType == nil {
+				claims = *claims.(*JWTClaims)
+			}
+			c.Set(config.ContextKey, claims)
+			return next(c)
+		}
+	}
+}
+
+// parseJWT verifies the token signature and time claims, and decodes its claims
+// into claims.
+func parseJWT(token, alg string, method jwtMethod, key, claims interface{}, now time.Time) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return errInvalidJWT
+	}
+	var header jwtHeader
+	if err := decodeJWTSegment(parts[0], &header); err != nil {
+		return err
+	}
+	// The method is the configured one, whatever the token claims, so e.g. an
+	// RSA public key can't be used as an HMAC secret
+	if header.Alg != alg {
+		return errInvalidJWT
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return errInvalidJWT
+	}
+	if !verifyJWT(method, key, parts[0]+"."+parts[1], sig) {
+		return errInvalidJWT
+	}
+
+	var times jwtTimes
+	if err := decodeJWTSegment(parts[1], &times); err != nil {
+		return err
+	}
+	if times.Exp != nil {
+		exp, err := times.Exp.Float64()
+		if err != nil || float64(now.Unix()) >= exp {
+			return errInvalidJWT
+		}
+	}
+	if times.Nbf != nil {
+		nbf, err := times.Nbf.Float64()
+		if err != nil || float64(now.Unix()) < nbf {
+			return errInvalidJWT
+		}
+	}
+	return decodeJWTSegment(parts[1], claims)
+}
+
+func decodeJWTSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return errInvalidJWT
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return errInvalidJWT
+	}
+	return nil
+}
+
+func verifyJWT(method jwtMethod, key interface{}, input string, sig []byte) bool {
+	h := method.hash.New()
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(method.hash.New, k)
+		mac.Write([]byte(input))
+		return hmac.Equal(sig, mac.Sum(nil))
+	case *rsa.PublicKey:
+		h.Write([]byte(input))
+		return rsa.VerifyPKCS1v15(k, method.hash, h.Sum(nil), sig) == nil
+	case *ecdsa.PublicKey:
+		if len(sig) != 2*method.size {
+			return false
+		}
+		h.Write([]byte(input))
+		r := new(big.Int).SetBytes(sig[:method.size])
+		s := new(big.Int).SetBytes(sig[method.size:])
+		return ecdsa.Verify(k, h.Sum(nil), r, s)
+	}
+	return false
+}
+
+// jwtKeyValid returns true if key is of the type used by the signing method.
+func jwtKeyValid(alg string, key interface{}) bool {
+	switch alg[:2] {
+	case "HS":
+		_, ok := key.([]byte)
+		return ok
+	case "RS":
+		_, ok := key.(*rsa.PublicKey)
+		return ok
+	case "ES":
+		_, ok := key.(*ecdsa.PublicKey)
+		return ok
+	}
+	return false
+}
+
+// jwtFromHeader returns a `jwtExtractor` that extracts token from the request
+// header, removing a "Bearer " scheme prefix.
+func jwtFromHeader(header string) jwtExtractor {
+	return func(c leego.Context) string {
+		auth := c.Request().Header().Get(header)
+		l := len(bearer)
+		if len(auth) > l+1 && strings.EqualFold(auth[:l], bearer) && auth[l] == ' ' {
+			return strings.TrimSpace(auth[l+1:])
+		}
+		return auth
+	}
+}
+
+// jwtFromQuery returns a `jwtExtractor` that extracts token from the query
+// string.
+func jwtFromQuery(param string) jwtExtractor {
+	return func(c leego.Context) string {
+		return c.QueryParam(param)
+	}
+}
+
+// jwtFromCookie returns a `jwtExtractor` that extracts token from the named
+// cookie.
+func jwtFromCookie(name string) jwtExtractor {
+	return func(c leego.Context) string {
+		cookie, err := c.Cookie(name)
+		if err != nil {
+			return ""
+		}
+		return cookie.Value()
+	}
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type jwtCustomClaims struct {
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+func signJWT(alg, claims string, sign func(input string) []byte) string {
+	enc := base64.RawURLEncoding
+	input := enc.EncodeToString([]byte(`{"alg":"`+alg+`","typ":"JWT"}`)) + "." + enc.EncodeToString([]byte(claims))
+	return input + "." + enc.EncodeToString(sign(input))
+}
+
+func signHS256(key []byte) func(string) []byte {
+	return func(input string) []byte {
+		mac := hmac.New(crypto.SHA256.New, key)
+		mac.Write([]byte(input))
+		return mac.Sum(nil)
+	}
+}
+
+func TestJWT(t *testing.T) {
+	key := []byte("secret")
+	e := leego.New()
+	var claims interface{}
+	h := func(c leego.Context) leego.LeegoError {
+		claims = c.Get("user")
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/", h, JWT(key))
+	e.GET("/query", h, JWTWithConfig(JWTConfig{
+		SigningKey:  key,
+		TokenLookup: "query:token",
+		Claims:      &jwtCustomClaims{},
+	}))
+	e.GET("/cookie", h, JWTWithConfig(JWTConfig{
+		SigningKey:  key,
+		TokenLookup: "cookie:jwt",
+	}))
+	token := signJWT("HS256", `{"name":"jon","admin":true}`, signHS256(key))
+
+	req := test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderAuthorization, "Bearer "+token)
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, JWTClaims{"name": "jon", "admin": true}, claims)
+
+	req = test.NewRequest(leego.GET, "/query?token="+token, nil)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, &jwtCustomClaims{Name: "jon", Admin: true}, claims)
+
+	req = test.NewRequest(leego.GET, "/cookie", nil)
+	req.Header().Set(leego.HeaderCookie, "jwt="+token)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+
+	for name, auth := range map[string]string{
+		"missing":   "",
+		"malformed": "Bearer abc",
+		"wrong key": "Bearer " + signJWT("HS256", `{}`, signHS256([]byte("other"))),
+		"none":      "Bearer " + signJWT("none", `{}`, func(string) []byte { return nil }),
+		"expired":   "Bearer " + signJWT("HS256", `{"exp":1}`, signHS256(key)),
+		"not yet":   "Bearer " + signJWT("HS256", `{"nbf":4102444800}`, signHS256(key)),
+	} {
+		req = test.NewRequest(leego.GET, "/", nil)
+		req.Header().Set(leego.HeaderAuthorization, auth)
+		rec = test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		assert.Equal(t, http.StatusUnauthorized, rec.Status(), name)
+	}
+
+	assert.Panics(t, func() { JWT(nil) })
+	assert.Panics(t, func() { JWTWithConfig(JWTConfig{SigningKey: key, SigningMethod: "RS256"}) })
+	assert.Panics(t, func() { JWTWithConfig(JWTConfig{SigningKey: key, TokenLookup: "form:jwt"}) })
+}
+
+func TestJWTECDSA(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if !assert.NoError(t, err) {
+		return
+	}
+	sign := func(input string) []byte {
+		h := crypto.SHA256.New()
+		h.Write([]byte(input))
+		r, s, err := ecdsa.Sign(rand.Reader, priv, h.Sum(nil))
+		assert.NoError(t, err)
+		sig := make([]byte, 64)
+		r.FillBytes(sig[:32])
+		s.FillBytes(sig[32:])
+		return sig
+	}
+	exp := strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)
+	method := jwtMethods["ES256"]
+	claims := JWTClaims{}
+	assert.NoError(t, parseJWT(signJWT("ES256", `{"sub":"1","exp":`+exp+`}`, sign), "ES256", method, &priv.PublicKey, &claims, time.Now()))
+	assert.Equal(t, "1", claims["sub"])
+	assert.Error(t, parseJWT(signJWT("ES256", `{"sub":"1"}`, signHS256([]byte("x"))), "ES256", method, &priv.PublicKey, &claims, time.Now()))
+}
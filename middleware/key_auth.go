@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// KeyAuthConfig defines the config for KeyAuth middleware.
+	KeyAuthConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// KeyLookup is a string in the form of "<source>:<name>" that is used
+		// to extract the key from the request.
+		// Optional. Default value "header:Authorization".
+		// Possible values:
+		// - "header:<name>"
+		// - "query:<name>"
+		// - "cookie:<name>"
+		KeyLookup string `json:"key_lookup"`
+
+		// AuthScheme is the scheme prefixing the key in the Authorization
+		// header, which is removed.
+		// Optional. Default value "Bearer".
+		AuthScheme string `json:"auth_scheme"`
+
+		// Validator is a function to validate the key. Required.
+		Validator KeyAuthValidator
+	}
+
+	// KeyAuthValidator defines a function to validate KeyAuth credentials.
+	KeyAuthValidator func(key string, c leego.Context) (bool, error)
+)
+
+var (
+	// DefaultKeyAuthConfig is the default KeyAuth middleware config.
+	DefaultKeyAuthConfig = KeyAuthConfig{
+		Skipper:    defaultSkipper,
+		KeyLookup:  "header:" + leego.HeaderAuthorization,
+		AuthScheme: bearer,
+	}
+)
+
+// KeyAuth returns a key auth middleware, for e.g. API keys.
+//
+// For a valid key, it calls the next handler. For a missing key, or one the
+// validator rejects or fails to validate, it returns `leego.ErrUnauthorized`.
+func KeyAuth(validator KeyAuthValidator) leego.MiddlewareFunc {
+	c := DefaultKeyAuthConfig
+	c.Validator = validator
+	return KeyAuthWithConfig(c)
+}
+
+// KeyAuthWithConfig returns a key auth middleware from config.
+// See `KeyAuth()`.
+func KeyAuthWithConfig(config KeyAuthConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultKeyAuthConfig.Skipper
+	}
+	if config.KeyLookup == "" {
+		config.KeyLookup = DefaultKeyAuthConfig.KeyLookup
+	}
+	if config.AuthScheme == "" {
+		config.AuthScheme = DefaultKeyAuthConfig.AuthScheme
+	}
+	if config.Validator == nil {
+		panic("leego: key-auth middleware requires a validator function")
+	}
+
+	// Initialize
+	parts := strings.SplitN(config.KeyLookup, ":", 2)
+	if len(parts) != 2 {
+		panic("leego: key-auth middleware: invalid key lookup " + config.KeyLookup)
+	}
+	var extractor jwtExtractor
+	switch parts[0] {
+	case "header":
+		extractor = keyFromHeader(parts[1], config.AuthScheme)
+	case "query":
+		extractor = jwtFromQuery(parts[1])
+	case "cookie":
+		extractor = jwtFromCookie(parts[1])
+	default:
+		panic("leego: key-auth middleware: invalid key lookup " + config.KeyLookup)
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			key := extractor(c)
+			if key == "" {
+				return leego.ErrUnauthorized
+			}
+			valid, err := config.Validator(key, c)
+			if err != nil || !valid {
+				return leego.ErrUnauthorized
+			}
+			return next(c)
+		}
+	}
+}
+
+// keyFromHeader returns an extractor that extracts the key from the request
+// header. From the Authorization header, the key must be prefixed with the
+// auth scheme, which is removed.
+func keyFromHeader(header, scheme string) jwtExtractor {
+	return func(c leego.Context) string {
+		auth := c.Request().Header().Get(header)
+		if http.CanonicalHeaderKey(header) != leego.HeaderAuthorization {
+			return auth
+		}
+		l := len(scheme)
+		if len(auth) > l+1 && strings.EqualFold(auth[:l], scheme) && auth[l] == ' ' {
+			return strings.TrimSpace(auth[l+1:])
+		}
+		return ""
+	}
+}
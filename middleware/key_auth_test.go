@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/engine"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyAuth(t *testing.T) {
+	validator := func(key string, c leego.Context) (bool, error) {
+		if key == "fail" {
+			return false, errors.New("lookup failed")
+		}
+		return key == "valid-key", nil
+	}
+	h := func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, "test")
+	}
+	serve := func(mw leego.MiddlewareFunc, req engine.Request) int {
+		e := leego.New()
+		e.Use(mw)
+		e.GET("/", h)
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec.Status()
+	}
+
+	// Header
+	mw := KeyAuth(validator)
+	req := test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderAuthorization, "Bearer valid-key")
+	assert.Equal(t, http.StatusOK, serve(mw, req))
+
+	req = test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderAuthorization, "bearer valid-key")
+	assert.Equal(t, http.StatusOK, serve(mw, req))
+
+	// Missing scheme
+	req = test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderAuthorization, "valid-key")
+	assert.Equal(t, http.StatusUnauthorized, serve(mw, req))
+
+	// Rejected
+	req = test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderAuthorization, "Bearer invalid-key")
+	assert.Equal(t, http.StatusUnauthorized, serve(mw, req))
+
+	req = test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderAuthorization, "Bearer fail")
+	assert.Equal(t, http.StatusUnauthorized, serve(mw, req))
+
+	// Missing
+	assert.Equal(t, http.StatusUnauthorized, serve(mw, test.NewRequest(leego.GET, "/", nil)))
+
+	// Custom header and scheme
+	mw = KeyAuthWithConfig(KeyAuthConfig{
+		KeyLookup:  "header:Authorization",
+		AuthScheme: "ApiKey",
+		Validator:  validator,
+	})
+	req = test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderAuthorization, "ApiKey valid-key")
+	assert.Equal(t, http.StatusOK, serve(mw, req))
+
+	mw = KeyAuthWithConfig(KeyAuthConfig{
+		KeyLookup: "header:X-API-Key",
+		Validator: validator,
+	})
+	req = test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set("X-API-Key", "valid-key")
+	assert.Equal(t, http.StatusOK, serve(mw, req))
+
+	// Query
+	mw = KeyAuthWithConfig(KeyAuthConfig{
+		KeyLookup: "query:api_key",
+		Validator: validator,
+	})
+	assert.Equal(t, http.StatusOK, serve(mw, test.NewRequest(leego.GET, "/?api_key=valid-key", nil)))
+	assert.Equal(t, http.StatusUnauthorized, serve(mw, test.NewRequest(leego.GET, "/?api_key=invalid-key", nil)))
+
+	// Cookie
+	mw = KeyAuthWithConfig(KeyAuthConfig{
+		KeyLookup: "cookie:key",
+		Validator: validator,
+	})
+	req = test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderCookie, "key=valid-key")
+	assert.Equal(t, http.StatusOK, serve(mw, req))
+	req = test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderCookie, "key=invalid-key")
+	assert.Equal(t, http.StatusUnauthorized, serve(mw, req))
+
+	assert.Panics(t, func() { KeyAuth(nil) })
+	assert.Panics(t, func() {
+		KeyAuthWithConfig(KeyAuthConfig{KeyLookup: "form:key", Validator: validator})
+	})
+}
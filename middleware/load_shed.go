@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"net/http"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// LoadShedConfig defines the config for LoadShed middleware.
+	LoadShedConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// MaxInFlight is the number of concurrent requests through the middleware
+		// above which the server is overloaded.
+		// Optional. Disabled if 0.
+		MaxInFlight int64
+
+		// MaxGoroutines is the number of goroutines above which the server is
+		// overloaded.
+		// Optional. Disabled if 0.
+		MaxGoroutines int
+
+		// Probe is a custom health signal which returns true if the server is
+		// overloaded.
+		// Optional.
+		Probe func() bool
+
+		// PriorityExtractor returns the priority of a request, e.g. derived from a
+		// header or the route.
+		// Optional. Default value is the integer in the `X-Priority` request header,
+		// or 0 if missing.
+		PriorityExtractor func(leego.Context) int
+
+		// MinPriority is the priority from which requests are served while
+		// overloaded. Lower priority requests are rejected with 503.
+		// Optional. Default value 1.
+		MinPriority int
+	}
+)
+
+var (
+	// DefaultLoadShedConfig is the default LoadShed middleware config.
+	DefaultLoadShedConfig = LoadShedConfig{
+		Skipper:           defaultSkipper,
+		PriorityExtractor: priorityFromHeader("X-Priority"),
+		MinPriority:       1,
+	}
+)
+
+// LoadShed returns a middleware which degrades gracefully under overload, as
+// signaled by any of the configured thresholds or the probe, by rejecting lower
+// priority requests with 503 while still serving high priority ones.
+func LoadShed(config LoadShedConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.MaxInFlight == 0 && config.MaxGoroutines == 0 && config.Probe == nil {
+		panic("leego: load shed middleware requires a threshold or probe")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultLoadShedConfig.Skipper
+	}
+	if config.PriorityExtractor == nil {
+		config.PriorityExtractor = DefaultLoadShedConfig.PriorityExtractor
+	}
+	if config.MinPriority == 0 {
+		config.MinPriority = DefaultLoadShedConfig.MinPriority
+	}
+
+	var inFlight int64
+	overloaded := func(n int64) bool {
+		return config.MaxInFlight > 0 && n > config.MaxInFlight ||
+			config.MaxGoroutines > 0 && runtime.NumGoroutine() > config.MaxGoroutines ||
+			config.Probe != nil && config.Probe()
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			n := atomic.AddInt64(&inFlight, 1)
+			defer atomic.AddInt64(&inFlight, -1)
+			if overloaded(n) && config.PriorityExtractor(c) < config.MinPriority {
+				return leego.NewHTTPError(http.StatusServiceUnavailable)
+			}
+			return next(c)
+		}
+	}
+}
+
+func priorityFromHeader(name string) func(leego.Context) int {
+	return func(c leego.Context) int {
+		p, _ := strconv.Atoi(c.Request().Header().Get(name))
+		return p
+	}
+}
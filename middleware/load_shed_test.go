@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadShed(t *testing.T) {
+	e := leego.New()
+	overloaded := false
+	h := LoadShed(LoadShedConfig{
+		Probe: func() bool { return overloaded },
+	})(func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, "test")
+	})
+	serve := func(priority string) leego.LeegoError {
+		req := test.NewRequest(leego.GET, "/", nil)
+		req.Header().Set("X-Priority", priority)
+		return h(e.NewContext(req, test.NewResponseRecorder()))
+	}
+
+	assert.NoError(t, serve(""))
+
+	overloaded = true
+	if he, ok := serve("").(*leego.HTTPError); assert.True(t, ok) {
+		assert.Equal(t, http.StatusServiceUnavailable, he.Code)
+	}
+	assert.NoError(t, serve("1"))
+}
+
+func TestLoadShedInFlight(t *testing.T) {
+	e := leego.New()
+	release := make(chan struct{})
+	started := make(chan struct{}, 2)
+	h := LoadShed(LoadShedConfig{
+		MaxInFlight: 2,
+		PriorityExtractor: func(c leego.Context) int {
+			if c.Request().URL().Path() == "/admin" {
+				return 1
+			}
+			return 0
+		},
+	})(func(c leego.Context) leego.LeegoError {
+		if c.Request().URL().Path() == "/slow" {
+			started <- struct{}{}
+			<-release
+		}
+		return nil
+	})
+	serve := func(path string) leego.LeegoError {
+		return h(e.NewContext(test.NewRequest(leego.GET, path, nil), test.NewResponseRecorder()))
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			serve("/slow")
+		}()
+	}
+	<-started
+	<-started
+
+	// Overloaded: low priority is shed, high priority passes
+	assert.Error(t, serve("/"))
+	assert.NoError(t, serve("/admin"))
+
+	close(release)
+	wg.Wait()
+	assert.NoError(t, serve("/"))
+}
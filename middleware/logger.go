@@ -0,0 +1,198 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// LoggerConfig defines the config for Logger middleware.
+	LoggerConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Format is the log line format, in which the following tags are replaced:
+		//
+		// - time_rfc3339
+		// - id (request id, see the RequestID middleware)
+		// - remote_ip (see `leego.Context#RealIP()`)
+		// - host
+		// - method
+		// - uri
+		// - path
+		// - route
+		// - status
+		// - latency (in nanoseconds)
+		// - latency_human (human readable)
+		// - bytes_in (request content length)
+		// - bytes_out (response body size)
+		// - user_agent
+		//
+		// Example "${remote_ip} ${status}"
+		//
+		// Optional. Default value DefaultLoggerConfig.Format.
+		Format string `json:"format"`
+
+		// Output is the writer the log lines are written to.
+		// Optional. Default value nil, which logs with `Leego#Logger()` at the
+		// info level.
+		Output io.Writer
+	}
+
+	// loggerSegment is a literal or a tag of a parsed log format.
+	loggerSegment struct {
+		literal string
+		tag     string
+	}
+)
+
+var (
+	// DefaultLoggerConfig is the default Logger middleware config.
+	DefaultLoggerConfig = LoggerConfig{
+		Skipper: defaultSkipper,
+		Format: "${time_rfc3339} ${id} ${remote_ip} ${method} ${uri} ${status} " +
+			"${latency_human} ${bytes_in} ${bytes_out}",
+	}
+)
+
+// Logger returns a middleware which logs every request with its method, path,
+// status, latency and number of bytes.
+func Logger() leego.MiddlewareFunc {
+	return LoggerWithConfig(DefaultLoggerConfig)
+}
+
+// LoggerWithConfig returns a Logger middleware from config.
+// See `Logger()`.
+func LoggerWithConfig(config LoggerConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultLoggerConfig.Skipper
+	}
+	if config.Format == "" {
+		config.Format = DefaultLoggerConfig.Format
+	}
+	segments := parseLoggerFormat(config.Format)
+	pool := sync.Pool{
+		New: func() interface{} {
+			return new(bytes.Buffer)
+		},
+	}
+	// Serializes the writes of concurrent requests to the output
+	var mu sync.Mutex
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			start := time.Now()
+			err := next(c)
+			stop := time.Now()
+
+			req := c.Request()
+			res := c.Response()
+			// The error handler runs once the middleware returns, so derive the
+			// status it will send
+			status := res.Status()
+			if err != nil && !res.Committed() {
+				status = http.StatusInternalServerError
+				if he, ok := err.(*leego.HTTPError); ok {
+					status = he.Code
+				}
+			}
+
+			buf := pool.Get().(*bytes.Buffer)
+			buf.Reset()
+			defer pool.Put(buf)
+			for _, s := range segments {
+				if s.tag == "" {
+					buf.WriteString(s.literal)
+					continue
+				}
+				switch s.tag {
+				case "time_rfc3339":
+					buf.WriteString(stop.Format(time.RFC3339))
+				case "id":
+					buf.WriteString(c.RequestID())
+				case "remote_ip":
+					buf.WriteString(c.RealIP())
+				case "host":
+					buf.WriteString(req.Host())
+				case "method":
+					buf.WriteString(req.Method())
+				case "uri":
+					buf.WriteString(req.URI())
+				case "path":
+					buf.WriteString(req.URL().Path())
+				case "route":
+					buf.WriteString(c.Path())
+				case "status":
+					buf.WriteString(strconv.Itoa(status))
+				case "latency":
+					buf.WriteString(strconv.FormatInt(int64(stop.Sub(start)), 10))
+				case "latency_human":
+					buf.WriteString(stop.Sub(start).String())
+				case "bytes_in":
+					cl := req.ContentLength()
+					if cl < 0 {
+						cl = 0
+					}
+					buf.WriteString(strconv.FormatInt(cl, 10))
+				case "bytes_out":
+					buf.WriteString(strconv.FormatInt(res.Size(), 10))
+				case "user_agent":
+					buf.WriteString(req.UserAgent())
+				default:
+					buf.WriteString(s.literal)
+				}
+			}
+
+			if config.Output == nil {
+				if l := c.Leego().Logger(); l != nil {
+					l.Info(buf.String())
+				}
+				return err
+			}
+			buf.WriteByte('\n')
+			mu.Lock()
+			config.Output.Write(buf.Bytes())
+			mu.Unlock()
+			return err
+		}
+	}
+}
+
+// parseLoggerFormat splits format into literals and `${tag}` tags. Unknown tags
+// are kept as literals.
+func parseLoggerFormat(format string) (segments []loggerSegment) {
+	for format != "" {
+		i := strings.Index(format, "${")
+		if i < 0 {
+			break
+		}
+		j := strings.Index(format[i:], "}")
+		if j < 0 {
+			break
+		}
+		if i > 0 {
+			segments = append(segments, loggerSegment{literal: format[:i]})
+		}
+		segments = append(segments, loggerSegment{
+			literal: format[i : i+j+1],
+			tag:     format[i+2 : i+j],
+		})
+		format = format[i+j+1:]
+	}
+	if format != "" {
+		segments = append(segments, loggerSegment{literal: format})
+	}
+	return
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogger(t *testing.T) {
+	e := leego.New()
+	buf := new(bytes.Buffer)
+	e.Use(RequestID(), LoggerWithConfig(LoggerConfig{
+		Format: "${id} ${method} ${path} ${route} ${status} ${latency} ${bytes_in} ${bytes_out} ${unknown}",
+		Output: buf,
+	}))
+	e.POST("/users/:id", func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusCreated, "created")
+	})
+	e.GET("/error", func(c leego.Context) leego.LeegoError {
+		return leego.NewHTTPError(http.StatusTeapot)
+	})
+
+	req := test.NewRequest(leego.POST, "/users/1", strings.NewReader("name=jon"))
+	req.Header().Set(leego.HeaderXRequestID, "abc")
+	e.ServeHTTP(req, test.NewResponseRecorder())
+	fields := strings.Fields(buf.String())
+	if assert.Len(t, fields, 9) {
+		assert.Equal(t, []string{"abc", "POST", "/users/1", "/users/:id", "201"}, fields[:5])
+		assert.NotEqual(t, "0", fields[5])
+		assert.Equal(t, []string{"8", "7", "${unknown}"}, fields[6:])
+	}
+
+	// Status from the returned error
+	buf.Reset()
+	req = test.NewRequest(leego.GET, "/error", nil)
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusTeapot, rec.Status())
+	fields = strings.Fields(buf.String())
+	if assert.Len(t, fields, 9) {
+		assert.Equal(t, "418", fields[4])
+		assert.Equal(t, "0", fields[7])
+	}
+}
+
+func TestParseLoggerFormat(t *testing.T) {
+	assert.Equal(t, []loggerSegment{
+		{literal: "ip="},
+		{literal: "${remote_ip}", tag: "remote_ip"},
+		{literal: " "},
+		{literal: "${status}", tag: "status"},
+		{literal: " ${oops"},
+	}, parseLoggerFormat("ip=${remote_ip} ${status} ${oops"))
+}
@@ -0,0 +1,99 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// PerClientConcurrencyConfig defines the config for PerClientConcurrency middleware.
+	PerClientConcurrencyConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Max is the number of concurrent requests allowed per client. Required.
+		Max int
+
+		// KeyExtractor returns the key identifying the client, e.g. an API key.
+		// Optional. Default value is the client IP.
+		KeyExtractor func(leego.Context) string
+	}
+
+	// clientCounter counts the in-flight requests per client. Clients without
+	// requests in flight are dropped, so idle clients don't accumulate.
+	clientCounter struct {
+		mu       sync.Mutex
+		inFlight map[string]int
+	}
+)
+
+var (
+	// DefaultPerClientConcurrencyConfig is the default PerClientConcurrency middleware config.
+	DefaultPerClientConcurrencyConfig = PerClientConcurrencyConfig{
+		Skipper:      defaultSkipper,
+		KeyExtractor: remoteIP,
+	}
+)
+
+// PerClientConcurrency returns a middleware which limits the concurrent requests
+// of each client, identified by keyFn or the client IP if nil, rejecting the
+// requests above max with 429, so a single client can't monopolize the server.
+func PerClientConcurrency(max int, keyFn func(leego.Context) string) leego.MiddlewareFunc {
+	c := DefaultPerClientConcurrencyConfig
+	c.Max = max
+	if keyFn != nil {
+		c.KeyExtractor = keyFn
+	}
+	return PerClientConcurrencyWithConfig(c)
+}
+
+// PerClientConcurrencyWithConfig returns a PerClientConcurrency middleware from
+// config. See `PerClientConcurrency()`.
+func PerClientConcurrencyWithConfig(config PerClientConcurrencyConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultPerClientConcurrencyConfig.Skipper
+	}
+	if config.KeyExtractor == nil {
+		config.KeyExtractor = DefaultPerClientConcurrencyConfig.KeyExtractor
+	}
+	if config.Max <= 0 {
+		panic("leego: per client concurrency middleware requires a positive max")
+	}
+	counter := &clientCounter{inFlight: make(map[string]int)}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			key := config.KeyExtractor(c)
+			if !counter.acquire(key, config.Max) {
+				return leego.NewHTTPError(http.StatusTooManyRequests)
+			}
+			defer counter.release(key)
+			return next(c)
+		}
+	}
+}
+
+func (cc *clientCounter) acquire(key string, max int) bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.inFlight[key] >= max {
+		return false
+	}
+	cc.inFlight[key]++
+	return true
+}
+
+func (cc *clientCounter) release(key string) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.inFlight[key]--; cc.inFlight[key] <= 0 {
+		delete(cc.inFlight, key)
+	}
+}
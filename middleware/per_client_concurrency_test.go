@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPerClientConcurrency(t *testing.T) {
+	e := leego.New()
+	e.Use(PerClientConcurrency(2, func(c leego.Context) string {
+		return c.Request().Header().Get("X-API-Key")
+	}))
+	started := make(chan struct{})
+	release := make(chan struct{})
+	e.GET("/slow", func(c leego.Context) leego.LeegoError {
+		started <- struct{}{}
+		<-release
+		return c.String(http.StatusOK, "slow")
+	})
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, "test")
+	})
+	get := func(path, key string) *test.ResponseRecorder {
+		req := test.NewRequest(leego.GET, path, nil)
+		req.Header().Set("X-API-Key", key)
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec
+	}
+
+	// Client a uses its share
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			get("/slow", "a")
+		}()
+		<-started
+	}
+	assert.Equal(t, http.StatusTooManyRequests, get("/", "a").Status())
+	assert.Equal(t, http.StatusOK, get("/", "b").Status())
+
+	close(release)
+	wg.Wait()
+	assert.Equal(t, http.StatusOK, get("/", "a").Status())
+}
+
+func TestClientCounterCleanup(t *testing.T) {
+	cc := &clientCounter{inFlight: make(map[string]int)}
+	assert.True(t, cc.acquire("a", 1))
+	assert.False(t, cc.acquire("a", 1))
+	cc.release("a")
+	assert.Empty(t, cc.inFlight)
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// QueryNormalizeConfig defines the config for QueryNormalize middleware.
+	QueryNormalizeConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// LowercaseKeys lowercases the query parameter names. The values of
+		// parameters differing only in case are merged.
+		LowercaseKeys bool
+
+		// TrimValues trims the leading and trailing white space of the query
+		// parameter values.
+		TrimValues bool
+
+		// Params lists the parameters to normalize, matched case-insensitively.
+		// Optional. Default value nil, which normalizes all of them.
+		Params []string
+	}
+)
+
+var (
+	// DefaultQueryNormalizeConfig is the default QueryNormalize middleware config.
+	DefaultQueryNormalizeConfig = QueryNormalizeConfig{
+		Skipper: defaultSkipper,
+	}
+)
+
+// QueryNormalize returns a middleware which normalizes the query parameters,
+// by lowercasing their names and/or trimming their values, for lenient APIs
+// accepting varied client input. It rewrites the parsed query, as read with
+// `Context#QueryParam()` and `Context#QueryParams()`, while
+// `Context#QueryString()` still returns the raw one.
+func QueryNormalize(config QueryNormalizeConfig) leego.MiddlewareFunc {
+	// Defaults
+	if !config.LowercaseKeys && !config.TrimValues {
+		panic("leego: query normalize middleware requires LowercaseKeys or TrimValues")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultQueryNormalizeConfig.Skipper
+	}
+	var allowed map[string]bool
+	if len(config.Params) > 0 {
+		allowed = make(map[string]bool, len(config.Params))
+		for _, p := range config.Params {
+			allowed[strings.ToLower(p)] = true
+		}
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			query := c.QueryParams()
+			// Sorted, so merged values are in a stable order
+			keys := make([]string, 0, len(query))
+			for k := range query {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				lower := strings.ToLower(k)
+				if allowed != nil && !allowed[lower] {
+					continue
+				}
+				values := query[k]
+				if config.TrimValues {
+					for i, v := range values {
+						values[i] = strings.TrimSpace(v)
+					}
+				}
+				if config.LowercaseKeys && lower != k {
+					delete(query, k)
+					query[lower] = append(query[lower], values...)
+				}
+			}
+			return next(c)
+		}
+	}
+}
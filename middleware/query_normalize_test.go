@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryNormalize(t *testing.T) {
+	e := leego.New()
+	var query map[string][]string
+	h := func(c leego.Context) leego.LeegoError {
+		query = c.QueryParams()
+		return c.NoContent(http.StatusOK)
+	}
+	e.GET("/all", h, QueryNormalize(QueryNormalizeConfig{
+		LowercaseKeys: true,
+		TrimValues:    true,
+	}))
+	e.GET("/some", h, QueryNormalize(QueryNormalizeConfig{
+		LowercaseKeys: true,
+		Params:        []string{"page"},
+	}))
+	get := func(uri string) {
+		e.ServeHTTP(test.NewRequest(leego.GET, uri, nil), test.NewResponseRecorder())
+	}
+
+	get("/all?Page=2&SORT=+name+&page=3&q=go")
+	assert.Equal(t, map[string][]string{
+		"page": {"3", "2"},
+		"sort": {"name"},
+		"q":    {"go"},
+	}, query)
+
+	// Allowlist
+	get("/some?PAGE=2&Sort=+name")
+	assert.Equal(t, map[string][]string{
+		"page": {"2"},
+		"Sort": {" name"},
+	}, query)
+
+	assert.Panics(t, func() { QueryNormalize(QueryNormalizeConfig{}) })
+}
@@ -0,0 +1,158 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// QueryParserConfig defines the config for QueryParser middleware.
+	QueryParserConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// SortableFields lists the fields allowed in the `sort` parameter.
+		SortableFields []string
+
+		// FilterableFields lists the fields allowed in `filter[<field>]` parameters.
+		FilterableFields []string
+
+		// DefaultPerPage is the page size used when `per_page` is not provided.
+		// Optional. Default value 20.
+		DefaultPerPage int
+
+		// MaxPerPage caps the page size.
+		// Optional. Default value 100.
+		MaxPerPage int
+
+		// ContextKey is the key the `*ParsedQuery` is stored under in the context.
+		// Optional. Default value "query".
+		ContextKey string
+	}
+
+	// ParsedQuery is the normalized form of the list query parameters.
+	ParsedQuery struct {
+		Sort    []SortField
+		Filters map[string]string
+		Page    int
+		PerPage int
+	}
+
+	// SortField is a single field of the `sort` parameter.
+	SortField struct {
+		Field string
+		Desc  bool
+	}
+)
+
+var (
+	// DefaultQueryParserConfig is the default QueryParser middleware config.
+	DefaultQueryParserConfig = QueryParserConfig{
+		Skipper:        defaultSkipper,
+		DefaultPerPage: 20,
+		MaxPerPage:     100,
+		ContextKey:     "query",
+	}
+)
+
+// QueryParser returns a middleware which parses list query parameters such as
+// `?sort=-created_at,name&filter[status]=active&page=2&per_page=50` into a
+// `*ParsedQuery` stored in the context. Sort and filter fields not in the
+// configured allowlists are rejected with 400.
+func QueryParser(config QueryParserConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultQueryParserConfig.Skipper
+	}
+	if config.DefaultPerPage == 0 {
+		config.DefaultPerPage = DefaultQueryParserConfig.DefaultPerPage
+	}
+	if config.MaxPerPage == 0 {
+		config.MaxPerPage = DefaultQueryParserConfig.MaxPerPage
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultQueryParserConfig.ContextKey
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			q, err := parseQuery(c.QueryParams(), config)
+			if err != nil {
+				return err
+			}
+			c.Set(config.ContextKey, q)
+			return next(c)
+		}
+	}
+}
+
+func parseQuery(params map[string][]string, config QueryParserConfig) (*ParsedQuery, leego.LeegoError) {
+	q := &ParsedQuery{
+		Filters: make(map[string]string),
+		Page:    1,
+		PerPage: config.DefaultPerPage,
+	}
+
+	// Sort
+	if sort := firstValue(params, "sort"); sort != "" {
+		for _, f := range strings.Split(sort, ",") {
+			f = strings.TrimSpace(f)
+			sf := SortField{Field: f}
+			if strings.HasPrefix(f, "-") {
+				sf = SortField{Field: f[1:], Desc: true}
+			}
+			if !containsString(config.SortableFields, sf.Field) {
+				return nil, leego.NewHTTPError(http.StatusBadRequest, "invalid sort field: "+sf.Field)
+			}
+			q.Sort = append(q.Sort, sf)
+		}
+	}
+
+	// Filters
+	for k, v := range params {
+		if !strings.HasPrefix(k, "filter[") || !strings.HasSuffix(k, "]") {
+			continue
+		}
+		f := k[len("filter[") : len(k)-1]
+		if !containsString(config.FilterableFields, f) {
+			return nil, leego.NewHTTPError(http.StatusBadRequest, "invalid filter field: "+f)
+		}
+		if len(v) > 0 {
+			q.Filters[f] = v[0]
+		}
+	}
+
+	// Pagination
+	if p := firstValue(params, "page"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			return nil, leego.NewHTTPError(http.StatusBadRequest, "invalid page: "+p)
+		}
+		q.Page = n
+	}
+	if p := firstValue(params, "per_page"); p != "" {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 1 {
+			return nil, leego.NewHTTPError(http.StatusBadRequest, "invalid per_page: "+p)
+		}
+		q.PerPage = n
+	}
+	if q.PerPage > config.MaxPerPage {
+		q.PerPage = config.MaxPerPage
+	}
+	return q, nil
+}
+
+func firstValue(params map[string][]string, name string) string {
+	if v := params[name]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
@@ -0,0 +1,50 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryParser(t *testing.T) {
+	e := leego.New()
+	var q *ParsedQuery
+	h := QueryParser(QueryParserConfig{
+		SortableFields:   []string{"created_at", "name"},
+		FilterableFields: []string{"status"},
+	})(func(c leego.Context) leego.LeegoError {
+		q = c.Get("query").(*ParsedQuery)
+		return nil
+	})
+
+	req := test.NewRequest(leego.GET, "/users?sort=-created_at,name&filter[status]=active&page=2&per_page=500", nil)
+	c := e.NewContext(req, test.NewResponseRecorder())
+	if assert.NoError(t, h(c)) {
+		assert.Equal(t, []SortField{{"created_at", true}, {"name", false}}, q.Sort)
+		assert.Equal(t, map[string]string{"status": "active"}, q.Filters)
+		assert.Equal(t, 2, q.Page)
+		assert.Equal(t, 100, q.PerPage)
+	}
+
+	// Defaults
+	req = test.NewRequest(leego.GET, "/users", nil)
+	c = e.NewContext(req, test.NewResponseRecorder())
+	if assert.NoError(t, h(c)) {
+		assert.Empty(t, q.Sort)
+		assert.Equal(t, 1, q.Page)
+		assert.Equal(t, 20, q.PerPage)
+	}
+
+	// Disallowed fields
+	for _, uri := range []string{"/users?sort=password", "/users?filter[role]=admin", "/users?page=0"} {
+		req = test.NewRequest(leego.GET, uri, nil)
+		c = e.NewContext(req, test.NewResponseRecorder())
+		he, ok := h(c).(*leego.HTTPError)
+		if assert.True(t, ok, uri) {
+			assert.Equal(t, http.StatusBadRequest, he.Code)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// RateLimiterConfig defines the config for RateLimiter middleware.
+	RateLimiterConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// IdentifierExtractor returns the identifier of the client the limit
+		// applies to, e.g. an API key.
+		// Optional. Default value is the client IP, see `leego.Context#RealIP()`.
+		IdentifierExtractor func(c leego.Context) (string, error)
+
+		// Store counts the requests of the clients. Required.
+		Store RateLimiterStore
+
+		// ErrorHandler returns the error for a request whose identifier can't be
+		// extracted.
+		// Optional. Default value returns a 403 error.
+		ErrorHandler func(c leego.Context, err error) leego.LeegoError
+
+		// DenyHandler returns the error for a request over the limit, or which
+		// the store failed to count, with err set.
+		// Optional. Default value returns a 429 error.
+		DenyHandler func(c leego.Context, identifier string, err error) leego.LeegoError
+	}
+
+	// RateLimiterStore is the interface of the stores counting the requests of
+	// the clients for `RateLimiter`, which may be backed by e.g. Redis to share
+	// the limits among instances.
+	RateLimiterStore interface {
+		// Allow returns whether the request of the client with identifier is
+		// allowed, counting it if so.
+		Allow(identifier string) (bool, error)
+	}
+
+	// RateLimiterMemoryStoreConfig defines the config for RateLimiterMemoryStore.
+	RateLimiterMemoryStoreConfig struct {
+		// Rate is the number of requests per second allowed per client, on
+		// average. Required.
+		Rate float64
+
+		// Burst is the number of requests a client may make at once.
+		// Optional. Default value is the rate rounded down, at least 1.
+		Burst int
+
+		// ExpiresIn is the duration after which the bucket of an idle client is
+		// dropped.
+		// Optional. Default value 3 minutes.
+		ExpiresIn time.Duration
+	}
+
+	// RateLimiterMemoryStore is an in-memory `RateLimiterStore`, limiting the
+	// requests of each client with a token bucket: it holds up to burst tokens,
+	// refilled at rate tokens per second, and a request takes one.
+	RateLimiterMemoryStore struct {
+		mu        sync.Mutex
+		rate      float64
+		burst     float64
+		expiresIn time.Duration
+		buckets   map[string]*tokenBucket
+		lastSweep time.Time
+		// now is replaced in tests
+		now func() time.Time
+	}
+
+	// tokenBucket is the token bucket of a client.
+	tokenBucket struct {
+		tokens float64
+		last   time.Time
+	}
+)
+
+var (
+	// DefaultRateLimiterConfig is the default RateLimiter middleware config.
+	DefaultRateLimiterConfig = RateLimiterConfig{
+		Skipper: defaultSkipper,
+		IdentifierExtractor: func(c leego.Context) (string, error) {
+			return c.RealIP(), nil
+		},
+		ErrorHandler: func(c leego.Context, err error) leego.LeegoError {
+			return leego.NewHTTPError(http.StatusForbidden, "error while extracting identifier")
+		},
+		DenyHandler: func(c leego.Context, identifier string, err error) leego.LeegoError {
+			return leego.NewHTTPError(http.StatusTooManyRequests, "rate limit exceeded")
+		},
+	}
+
+	// DefaultRateLimiterMemoryStoreConfig is the default RateLimiterMemoryStore
+	// config.
+	DefaultRateLimiterMemoryStoreConfig = RateLimiterMemoryStoreConfig{
+		ExpiresIn: 3 * time.Minute,
+	}
+)
+
+// RateLimiter returns a middleware which limits the rate of the requests of
+// each client, identified by its IP, counted by store. The requests over the
+// limit get 429.
+//
+// Usage `e.Use(RateLimiter(NewRateLimiterMemoryStore(10)))`
+func RateLimiter(store RateLimiterStore) leego.MiddlewareFunc {
+	c := DefaultRateLimiterConfig
+	c.Store = store
+	return RateLimiterWithConfig(c)
+}
+
+// RateLimiterWithConfig returns a RateLimiter middleware from config.
+// See `RateLimiter()`.
+func RateLimiterWithConfig(config RateLimiterConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRateLimiterConfig.Skipper
+	}
+	if config.IdentifierExtractor == nil {
+		config.IdentifierExtractor = DefaultRateLimiterConfig.IdentifierExtractor
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = DefaultRateLimiterConfig.ErrorHandler
+	}
+	if config.DenyHandler == nil {
+		config.DenyHandler = DefaultRateLimiterConfig.DenyHandler
+	}
+	if config.Store == nil {
+		panic("leego: rate limiter middleware requires a store")
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			id, err := config.IdentifierExtractor(c)
+			if err != nil {
+				return config.ErrorHandler(c, err)
+			}
+			if allow, err := config.Store.Allow(id); !allow || err != nil {
+				return config.DenyHandler(c, id, err)
+			}
+			return next(c)
+		}
+	}
+}
+
+// NewRateLimiterMemoryStore returns a `RateLimiterMemoryStore` allowing rate
+// requests per second per client, with a burst of rate.
+func NewRateLimiterMemoryStore(rate float64) *RateLimiterMemoryStore {
+	c := DefaultRateLimiterMemoryStoreConfig
+	c.Rate = rate
+	return NewRateLimiterMemoryStoreWithConfig(c)
+}
+
+// NewRateLimiterMemoryStoreWithConfig returns a `RateLimiterMemoryStore` from
+// config.
+func NewRateLimiterMemoryStoreWithConfig(config RateLimiterMemoryStoreConfig) *RateLimiterMemoryStore {
+	if config.Rate <= 0 {
+		panic("leego: rate limiter memory store requires a positive rate")
+	}
+	if config.Burst <= 0 {
+		config.Burst = int(math.Max(1, math.Floor(config.Rate)))
+	}
+	if config.ExpiresIn <= 0 {
+		config.ExpiresIn = DefaultRateLimiterMemoryStoreConfig.ExpiresIn
+	}
+	return &RateLimiterMemoryStore{
+		rate:      config.Rate,
+		burst:     float64(config.Burst),
+		expiresIn: config.ExpiresIn,
+		buckets:   make(map[string]*tokenBucket),
+		now:       time.Now,
+	}
+}
+
+// Allow implements `RateLimiterStore#Allow` function.
+func (s *RateLimiterMemoryStore) Allow(identifier string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+	b, ok := s.buckets[identifier]
+	if !ok {
+		b = &tokenBucket{tokens: s.burst, last: now}
+		s.buckets[identifier] = b
+	}
+	// Refill for the time elapsed since the last request
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(s.burst, b.tokens+elapsed.Seconds()*s.rate)
+	}
+	b.last = now
+	if b.tokens < 1 {
+		return false, nil
+	}
+	b.tokens--
+	return true, nil
+}
+
+// sweep drops the buckets of the clients idle for longer than expiresIn, at
+// most once per expiresIn, so idle clients don't accumulate.
+func (s *RateLimiterMemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < s.expiresIn {
+		return
+	}
+	s.lastSweep = now
+	for id, b := range s.buckets {
+		if now.Sub(b.last) > s.expiresIn {
+			delete(s.buckets, id)
+		}
+	}
+}
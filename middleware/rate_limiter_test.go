@@ -0,0 +1,95 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiterMemoryStore(t *testing.T) {
+	now := time.Now()
+	s := NewRateLimiterMemoryStoreWithConfig(RateLimiterMemoryStoreConfig{
+		Rate:  1,
+		Burst: 3,
+	})
+	s.now = func() time.Time { return now }
+
+	// Burst
+	for i := 0; i < 3; i++ {
+		allow, err := s.Allow("a")
+		assert.NoError(t, err)
+		assert.True(t, allow, "request %d", i)
+	}
+	allow, _ := s.Allow("a")
+	assert.False(t, allow)
+
+	// Per client
+	allow, _ = s.Allow("b")
+	assert.True(t, allow)
+
+	// Refill
+	now = now.Add(500 * time.Millisecond)
+	allow, _ = s.Allow("a")
+	assert.False(t, allow)
+	now = now.Add(500 * time.Millisecond)
+	allow, _ = s.Allow("a")
+	assert.True(t, allow)
+	allow, _ = s.Allow("a")
+	assert.False(t, allow)
+
+	// Up to the burst
+	now = now.Add(time.Hour)
+	for i := 0; i < 3; i++ {
+		allow, _ = s.Allow("a")
+		assert.True(t, allow)
+	}
+	allow, _ = s.Allow("a")
+	assert.False(t, allow)
+
+	// Idle clients are dropped
+	assert.Len(t, s.buckets, 1)
+}
+
+func TestRateLimiter(t *testing.T) {
+	e := leego.New()
+	e.Use(RateLimiter(NewRateLimiterMemoryStoreWithConfig(RateLimiterMemoryStoreConfig{
+		Rate:  1,
+		Burst: 2,
+	})))
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		return c.NoContent(http.StatusOK)
+	})
+	get := func(ip string) int {
+		req := test.NewRequest(leego.GET, "/", nil)
+		req.Header().Set(leego.HeaderXForwardedFor, ip+", 10.0.0.1")
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec.Status()
+	}
+	assert.Equal(t, http.StatusOK, get("1.1.1.1"))
+	assert.Equal(t, http.StatusOK, get("1.1.1.1"))
+	assert.Equal(t, http.StatusTooManyRequests, get("1.1.1.1"))
+	assert.Equal(t, http.StatusOK, get("2.2.2.2"))
+
+	// Identifier error
+	e = leego.New()
+	e.Use(RateLimiterWithConfig(RateLimiterConfig{
+		Store: NewRateLimiterMemoryStore(1),
+		IdentifierExtractor: func(c leego.Context) (string, error) {
+			return "", errors.New("no key")
+		},
+	}))
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		return c.NoContent(http.StatusOK)
+	})
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.GET, "/", nil), rec)
+	assert.Equal(t, http.StatusForbidden, rec.Status())
+
+	assert.Panics(t, func() { RateLimiter(nil) })
+}
@@ -0,0 +1,161 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// RedirectConfig defines the config for Redirect middleware.
+	RedirectConfig struct {
+		// Skipper defines a function to skip middleware, e.g. for health checks
+		// over plain HTTP.
+		Skipper Skipper
+
+		// Status code to be used when redirecting the request.
+		// Optional. Default value http.StatusMovedPermanently.
+		Code int `json:"code"`
+	}
+
+	// redirector returns the URL to redirect to for the scheme and host of the
+	// request, or false if it needn't be redirected.
+	redirector func(scheme, host, uri string) (string, bool)
+)
+
+const www = "www."
+
+var (
+	// DefaultRedirectConfig is the default Redirect middleware config.
+	DefaultRedirectConfig = RedirectConfig{
+		Skipper: defaultSkipper,
+		Code:    http.StatusMovedPermanently,
+	}
+)
+
+// HTTPSRedirect returns a root level (before router) middleware which redirects
+// http requests to https, e.g. http://leego.com to https://leego.com.
+//
+// Usage `Leego#Pre(HTTPSRedirect())`
+func HTTPSRedirect() leego.MiddlewareFunc {
+	return HTTPSRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// HTTPSRedirectWithConfig returns a HTTPSRedirect middleware from config.
+// See `HTTPSRedirect()`.
+func HTTPSRedirectWithConfig(config RedirectConfig) leego.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (string, bool) {
+		if scheme == "https" {
+			return "", false
+		}
+		return "https://" + host + uri, true
+	})
+}
+
+// HTTPSWWWRedirect returns a root level (before router) middleware which
+// redirects http requests to https www, e.g. http://leego.com to
+// https://www.leego.com.
+//
+// Usage `Leego#Pre(HTTPSWWWRedirect())`
+func HTTPSWWWRedirect() leego.MiddlewareFunc {
+	return HTTPSWWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// HTTPSWWWRedirectWithConfig returns a HTTPSWWWRedirect middleware from config.
+// See `HTTPSWWWRedirect()`.
+func HTTPSWWWRedirectWithConfig(config RedirectConfig) leego.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (string, bool) {
+		if scheme == "https" && strings.HasPrefix(host, www) {
+			return "", false
+		}
+		if !strings.HasPrefix(host, www) {
+			host = www + host
+		}
+		return "https://" + host + uri, true
+	})
+}
+
+// WWWRedirect returns a root level (before router) middleware which redirects
+// non www requests to www, keeping the scheme, e.g. http://leego.com to
+// http://www.leego.com.
+//
+// Usage `Leego#Pre(WWWRedirect())`
+func WWWRedirect() leego.MiddlewareFunc {
+	return WWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// WWWRedirectWithConfig returns a WWWRedirect middleware from config.
+// See `WWWRedirect()`.
+func WWWRedirectWithConfig(config RedirectConfig) leego.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (string, bool) {
+		if strings.HasPrefix(host, www) {
+			return "", false
+		}
+		return scheme + "://" + www + host + uri, true
+	})
+}
+
+// NonWWWRedirect returns a root level (before router) middleware which
+// redirects www requests to non www, keeping the scheme, e.g.
+// http://www.leego.com to http://leego.com.
+//
+// Usage `Leego#Pre(NonWWWRedirect())`
+func NonWWWRedirect() leego.MiddlewareFunc {
+	return NonWWWRedirectWithConfig(DefaultRedirectConfig)
+}
+
+// NonWWWRedirectWithConfig returns a NonWWWRedirect middleware from config.
+// See `NonWWWRedirect()`.
+func NonWWWRedirectWithConfig(config RedirectConfig) leego.MiddlewareFunc {
+	return redirect(config, func(scheme, host, uri string) (string, bool) {
+		if !strings.HasPrefix(host, www) {
+			return "", false
+		}
+		return scheme + "://" + host[len(www):] + uri, true
+	})
+}
+
+// redirect returns a middleware redirecting the requests for which r returns a
+// URL, keeping the path and query string.
+func redirect(config RedirectConfig, r redirector) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRedirectConfig.Skipper
+	}
+	if config.Code == 0 {
+		config.Code = DefaultRedirectConfig.Code
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			req := c.Request()
+			scheme := req.Scheme()
+			// Behind a TLS terminating proxy
+			if proto := req.Header().Get(leego.HeaderXForwardedProto); proto != "" {
+				scheme = strings.ToLower(proto)
+			}
+			// As sent, so escaped characters, e.g. `%3F`, stay escaped
+			uri := req.URI()
+			if i := strings.Index(uri, "://"); i >= 0 && !strings.HasPrefix(uri, "/") {
+				// Absolute form, e.g. from a proxy
+				uri = uri[i+3:]
+				if i = strings.IndexAny(uri, "/?"); i < 0 {
+					uri = "/"
+				} else if uri[i] == '?' {
+					uri = "/" + uri[i:]
+				} else {
+					uri = uri[i:]
+				}
+			}
+			if url, ok := r(scheme, req.Host(), uri); ok {
+				return c.Redirect(config.Code, url)
+			}
+			return next(c)
+		}
+	}
+}
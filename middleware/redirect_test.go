@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedirect(t *testing.T) {
+	serve := func(mw leego.MiddlewareFunc, url, proto string) (int, string) {
+		e := leego.New()
+		e.Pre(mw)
+		e.GET("/*", func(c leego.Context) leego.LeegoError {
+			return c.NoContent(http.StatusOK)
+		})
+		req := test.NewRequest(leego.GET, url, nil)
+		if proto != "" {
+			req.Header().Set(leego.HeaderXForwardedProto, proto)
+		}
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec.Status(), rec.Header().Get(leego.HeaderLocation)
+	}
+
+	tests := []struct {
+		name     string
+		mw       leego.MiddlewareFunc
+		url      string
+		proto    string
+		code     int
+		location string
+	}{
+		{"https", HTTPSRedirect(), "http://leego.com/a?b=c", "", http.StatusMovedPermanently, "https://leego.com/a?b=c"},
+		{"https escaped", HTTPSRedirect(), "http://leego.io/a%3Fb/c%20d?x=1", "", http.StatusMovedPermanently, "https://leego.io/a%3Fb/c%20d?x=1"},
+		{"https no path", HTTPSRedirect(), "http://leego.io?x=1", "", http.StatusMovedPermanently, "https://leego.io/?x=1"},
+		{"https proxied", HTTPSRedirect(), "http://leego.com/a", "https", http.StatusOK, ""},
+		{"https www", HTTPSWWWRedirect(), "http://leego.com/a?b=c", "", http.StatusMovedPermanently, "https://www.leego.com/a?b=c"},
+		{"https www from www", HTTPSWWWRedirect(), "http://www.leego.com/a", "", http.StatusMovedPermanently, "https://www.leego.com/a"},
+		{"https www from https", HTTPSWWWRedirect(), "http://leego.com/a", "https", http.StatusMovedPermanently, "https://www.leego.com/a"},
+		{"https www done", HTTPSWWWRedirect(), "http://www.leego.com/a", "https", http.StatusOK, ""},
+		{"www", WWWRedirect(), "http://leego.com/a?b=c", "", http.StatusMovedPermanently, "http://www.leego.com/a?b=c"},
+		{"www keeps scheme", WWWRedirect(), "http://leego.com/a", "https", http.StatusMovedPermanently, "https://www.leego.com/a"},
+		{"www done", WWWRedirect(), "http://www.leego.com/a", "", http.StatusOK, ""},
+		{"non www", NonWWWRedirect(), "http://www.leego.com/a?b=c", "", http.StatusMovedPermanently, "http://leego.com/a?b=c"},
+		{"non www done", NonWWWRedirect(), "http://leego.com/a", "", http.StatusOK, ""},
+		{"code", HTTPSRedirectWithConfig(RedirectConfig{Code: http.StatusTemporaryRedirect}), "http://leego.com/a", "", http.StatusTemporaryRedirect, "https://leego.com/a"},
+		{"skipped", HTTPSRedirectWithConfig(RedirectConfig{
+			Skipper: func(c leego.Context) bool {
+				return strings.HasPrefix(c.Request().URL().Path(), "/healthz")
+			},
+		}), "http://leego.com/healthz", "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		code, location := serve(tt.mw, tt.url, tt.proto)
+		assert.Equal(t, tt.code, code, tt.name)
+		assert.Equal(t, tt.location, location, tt.name)
+	}
+
+	// Origin form, as sent by clients
+	e := leego.New()
+	e.Pre(HTTPSRedirect())
+	req := test.NewRequest(leego.GET, "http://leego.io/a%3Fb/c%20d?x=1", nil)
+	req.SetURI("/a%3Fb/c%20d?x=1")
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, "https://leego.io/a%3Fb/c%20d?x=1", rec.Header().Get(leego.HeaderLocation))
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// RemoveHeadersConfig defines the config for RemoveHeaders middleware.
+	RemoveHeadersConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Headers lists the response headers to remove.
+		// Optional. Default value is `Server` and `X-Powered-By`.
+		Headers []string
+	}
+)
+
+var (
+	// DefaultRemoveHeadersConfig is the default RemoveHeaders middleware config.
+	DefaultRemoveHeadersConfig = RemoveHeadersConfig{
+		Skipper: defaultSkipper,
+		Headers: []string{leego.HeaderServer, "X-Powered-By"},
+	}
+)
+
+// RemoveHeaders returns a middleware which removes the named headers from the
+// response just before it's committed, so headers leaking implementation
+// details are dropped even if set by downstream handlers.
+func RemoveHeaders(names ...string) leego.MiddlewareFunc {
+	c := DefaultRemoveHeadersConfig
+	if len(names) > 0 {
+		c.Headers = names
+	}
+	return RemoveHeadersWithConfig(c)
+}
+
+// RemoveHeadersWithConfig returns a RemoveHeaders middleware from config.
+// See `RemoveHeaders()`.
+func RemoveHeadersWithConfig(config RemoveHeadersConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRemoveHeadersConfig.Skipper
+	}
+	if len(config.Headers) == 0 {
+		config.Headers = DefaultRemoveHeadersConfig.Headers
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			res := c.Response()
+			res.Before(func() {
+				for _, h := range config.Headers {
+					res.Header().Del(h)
+				}
+			})
+			return next(c)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemoveHeaders(t *testing.T) {
+	e := leego.New()
+	e.Use(RemoveHeaders())
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		c.Response().Header().Set(leego.HeaderServer, "leego/1.0")
+		c.Response().Header().Set("X-Powered-By", "Go")
+		c.Response().Header().Set("X-Request-ID", "1")
+		return c.String(http.StatusOK, "test")
+	})
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.GET, "/", nil), rec)
+	assert.Equal(t, "test", rec.Body.String())
+	assert.Empty(t, rec.Header().Get(leego.HeaderServer))
+	assert.Empty(t, rec.Header().Get("X-Powered-By"))
+	assert.Equal(t, "1", rec.Header().Get("X-Request-ID"))
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// RequestDeadlineConfig defines the config for RequestDeadline middleware.
+	RequestDeadlineConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Max is the longest timeout a client can request. Longer ones are
+		// clamped to it.
+		// Optional. Default value 1 minute.
+		Max time.Duration
+	}
+)
+
+var (
+	// DefaultRequestDeadlineConfig is the default RequestDeadline middleware config.
+	DefaultRequestDeadlineConfig = RequestDeadlineConfig{
+		Skipper: defaultSkipper,
+		Max:     time.Minute,
+	}
+
+	// grpcTimeoutUnits maps the units of the `grpc-timeout` header.
+	grpcTimeoutUnits = map[byte]time.Duration{
+		'H': time.Hour,
+		'M': time.Minute,
+		'S': time.Second,
+		'm': time.Millisecond,
+		'u': time.Microsecond,
+		'n': time.Nanosecond,
+	}
+)
+
+// RequestDeadline returns a middleware which sets the timeout requested by the
+// client on the request, see `Context#SetTimeout()`, so handlers and downstream
+// calls using `Context#Context()` give up when the client does. The timeout is
+// read from the `X-Request-Timeout` header, in seconds or as a duration such as
+// `1.5s`, or the gRPC-style `grpc-timeout` header, e.g. `200m`, and clamped to
+// the configured maximum. Malformed timeouts are ignored.
+func RequestDeadline() leego.MiddlewareFunc {
+	return RequestDeadlineWithConfig(DefaultRequestDeadlineConfig)
+}
+
+// RequestDeadlineWithConfig returns a RequestDeadline middleware from config.
+// See `RequestDeadline()`.
+func RequestDeadlineWithConfig(config RequestDeadlineConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRequestDeadlineConfig.Skipper
+	}
+	if config.Max == 0 {
+		config.Max = DefaultRequestDeadlineConfig.Max
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			h := c.Request().Header()
+			d, ok := parseRequestTimeout(h.Get("X-Request-Timeout"), config.Max)
+			if !ok {
+				d, ok = parseGRPCTimeout(h.Get("grpc-timeout"))
+			}
+			if ok {
+				if d > config.Max {
+					d = config.Max
+				}
+				c.SetTimeout(d)
+			}
+			return next(c)
+		}
+	}
+}
+
+// parseRequestTimeout parses a timeout in seconds, e.g. `2.5`, or a duration,
+// e.g. `2500ms`. Seconds over max, e.g. `1e300` or `inf`, are clamped to it, as
+// they overflow a `time.Duration`.
+func parseRequestTimeout(v string, max time.Duration) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.ParseFloat(v, 64); err == nil {
+		if s > max.Seconds() {
+			return max, true
+		}
+		return time.Duration(s * float64(time.Second)), s > 0
+	}
+	d, err := time.ParseDuration(v)
+	return d, err == nil && d > 0
+}
+
+// parseGRPCTimeout parses a `grpc-timeout` header value, up to 8 digits followed
+// by a unit, e.g. `100m`.
+func parseGRPCTimeout(v string) (time.Duration, bool) {
+	if len(v) < 2 || len(v) > 9 {
+		return 0, false
+	}
+	unit, ok := grpcTimeoutUnits[v[len(v)-1]]
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(strings.TrimSpace(v[:len(v)-1]), 10, 64)
+	if err != nil || n <= 0 {
+		return 0, false
+	}
+	if n > int64(1<<63-1)/int64(unit) {
+		// Overflows, which is over any sensible maximum anyway
+		return 1<<63 - 1, true
+	}
+	return time.Duration(n) * unit, true
+}
@@ -0,0 +1,96 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestDeadline(t *testing.T) {
+	e := leego.New()
+	e.Use(RequestDeadlineWithConfig(RequestDeadlineConfig{Max: time.Second}))
+	var remaining time.Duration
+	var downstream error
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		if deadline, ok := c.Context().Deadline(); ok {
+			remaining = time.Until(deadline)
+		} else {
+			remaining = 0
+		}
+		// A downstream operation giving up with the request
+		select {
+		case <-c.Context().Done():
+			downstream = c.Context().Err()
+		case <-time.After(200 * time.Millisecond):
+			downstream = nil
+		}
+		return c.NoContent(http.StatusOK)
+	})
+	get := func(header, value string) {
+		req := test.NewRequest(leego.GET, "/", nil)
+		if header != "" {
+			req.Header().Set(header, value)
+		}
+		e.ServeHTTP(req, test.NewResponseRecorder())
+	}
+
+	get("X-Request-Timeout", "0.05")
+	assert.True(t, remaining > 0 && remaining <= 50*time.Millisecond)
+	assert.Error(t, downstream)
+
+	get("grpc-timeout", "50m")
+	assert.True(t, remaining > 0 && remaining <= 50*time.Millisecond)
+	assert.Error(t, downstream)
+
+	// Clamped
+	get("X-Request-Timeout", "1h")
+	assert.True(t, remaining > 500*time.Millisecond && remaining <= time.Second)
+	assert.NoError(t, downstream)
+
+	// No or malformed header
+	get("", "")
+	assert.Zero(t, remaining)
+	get("grpc-timeout", "50x")
+	assert.Zero(t, remaining)
+	assert.NoError(t, downstream)
+}
+
+func TestParseGRPCTimeout(t *testing.T) {
+	for v, d := range map[string]time.Duration{
+		"1H":        time.Hour,
+		"30S":       30 * time.Second,
+		"250m":      250 * time.Millisecond,
+		"10u":       10 * time.Microsecond,
+		"99999999H": 1<<63 - 1,
+	} {
+		got, ok := parseGRPCTimeout(v)
+		assert.True(t, ok, v)
+		assert.Equal(t, d, got, v)
+	}
+	for _, v := range []string{"", "1", "H", "-1S", "123456789S", "1s"} {
+		_, ok := parseGRPCTimeout(v)
+		assert.False(t, ok, v)
+	}
+}
+
+func TestParseRequestTimeout(t *testing.T) {
+	for v, d := range map[string]time.Duration{
+		"2.5":    2500 * time.Millisecond,
+		"2500ms": 2500 * time.Millisecond,
+		"1e300":  time.Minute,
+		"inf":    time.Minute,
+		"1h":     time.Hour,
+	} {
+		got, ok := parseRequestTimeout(v, time.Minute)
+		assert.True(t, ok, v)
+		assert.Equal(t, d, got, v)
+	}
+	for _, v := range []string{"", "0", "-1", "-inf", "NaN", "1e400", "x"} {
+		_, ok := parseRequestTimeout(v, time.Minute)
+		assert.False(t, ok, v)
+	}
+}
@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// RequestIDConfig defines the config for RequestID middleware.
+	RequestIDConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Generator returns a new request id.
+		// Optional. Default value generates a random 32-character hex string.
+		Generator func() string
+
+		// TargetHeader is the header the request id is read from and echoed back
+		// in.
+		// Optional. Default value "X-Request-ID".
+		TargetHeader string
+	}
+)
+
+var (
+	// DefaultRequestIDConfig is the default RequestID middleware config.
+	DefaultRequestIDConfig = RequestIDConfig{
+		Skipper:      defaultSkipper,
+		Generator:    generateRequestID,
+		TargetHeader: leego.HeaderXRequestID,
+	}
+)
+
+// RequestID returns a middleware which assigns an id to every request, for
+// tracing across services. The id sent by the client in the `X-Request-ID`
+// header is kept, otherwise a new one is generated. It's echoed back in the
+// response header and available to handlers with `Context#RequestID()`.
+func RequestID() leego.MiddlewareFunc {
+	return RequestIDWithConfig(DefaultRequestIDConfig)
+}
+
+// RequestIDWithConfig returns a RequestID middleware from config.
+// See `RequestID()`.
+func RequestIDWithConfig(config RequestIDConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultRequestIDConfig.Skipper
+	}
+	if config.Generator == nil {
+		config.Generator = DefaultRequestIDConfig.Generator
+	}
+	if config.TargetHeader == "" {
+		config.TargetHeader = DefaultRequestIDConfig.TargetHeader
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			id := c.Request().Header().Get(config.TargetHeader)
+			if id == "" {
+				id = config.Generator()
+			}
+			c.SetData(leego.RequestIDKey, id)
+			c.Response().Header().Set(config.TargetHeader, id)
+			return next(c)
+		}
+	}
+}
+
+func generateRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("leego: failed to generate request id: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,58 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	e := leego.New()
+	e.Use(RequestID())
+	var id string
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		id = c.RequestID()
+		return c.NoContent(http.StatusOK)
+	})
+
+	// Generated
+	req := test.NewRequest(leego.GET, "/", nil)
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, rec.Header().Get(leego.HeaderXRequestID))
+	first := id
+
+	req = test.NewRequest(leego.GET, "/", nil)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Len(t, id, 32)
+	assert.NotEqual(t, first, id)
+
+	// Incoming
+	req = test.NewRequest(leego.GET, "/", nil)
+	req.Header().Set(leego.HeaderXRequestID, "abc")
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, "abc", id)
+	assert.Equal(t, "abc", rec.Header().Get(leego.HeaderXRequestID))
+
+	// Custom
+	e = leego.New()
+	e.Use(RequestIDWithConfig(RequestIDConfig{
+		Generator:    func() string { return "custom" },
+		TargetHeader: "X-Trace-ID",
+	}))
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		id = c.RequestID()
+		return c.NoContent(http.StatusOK)
+	})
+	req = test.NewRequest(leego.GET, "/", nil)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, "custom", id)
+	assert.Equal(t, "custom", rec.Header().Get("X-Trace-ID"))
+}
@@ -0,0 +1,232 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/engine/standard"
+)
+
+type (
+	// RequestReplayConfig defines the config for RequestReplay middleware.
+	RequestReplayConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Store keeps the recorded requests. Required.
+		Store ReplayStore
+
+		// MaxBodySize is the size in bytes of the request body recorded at most.
+		// A request with a larger body is recorded with `BodyTruncated` set, and
+		// can't be replayed.
+		// Optional. Default value 1 MB.
+		MaxBodySize int64
+	}
+
+	// ReplayStore is the interface of the storage of recorded requests.
+	ReplayStore interface {
+		// Save stores the request under its ID.
+		Save(*RecordedRequest)
+
+		// Load returns the request recorded under id, or nil if it's not found.
+		Load(id string) *RecordedRequest
+	}
+
+	// RecordedRequest holds the details of a request needed to replay it.
+	RecordedRequest struct {
+		ID     string
+		Time   time.Time
+		Method string
+		URI    string
+		Host   string
+		Header map[string][]string
+		Body   []byte
+
+		// BodyTruncated tells the body was cut to `RequestReplayConfig.MaxBodySize`.
+		BodyTruncated bool
+	}
+
+	// MemoryReplayStore is a `ReplayStore` which keeps the most recent requests
+	// in memory, evicting the oldest ones once its size is reached.
+	MemoryReplayStore struct {
+		mu       sync.Mutex
+		size     int
+		ids      []string
+		requests map[string]*RecordedRequest
+	}
+)
+
+const (
+	// HeaderXReplayID is the response header carrying the ID a request was
+	// recorded under.
+	HeaderXReplayID = "X-Replay-ID"
+
+	// HeaderXReplayOf marks a replayed request with the ID of the original one.
+	// Replayed requests are not recorded again.
+	HeaderXReplayOf = "X-Replay-Of"
+)
+
+var (
+	// DefaultRequestReplayConfig is the default RequestReplay middleware config.
+	DefaultRequestReplayConfig = RequestReplayConfig{
+		Skipper:     defaultSkipper,
+		MaxBodySize: 1 << 20, // 1 MB
+	}
+)
+
+// NewMemoryReplayStore returns a `MemoryReplayStore` keeping up to size
+// requests. It panics if size isn't positive.
+func NewMemoryReplayStore(size int) *MemoryReplayStore {
+	if size <= 0 {
+		panic("leego: memory replay store requires a positive size")
+	}
+	return &MemoryReplayStore{
+		size:     size,
+		requests: make(map[string]*RecordedRequest, size),
+	}
+}
+
+// Save implements `ReplayStore#Save` function.
+func (s *MemoryReplayStore) Save(r *RecordedRequest) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// A request saved again keeps its place, so its ID isn't listed twice
+	if _, ok := s.requests[r.ID]; ok {
+		s.requests[r.ID] = r
+		return
+	}
+	if len(s.ids) >= s.size {
+		delete(s.requests, s.ids[0])
+		s.ids = s.ids[1:]
+	}
+	s.ids = append(s.ids, r.ID)
+	s.requests[r.ID] = r
+}
+
+// Load implements `ReplayStore#Load` function.
+func (s *MemoryReplayStore) Load(id string) *RecordedRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[id]
+}
+
+// RequestReplay returns a middleware which records the requests in store, so
+// developers can reproduce bugs by replaying them with `ReplayHandler()`. The ID
+// a request is recorded under is sent in the `X-Replay-ID` response header.
+//
+// The request body is read into memory to be recorded, up to a limit.
+// Recording is strictly limited to debug mode, see `Leego#SetDebug()`, as the
+// requests may carry credentials and personal data.
+func RequestReplay(store ReplayStore) leego.MiddlewareFunc {
+	c := DefaultRequestReplayConfig
+	c.Store = store
+	return RequestReplayWithConfig(c)
+}
+
+// RequestReplayWithConfig returns a RequestReplay middleware from config.
+// See `RequestReplay()`.
+func RequestReplayWithConfig(config RequestReplayConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Store == nil {
+		panic("leego: request replay middleware requires a store")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultRequestReplayConfig.Skipper
+	}
+	if config.MaxBodySize == 0 {
+		config.MaxBodySize = DefaultRequestReplayConfig.MaxBodySize
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			req := c.Request()
+			if config.Skipper(c) || !c.Leego().Debug() || req.Header().Get(HeaderXReplayOf) != "" {
+				return next(c)
+			}
+
+			var body []byte
+			truncated := false
+			if req.Body() != nil {
+				// One byte over the limit tells a body at the limit from a larger one
+				b, err := ioutil.ReadAll(io.LimitReader(req.Body(), config.MaxBodySize+1))
+				if err != nil {
+					return err
+				}
+				if int64(len(b)) > config.MaxBodySize {
+					// The handler still reads the whole body
+					req.SetBody(io.MultiReader(bytes.NewReader(b), req.Body()))
+					b, truncated = b[:config.MaxBodySize], true
+				} else {
+					req.SetBody(bytes.NewReader(b))
+				}
+				body = b
+			}
+			header := make(map[string][]string)
+			for _, k := range req.Header().Keys() {
+				header[k] = append([]string(nil), req.Header().Values(k)...)
+			}
+			r := &RecordedRequest{
+				ID:            replayID(),
+				Time:          time.Now(),
+				Method:        req.Method(),
+				URI:           req.URI(),
+				Host:          req.Host(),
+				Header:        header,
+				Body:          body,
+				BodyTruncated: truncated,
+			}
+			config.Store.Save(r)
+			c.Response().Header().Set(HeaderXReplayID, r.ID)
+			return next(c)
+		}
+	}
+}
+
+// ReplayHandler returns an admin handler which replays the request recorded in
+// store under the `id` path parameter against the server, responding with the
+// response of the replayed request. It responds with 404 unless in debug mode,
+// and with 422 if the recorded body is truncated.
+//
+// Usage `admin.POST("/replay/:id", middleware.ReplayHandler(store))`
+func ReplayHandler(store ReplayStore) leego.HandlerFunc {
+	return func(c leego.Context) leego.LeegoError {
+		if !c.Leego().Debug() {
+			return leego.ErrNotFound
+		}
+		r := store.Load(c.Param("id"))
+		if r == nil {
+			return leego.ErrNotFound
+		}
+		if r.BodyTruncated {
+			return leego.NewHTTPError(http.StatusUnprocessableEntity, "recorded request body is truncated")
+		}
+
+		hr, err := http.NewRequest(r.Method, r.URI, bytes.NewReader(r.Body))
+		if err != nil {
+			return err
+		}
+		hr.RequestURI = r.URI
+		hr.Host = r.Host
+		for k, vs := range r.Header {
+			for _, v := range vs {
+				hr.Header.Add(k, v)
+			}
+		}
+		hr.Header.Set(HeaderXReplayOf, r.ID)
+		c.Leego().ServeHTTP(standard.NewRequest(hr), c.Response())
+		return nil
+	}
+}
+
+func replayID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
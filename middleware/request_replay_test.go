@@ -0,0 +1,122 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestReplay(t *testing.T) {
+	e := leego.New()
+	store := NewMemoryReplayStore(2)
+	e.Use(RequestReplay(store))
+	var bodies []string
+	e.POST("/orders", func(c leego.Context) leego.LeegoError {
+		b, _ := ioutil.ReadAll(c.Request().Body())
+		bodies = append(bodies, string(b))
+		return c.String(http.StatusCreated, c.Request().Header().Get("X-Tenant")+":"+string(b))
+	})
+	e.POST("/replay/:id", ReplayHandler(store))
+	order := func() *test.ResponseRecorder {
+		req := test.NewRequest(leego.POST, "/orders", strings.NewReader(`{"qty":1}`))
+		req.Header().Set("X-Tenant", "acme")
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec
+	}
+
+	// Off unless in debug mode
+	rec := order()
+	assert.Empty(t, rec.Header().Get(HeaderXReplayID))
+
+	e.SetDebug(true)
+	rec = order()
+	id := rec.Header().Get(HeaderXReplayID)
+	if assert.NotEmpty(t, id) {
+		// The handler still reads the full body
+		assert.Equal(t, `{"qty":1}`, bodies[1])
+	}
+
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.POST, "/replay/"+id, nil), rec)
+	assert.Equal(t, http.StatusCreated, rec.Status())
+	assert.Equal(t, `acme:{"qty":1}`, rec.Body.String())
+	assert.Len(t, bodies, 3)
+
+	// The store is bounded
+	order()
+	order()
+	assert.Nil(t, store.Load(id))
+
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.POST, "/replay/"+id, nil), rec)
+	assert.Equal(t, http.StatusNotFound, rec.Status())
+}
+
+func TestRequestReplayHeadersAndLimit(t *testing.T) {
+	e := leego.New()
+	e.SetDebug(true)
+	store := NewMemoryReplayStore(2)
+	e.Use(RequestReplayWithConfig(RequestReplayConfig{Store: store, MaxBodySize: 4}))
+	e.POST("/", func(c leego.Context) leego.LeegoError {
+		b, _ := ioutil.ReadAll(c.Request().Body())
+		return c.String(http.StatusOK, strings.Join(c.Request().Header().Values("X-Tag"), ",")+":"+string(b))
+	})
+	e.POST("/replay/:id", ReplayHandler(store))
+	post := func(body string) string {
+		req := test.NewRequest(leego.POST, "/", strings.NewReader(body))
+		req.Header().Add("X-Tag", "a")
+		req.Header().Add("X-Tag", "b")
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec.Header().Get(HeaderXReplayID)
+	}
+
+	// Repeated headers are replayed
+	id := post("abcd")
+	if r := store.Load(id); assert.NotNil(t, r) {
+		assert.Equal(t, []string{"a", "b"}, r.Header["X-Tag"])
+		assert.False(t, r.BodyTruncated)
+	}
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.POST, "/replay/"+id, nil), rec)
+	assert.Equal(t, "a,b:abcd", rec.Body.String())
+
+	// A larger body is cut, while the handler still reads it whole
+	req := test.NewRequest(leego.POST, "/", strings.NewReader("abcdef"))
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, ":abcdef", rec.Body.String())
+	id = rec.Header().Get(HeaderXReplayID)
+	if r := store.Load(id); assert.NotNil(t, r) {
+		assert.Equal(t, "abcd", string(r.Body))
+		assert.True(t, r.BodyTruncated)
+	}
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.POST, "/replay/"+id, nil), rec)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Status())
+}
+
+func TestMemoryReplayStore(t *testing.T) {
+	s := NewMemoryReplayStore(2)
+	s.Save(&RecordedRequest{ID: "a"})
+	s.Save(&RecordedRequest{ID: "b", URI: "/1"})
+	s.Save(&RecordedRequest{ID: "b", URI: "/2"})
+	if assert.NotNil(t, s.Load("b")) {
+		assert.Equal(t, "/2", s.Load("b").URI)
+	}
+	assert.NotNil(t, s.Load("a"))
+
+	// The oldest is evicted, once
+	s.Save(&RecordedRequest{ID: "c"})
+	assert.Nil(t, s.Load("a"))
+	assert.NotNil(t, s.Load("b"))
+	assert.NotNil(t, s.Load("c"))
+
+	assert.Panics(t, func() { NewMemoryReplayStore(0) })
+}
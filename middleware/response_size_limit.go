@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// ResponseSizeLimitConfig defines the config for ResponseSizeLimit middleware.
+	ResponseSizeLimitConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Limit is the maximum number of body bytes a handler can write. Required.
+		Limit int64
+	}
+
+	limitedWriter struct {
+		io.Writer
+		remaining int64
+		exceeded  bool
+	}
+)
+
+var (
+	// DefaultResponseSizeLimitConfig is the default ResponseSizeLimit middleware
+	// config.
+	DefaultResponseSizeLimitConfig = ResponseSizeLimitConfig{
+		Skipper: defaultSkipper,
+	}
+
+	// ErrResponseTooLarge is returned by writes to a response exceeding the
+	// limit of the ResponseSizeLimit middleware.
+	ErrResponseTooLarge = errors.New("response size limit exceeded")
+)
+
+// ResponseSizeLimit returns a middleware which caps the size of the response
+// body at max bytes, protecting against handlers writing unbounded data.
+//
+// On overflow the body is truncated at the limit and further writes fail with
+// `ErrResponseTooLarge`. As the header is already sent by then, the response is
+// treated as an aborted stream: the error is logged and the connection is
+// closed, so the client can tell the response is incomplete.
+func ResponseSizeLimit(max int64) leego.MiddlewareFunc {
+	c := DefaultResponseSizeLimitConfig
+	c.Limit = max
+	return ResponseSizeLimitWithConfig(c)
+}
+
+// ResponseSizeLimitWithConfig returns a ResponseSizeLimit middleware from
+// config.
+// See `ResponseSizeLimit()`.
+func ResponseSizeLimitWithConfig(config ResponseSizeLimitConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Limit <= 0 {
+		panic("leego: response size limit middleware requires a positive limit")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultResponseSizeLimitConfig.Skipper
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			res := c.Response()
+			w := res.Writer()
+			lw := &limitedWriter{Writer: w, remaining: config.Limit}
+			res.SetWriter(lw)
+			defer res.SetWriter(w)
+
+			err := next(c)
+			if lw.exceeded {
+				c.SetStreaming(true)
+				return ErrResponseTooLarge
+			}
+			return err
+		}
+	}
+}
+
+func (w *limitedWriter) Write(b []byte) (n int, err error) {
+	if w.exceeded {
+		return 0, ErrResponseTooLarge
+	}
+	if int64(len(b)) > w.remaining {
+		n, err = w.Writer.Write(b[:w.remaining])
+		w.remaining -= int64(n)
+		w.exceeded = true
+		if err == nil {
+			err = ErrResponseTooLarge
+		}
+		return
+	}
+	n, err = w.Writer.Write(b)
+	w.remaining -= int64(n)
+	return
+}
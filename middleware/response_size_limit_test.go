@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseSizeLimit(t *testing.T) {
+	e := leego.New()
+	var writeErr error
+	h := ResponseSizeLimit(10)(func(c leego.Context) leego.LeegoError {
+		c.Response().WriteHeader(http.StatusOK)
+		for i := 0; i < 3; i++ {
+			if _, writeErr = c.Response().Write([]byte("hello")); writeErr != nil {
+				break
+			}
+		}
+		return nil
+	})
+
+	rec := test.NewResponseRecorder()
+	c := e.NewContext(test.NewRequest(leego.GET, "/", nil), rec)
+	assert.Equal(t, ErrResponseTooLarge, h(c))
+	assert.Equal(t, ErrResponseTooLarge, writeErr)
+	assert.Equal(t, "hellohello", rec.Body.String())
+	// Handled as an aborted stream
+	assert.True(t, c.Streaming())
+
+	// Within the limit
+	h = ResponseSizeLimit(10)(func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, strings.Repeat("a", 10))
+	})
+	rec = test.NewResponseRecorder()
+	assert.NoError(t, h(e.NewContext(test.NewRequest(leego.GET, "/", nil), rec)))
+	assert.Equal(t, 10, rec.Body.Len())
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"hash/fnv"
+	"math/rand"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// SampleConfig defines the config for Sample middleware.
+	SampleConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Rate is the fraction, between 0 and 1, of the requests processed by
+		// the sampled middleware.
+		Rate float64
+
+		// Middleware is the sampled middleware, e.g. detailed logging or tracing.
+		// Required.
+		Middleware leego.MiddlewareFunc
+
+		// KeyExtractor returns the key requests are sampled by, e.g. a request ID,
+		// so the same key is always sampled the same way.
+		// Optional. Default value nil, which samples requests at random.
+		KeyExtractor func(leego.Context) string
+
+		// ContextKey is the key the sampling decision is stored under in the
+		// context, as a bool. A decision already stored under the key is reused.
+		// Optional. Default value "sampled".
+		ContextKey string
+	}
+)
+
+var (
+	// DefaultSampleConfig is the default Sample middleware config.
+	DefaultSampleConfig = SampleConfig{
+		Skipper:    defaultSkipper,
+		ContextKey: "sampled",
+	}
+)
+
+// Sample returns a middleware which applies the sampled middleware to the given
+// fraction of requests only, chosen at random, so costly observability can run on
+// a share of the traffic.
+func Sample(rate float64, sampled leego.MiddlewareFunc) leego.MiddlewareFunc {
+	c := DefaultSampleConfig
+	c.Rate = rate
+	c.Middleware = sampled
+	return SampleWithConfig(c)
+}
+
+// SampleWithConfig returns a Sample middleware from config.
+// See `Sample()`.
+func SampleWithConfig(config SampleConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultSampleConfig.Skipper
+	}
+	if config.ContextKey == "" {
+		config.ContextKey = DefaultSampleConfig.ContextKey
+	}
+	if config.Middleware == nil {
+		panic("leego: sample middleware requires a sampled middleware")
+	}
+	if config.Rate < 0 || config.Rate > 1 {
+		panic("leego: sample middleware requires a rate between 0 and 1")
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		sampledNext := config.Middleware(next)
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			sampled, ok := c.Get(config.ContextKey).(bool)
+			if !ok {
+				if config.KeyExtractor != nil {
+					sampled = sampleKey(config.KeyExtractor(c)) < config.Rate
+				} else {
+					sampled = rand.Float64() < config.Rate
+				}
+				c.Set(config.ContextKey, sampled)
+			}
+			if sampled {
+				return sampledNext(c)
+			}
+			return next(c)
+		}
+	}
+}
+
+// sampleKey deterministically maps key to a number in [0, 1).
+func sampleKey(key string) float64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+	// Mix the bits, as FNV spreads short keys poorly over the high bits
+	x := h.Sum64()
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	return float64(x>>11) / (1 << 53)
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSample(t *testing.T) {
+	e := leego.New()
+	traced := 0
+	trace := func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			traced++
+			return next(c)
+		}
+	}
+	h := Sample(0.1, trace)(func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, "test")
+	})
+
+	n := 10000
+	for i := 0; i < n; i++ {
+		h(e.NewContext(test.NewRequest(leego.GET, "/", nil), test.NewResponseRecorder()))
+	}
+	assert.InDelta(t, 0.1, float64(traced)/float64(n), 0.02)
+
+	// The decision is stable within a request
+	traced = 0
+	h = Sample(0.5, trace)(Sample(0.5, trace)(func(c leego.Context) leego.LeegoError {
+		return nil
+	}))
+	for i := 0; i < 100; i++ {
+		h(e.NewContext(test.NewRequest(leego.GET, "/", nil), test.NewResponseRecorder()))
+	}
+	assert.Equal(t, 0, traced%2)
+}
+
+func TestSampleKeyExtractor(t *testing.T) {
+	e := leego.New()
+	sampled := map[string]bool{}
+	h := SampleWithConfig(SampleConfig{
+		Rate: 0.25,
+		Middleware: func(next leego.HandlerFunc) leego.HandlerFunc {
+			return func(c leego.Context) leego.LeegoError {
+				sampled[c.Request().Header().Get("X-Request-ID")] = true
+				return next(c)
+			}
+		},
+		KeyExtractor: func(c leego.Context) string {
+			return c.Request().Header().Get("X-Request-ID")
+		},
+	})(func(c leego.Context) leego.LeegoError {
+		return nil
+	})
+
+	serve := func(id string) {
+		req := test.NewRequest(leego.GET, "/", nil)
+		req.Header().Set("X-Request-ID", id)
+		h(e.NewContext(req, test.NewResponseRecorder()))
+	}
+	n := 4000
+	for i := 0; i < n; i++ {
+		serve(strconv.Itoa(i))
+	}
+	assert.InDelta(t, 0.25, float64(len(sampled))/float64(n), 0.03)
+
+	// Deterministic by key
+	before := len(sampled)
+	for i := 0; i < n; i++ {
+		serve(strconv.Itoa(i))
+	}
+	assert.Equal(t, before, len(sampled))
+}
@@ -0,0 +1,177 @@
+package middleware
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// SizeMetricsConfig defines the config for SizeMetrics middleware.
+	SizeMetricsConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Observer records the sizes. Required.
+		Observer SizeObserver
+	}
+
+	// SizeObserver is the interface that wraps the ObserveSizes method.
+	//
+	// ObserveSizes records the request and response body sizes of a request to
+	// the route pattern, e.g. `/users/:id`. The request size is -1 if unknown,
+	// e.g. for a chunked body. It allows integration with external metrics
+	// systems.
+	SizeObserver interface {
+		ObserveSizes(route string, request, response int64)
+	}
+
+	// SizeHistograms is a `SizeObserver` which keeps request and response size
+	// histograms per route in memory.
+	SizeHistograms struct {
+		buckets []int64
+		mu      sync.Mutex
+		routes  map[string]*routeSizes
+	}
+
+	// SizeHistogram is a snapshot of a size histogram.
+	SizeHistogram struct {
+		// Buckets are the upper bounds, inclusive, of the buckets.
+		Buckets []int64
+
+		// Counts are the numbers of observations in each bucket, not cumulative,
+		// followed by the number of observations over the last bucket.
+		Counts []uint64
+
+		// Count is the total number of observations.
+		Count uint64
+
+		// Sum is the sum of the observed sizes.
+		Sum int64
+	}
+
+	routeSizes struct {
+		request, response SizeHistogram
+	}
+)
+
+var (
+	// DefaultSizeMetricsConfig is the default SizeMetrics middleware config.
+	DefaultSizeMetricsConfig = SizeMetricsConfig{
+		Skipper: defaultSkipper,
+	}
+
+	// DefaultSizeBuckets are the default buckets of `SizeHistograms`, from 256 B
+	// to 16 MB.
+	DefaultSizeBuckets = []int64{256, 1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20}
+)
+
+// SizeMetrics returns a middleware which records the request and response body
+// sizes per route pattern with observer, to identify bandwidth-heavy endpoints.
+// The request size is its content length and the response size the number of
+// body bytes written.
+func SizeMetrics(observer SizeObserver) leego.MiddlewareFunc {
+	c := DefaultSizeMetricsConfig
+	c.Observer = observer
+	return SizeMetricsWithConfig(c)
+}
+
+// SizeMetricsWithConfig returns a SizeMetrics middleware from config.
+// See `SizeMetrics()`.
+func SizeMetricsWithConfig(config SizeMetricsConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Observer == nil {
+		panic("leego: size metrics middleware requires an observer")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultSizeMetricsConfig.Skipper
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			err := next(c)
+			config.Observer.ObserveSizes(c.Path(), c.Request().ContentLength(), c.Response().Size())
+			return err
+		}
+	}
+}
+
+// NewSizeHistograms returns a `SizeHistograms` with the given bucket upper
+// bounds, or `DefaultSizeBuckets` if none.
+func NewSizeHistograms(buckets ...int64) *SizeHistograms {
+	if len(buckets) == 0 {
+		buckets = DefaultSizeBuckets
+	}
+	b := make([]int64, len(buckets))
+	copy(b, buckets)
+	sort.Slice(b, func(i, j int) bool { return b[i] < b[j] })
+	return &SizeHistograms{
+		buckets: b,
+		routes:  make(map[string]*routeSizes),
+	}
+}
+
+// ObserveSizes implements `SizeObserver#ObserveSizes` function. Unknown request
+// sizes aren't recorded.
+func (h *SizeHistograms) ObserveSizes(route string, request, response int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.routes[route]
+	if !ok {
+		r = &routeSizes{
+			request:  SizeHistogram{Buckets: h.buckets, Counts: make([]uint64, len(h.buckets)+1)},
+			response: SizeHistogram{Buckets: h.buckets, Counts: make([]uint64, len(h.buckets)+1)},
+		}
+		h.routes[route] = r
+	}
+	if request >= 0 {
+		r.request.observe(request)
+	}
+	r.response.observe(response)
+}
+
+// Request returns a snapshot of the request size histogram of route.
+func (h *SizeHistograms) Request(route string) SizeHistogram {
+	return h.snapshot(route, func(r *routeSizes) SizeHistogram { return r.request })
+}
+
+// Response returns a snapshot of the response size histogram of route.
+func (h *SizeHistograms) Response(route string) SizeHistogram {
+	return h.snapshot(route, func(r *routeSizes) SizeHistogram { return r.response })
+}
+
+// Routes returns the observed routes, sorted.
+func (h *SizeHistograms) Routes() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	routes := make([]string, 0, len(h.routes))
+	for route := range h.routes {
+		routes = append(routes, route)
+	}
+	sort.Strings(routes)
+	return routes
+}
+
+func (h *SizeHistograms) snapshot(route string, get func(*routeSizes) SizeHistogram) SizeHistogram {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	r, ok := h.routes[route]
+	if !ok {
+		return SizeHistogram{Buckets: h.buckets, Counts: make([]uint64, len(h.buckets)+1)}
+	}
+	s := get(r)
+	s.Counts = append([]uint64(nil), s.Counts...)
+	return s
+}
+
+func (s *SizeHistogram) observe(size int64) {
+	i := sort.Search(len(s.Buckets), func(i int) bool { return size <= s.Buckets[i] })
+	s.Counts[i]++
+	s.Count++
+	s.Sum += size
+}
@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSizeMetrics(t *testing.T) {
+	e := leego.New()
+	h := NewSizeHistograms(100, 1000)
+	e.Use(SizeMetrics(h))
+	e.POST("/users/:id", func(c leego.Context) leego.LeegoError {
+		b, _ := ioutil.ReadAll(c.Request().Body())
+		return c.String(http.StatusOK, strings.Repeat("x", 2*len(b)))
+	})
+
+	for _, path := range []string{"/users/1", "/users/2"} {
+		req := test.NewRequest(leego.POST, path, strings.NewReader(strings.Repeat("a", 500)))
+		e.ServeHTTP(req, test.NewResponseRecorder())
+	}
+
+	assert.Equal(t, []string{"/users/:id"}, h.Routes())
+	req := h.Request("/users/:id")
+	assert.Equal(t, []int64{100, 1000}, req.Buckets)
+	assert.Equal(t, []uint64{0, 2, 0}, req.Counts)
+	assert.EqualValues(t, 2, req.Count)
+	assert.EqualValues(t, 1000, req.Sum)
+	res := h.Response("/users/:id")
+	assert.Equal(t, []uint64{0, 2, 0}, res.Counts)
+	assert.EqualValues(t, 2000, res.Sum)
+
+	// Unobserved
+	assert.Zero(t, h.Response("/").Count)
+}
@@ -26,7 +26,9 @@ var (
 // AddTrailingSlash returns a root level (before router) middleware which adds a
 // trailing slash to the request `URL#Path`.
 //
-// Usage `Leego#Pre(AddTrailingSlash())`
+// Usage `Leego#Pre(AddTrailingSlash())`, along with
+// `Leego#SetSlashPolicy(leego.SlashPolicyAdd)` so generated URIs aren't
+// redirected.
 func AddTrailingSlash() leego.MiddlewareFunc {
 	return AddTrailingSlashWithConfig(TrailingSlashConfig{})
 }
@@ -73,7 +75,9 @@ func AddTrailingSlashWithConfig(config TrailingSlashConfig) leego.MiddlewareFunc
 // RemoveTrailingSlash returns a root level (before router) middleware which removes
 // a trailing slash from the request URI.
 //
-// Usage `Leego#Pre(RemoveTrailingSlash())`
+// Usage `Leego#Pre(RemoveTrailingSlash())`, along with
+// `Leego#SetSlashPolicy(leego.SlashPolicyRemove)` so generated URIs aren't
+// redirected.
 func RemoveTrailingSlash() leego.MiddlewareFunc {
 	return RemoveTrailingSlashWithConfig(TrailingSlashConfig{})
 }
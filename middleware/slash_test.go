@@ -4,17 +4,17 @@ import (
 	"net/http"
 	"testing"
 
-	"github.com/labstack/echo"
-	"github.com/labstack/echo/test"
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestAddTrailingSlash(t *testing.T) {
-	e := echo.New()
-	req := test.NewRequest(echo.GET, "/add-slash", nil)
+	e := leego.New()
+	req := test.NewRequest(leego.GET, "/add-slash", nil)
 	rec := test.NewResponseRecorder()
 	c := e.NewContext(req, rec)
-	h := AddTrailingSlash()(func(c echo.Context) error {
+	h := AddTrailingSlash()(func(c leego.Context) leego.LeegoError {
 		return nil
 	})
 	h(c)
@@ -22,25 +22,25 @@ func TestAddTrailingSlash(t *testing.T) {
 	assert.Equal(t, "/add-slash/", req.URI())
 
 	// With config
-	req = test.NewRequest(echo.GET, "/add-slash?key=value", nil)
+	req = test.NewRequest(leego.GET, "/add-slash?key=value", nil)
 	rec = test.NewResponseRecorder()
 	c = e.NewContext(req, rec)
 	h = AddTrailingSlashWithConfig(TrailingSlashConfig{
 		RedirectCode: http.StatusMovedPermanently,
-	})(func(c echo.Context) error {
+	})(func(c leego.Context) leego.LeegoError {
 		return nil
 	})
 	h(c)
 	assert.Equal(t, http.StatusMovedPermanently, rec.Status())
-	assert.Equal(t, "/add-slash/?key=value", rec.Header().Get(echo.HeaderLocation))
+	assert.Equal(t, "/add-slash/?key=value", rec.Header().Get(leego.HeaderLocation))
 }
 
 func TestRemoveTrailingSlash(t *testing.T) {
-	e := echo.New()
-	req := test.NewRequest(echo.GET, "/remove-slash/", nil)
+	e := leego.New()
+	req := test.NewRequest(leego.GET, "/remove-slash/", nil)
 	rec := test.NewResponseRecorder()
 	c := e.NewContext(req, rec)
-	h := RemoveTrailingSlash()(func(c echo.Context) error {
+	h := RemoveTrailingSlash()(func(c leego.Context) leego.LeegoError {
 		return nil
 	})
 	h(c)
@@ -48,23 +48,23 @@ func TestRemoveTrailingSlash(t *testing.T) {
 	assert.Equal(t, "/remove-slash", req.URI())
 
 	// With config
-	req = test.NewRequest(echo.GET, "/remove-slash/?key=value", nil)
+	req = test.NewRequest(leego.GET, "/remove-slash/?key=value", nil)
 	rec = test.NewResponseRecorder()
 	c = e.NewContext(req, rec)
 	h = RemoveTrailingSlashWithConfig(TrailingSlashConfig{
 		RedirectCode: http.StatusMovedPermanently,
-	})(func(c echo.Context) error {
+	})(func(c leego.Context) leego.LeegoError {
 		return nil
 	})
 	h(c)
 	assert.Equal(t, http.StatusMovedPermanently, rec.Status())
-	assert.Equal(t, "/remove-slash?key=value", rec.Header().Get(echo.HeaderLocation))
+	assert.Equal(t, "/remove-slash?key=value", rec.Header().Get(leego.HeaderLocation))
 
 	// With bare URL
-	req = test.NewRequest(echo.GET, "http://localhost", nil)
+	req = test.NewRequest(leego.GET, "http://localhost", nil)
 	rec = test.NewResponseRecorder()
 	c = e.NewContext(req, rec)
-	h = RemoveTrailingSlash()(func(c echo.Context) error {
+	h = RemoveTrailingSlash()(func(c leego.Context) leego.LeegoError {
 		return nil
 	})
 	h(c)
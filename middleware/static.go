@@ -0,0 +1,180 @@
+package middleware
+
+import (
+	"html/template"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// StaticConfig defines the config for Static middleware.
+	StaticConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Root is the directory the static content is served from.
+		// Optional. Default value ".".
+		Root string `json:"root"`
+
+		// Index is the file served for a directory.
+		// Optional. Default value "index.html".
+		Index string `json:"index"`
+
+		// Browse enables the directory listing of directories without an index
+		// file.
+		// Optional. Default value false.
+		Browse bool `json:"browse"`
+
+		// HTML5 serves the root index file for the paths matching neither a file
+		// nor a route, so the client side routing of single-page applications
+		// works.
+		// Optional. Default value false.
+		HTML5 bool `json:"html5"`
+
+		// SPAFallback is like HTML5 but for the paths without an extension only,
+		// so the requests for missing assets, e.g. `/missing.js`, still get 404.
+		// Optional. Default value false.
+		SPAFallback bool `json:"spa_fallback"`
+	}
+)
+
+var (
+	// DefaultStaticConfig is the default Static middleware config.
+	DefaultStaticConfig = StaticConfig{
+		Skipper: defaultSkipper,
+		Root:    ".",
+		Index:   "index.html",
+	}
+
+	browseTemplate = template.Must(template.New("browse").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>{{.Path}}</title></head>
+<body>
+<h1>{{.Path}}</h1>
+<ul>
+{{range .Files}}<li><a href="{{.Href}}">{{.Name}}</a></li>
+{{end}}</ul>
+</body>
+</html>
+`))
+)
+
+// Static returns a middleware which serves the static files of the root
+// directory, with their content type and `Last-Modified` header, answering 304
+// to a matching `If-Modified-Since`. Pre-compressed variants are served like
+// with `Context#File()`. Requests for files which don't exist are passed on to
+// the next handler.
+func Static(root string) leego.MiddlewareFunc {
+	c := DefaultStaticConfig
+	c.Root = root
+	return StaticWithConfig(c)
+}
+
+// StaticWithConfig returns a Static middleware from config.
+// See `Static()`.
+func StaticWithConfig(config StaticConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultStaticConfig.Skipper
+	}
+	if config.Root == "" {
+		config.Root = DefaultStaticConfig.Root
+	}
+	if config.Index == "" {
+		config.Index = DefaultStaticConfig.Index
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+			if m := c.Request().Method(); m != leego.GET && m != leego.HEAD {
+				return next(c)
+			}
+
+			p := c.Request().URL().Path()
+			if strings.IndexByte(c.Path(), '*') >= 0 {
+				// When serving from a catch-all route, e.g. `/static/*`
+				p = c.P(len(c.ParamNames()) - 1)
+			}
+			p, err := url.PathUnescape(p)
+			if err != nil {
+				return next(c)
+			}
+			// Cleaned as an absolute path, so it can't escape the root
+			name := filepath.Join(config.Root, filepath.FromSlash(path.Clean("/"+p)))
+
+			fi, err := os.Stat(name)
+			if err != nil {
+				fallback := config.HTML5 || config.SPAFallback && path.Ext(p) == ""
+				if !fallback || !os.IsNotExist(err) {
+					return next(c)
+				}
+				// Only for the paths no route serves either
+				err := next(c)
+				if he, ok := err.(*leego.HTTPError); !ok || he.Code != http.StatusNotFound || c.Response().Committed() {
+					return err
+				}
+				return c.File(filepath.Join(config.Root, config.Index))
+			}
+			if !fi.IsDir() {
+				return c.File(name)
+			}
+
+			index := filepath.Join(name, config.Index)
+			if _, err := os.Stat(index); err == nil {
+				return c.File(index)
+			}
+			if config.Browse {
+				return listDir(c, name, p)
+			}
+			return next(c)
+		}
+	}
+}
+
+// listDir renders the listing of the directory name, requested as p.
+func listDir(c leego.Context, name, p string) error {
+	d, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	fis, err := d.Readdir(-1)
+	if err != nil {
+		return err
+	}
+	sort.Slice(fis, func(i, j int) bool { return fis[i].Name() < fis[j].Name() })
+	if !strings.HasSuffix(p, "/") {
+		p += "/"
+	}
+	// Absolute links, as the directory may be requested without trailing slash
+	base := c.Request().URL().Path()
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	type file struct{ Name, Href string }
+	files := make([]file, 0, len(fis))
+	for _, fi := range fis {
+		n := fi.Name()
+		if fi.IsDir() {
+			n += "/"
+		}
+		files = append(files, file{n, (&url.URL{Path: base + n}).EscapedPath()})
+	}
+	res := c.Response()
+	res.Header().Set(leego.HeaderContentType, leego.MIMETextHTMLCharsetUTF8)
+	res.WriteHeader(http.StatusOK)
+	return browseTemplate.Execute(res, struct {
+		Path  string
+		Files []file
+	}{p, files})
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "static")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	root := filepath.Join(dir, "public")
+	assert.NoError(t, os.MkdirAll(filepath.Join(root, "docs"), 0755))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "index.html"), []byte("<h1>index</h1>"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "app.css"), []byte("body{}"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "docs", "b.txt"), []byte("b"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(root, "docs", "a.txt"), []byte("a"), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "secret.txt"), []byte("secret"), 0644))
+
+	newLeego := func(config StaticConfig) *leego.Leego {
+		e := leego.New()
+		config.Root = root
+		e.Use(StaticWithConfig(config))
+		e.GET("/api", func(c leego.Context) leego.LeegoError {
+			return c.String(http.StatusOK, "api")
+		})
+		return e
+	}
+	get := func(e *leego.Leego, path string, header ...string) *test.ResponseRecorder {
+		req := test.NewRequest(leego.GET, path, nil)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header().Set(header[i], header[i+1])
+		}
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec
+	}
+
+	e := newLeego(StaticConfig{})
+	rec := get(e, "/app.css")
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "body{}", rec.Body.String())
+	assert.Contains(t, rec.Header().Get(leego.HeaderContentType), "text/css")
+	lastModified := rec.Header().Get(leego.HeaderLastModified)
+	assert.NotEmpty(t, lastModified)
+
+	// Not modified
+	rec = get(e, "/app.css", leego.HeaderIfModifiedSince, lastModified)
+	assert.Equal(t, http.StatusNotModified, rec.Status())
+	rec = get(e, "/app.css", leego.HeaderIfModifiedSince, time.Unix(0, 0).UTC().Format(http.TimeFormat))
+	assert.Equal(t, http.StatusOK, rec.Status())
+
+	// Index
+	rec = get(e, "/")
+	assert.Equal(t, "<h1>index</h1>", rec.Body.String())
+
+	// Fall through
+	assert.Equal(t, "api", get(e, "/api").Body.String())
+	assert.Equal(t, http.StatusNotFound, get(e, "/missing.js").Status())
+	assert.Equal(t, http.StatusNotFound, get(e, "/docs").Status())
+
+	// Path traversal
+	for _, p := range []string{"/../secret.txt", "/docs/../../secret.txt", "/%2e%2e/secret.txt"} {
+		assert.Equal(t, http.StatusNotFound, get(e, p).Status(), p)
+	}
+
+	// Browse
+	e = newLeego(StaticConfig{Browse: true})
+	rec = get(e, "/docs")
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, leego.MIMETextHTMLCharsetUTF8, rec.Header().Get(leego.HeaderContentType))
+	body := rec.Body.String()
+	assert.Contains(t, body, `<a href="/docs/a.txt">a.txt</a>`)
+	assert.True(t, strings.Index(body, "a.txt") < strings.Index(body, "b.txt"))
+	// The same links with a trailing slash
+	assert.Contains(t, get(e, "/docs/").Body.String(), `<a href="/docs/a.txt">a.txt</a>`)
+
+	// HTML5
+	e = newLeego(StaticConfig{HTML5: true})
+	rec = get(e, "/users/1")
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "<h1>index</h1>", rec.Body.String())
+	assert.Equal(t, "api", get(e, "/api").Body.String())
+	assert.Equal(t, "<h1>index</h1>", get(e, "/missing.js").Body.String())
+
+	// SPA fallback
+	e = newLeego(StaticConfig{SPAFallback: true})
+	rec = get(e, "/some/route")
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, "<h1>index</h1>", rec.Body.String())
+	assert.Equal(t, http.StatusNotFound, get(e, "/missing.js").Status())
+	assert.Equal(t, http.StatusNotFound, get(e, "/js/missing.min.js").Status())
+	assert.Equal(t, "api", get(e, "/api").Body.String())
+	assert.Equal(t, "body{}", get(e, "/app.css").Body.String())
+}
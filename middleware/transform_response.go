@@ -0,0 +1,155 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// TransformResponseConfig defines the config for TransformResponse
+	// middleware.
+	TransformResponseConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Transform rewrites the response body. Required.
+		Transform func(body []byte, c leego.Context) ([]byte, error)
+
+		// MaxSize is the maximum number of body bytes buffered for the transform.
+		// Larger responses are sent unchanged.
+		// Optional. Default value 1 MB.
+		MaxSize int64
+	}
+
+	// transformWriter buffers the response body until the handler completes,
+	// unless it grows over max bytes or is flushed. The header is only sent once
+	// the body is written through.
+	transformWriter struct {
+		io.Writer
+		buf         bytes.Buffer
+		max         int64
+		passthrough bool
+	}
+)
+
+var (
+	// DefaultTransformResponseConfig is the default TransformResponse middleware
+	// config.
+	DefaultTransformResponseConfig = TransformResponseConfig{
+		Skipper: defaultSkipper,
+		MaxSize: 1 << 20,
+	}
+)
+
+// TransformResponse returns a middleware which buffers the response body and
+// lets fn rewrite it before it's sent, e.g. to minify HTML or rewrite asset URLs.
+//
+// As the header is only sent along with the transformed body, fn may still
+// change it, e.g. to add a CSP nonce. An error from fn replaces the response with
+// an error one, with the status of a `*leego.HTTPError` or 500.
+//
+// Responses which are empty, larger than the maximum size or streamed, i.e.
+// flushed or marked with `Context#SetStreaming()`, are sent unchanged.
+func TransformResponse(fn func(body []byte, c leego.Context) ([]byte, error)) leego.MiddlewareFunc {
+	c := DefaultTransformResponseConfig
+	c.Transform = fn
+	return TransformResponseWithConfig(c)
+}
+
+// TransformResponseWithConfig returns a TransformResponse middleware from
+// config.
+// See `TransformResponse()`.
+func TransformResponseWithConfig(config TransformResponseConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Transform == nil {
+		panic("leego: transform response middleware requires a transform function")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultTransformResponseConfig.Skipper
+	}
+	if config.MaxSize == 0 {
+		config.MaxSize = DefaultTransformResponseConfig.MaxSize
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			res := c.Response()
+			var tw *transformWriter
+			// Installed when the response is committed, so it wraps the writers of
+			// outer middleware, e.g. Gzip, and sees the plain body
+			res.Before(func() {
+				tw = &transformWriter{Writer: res.Writer(), max: config.MaxSize}
+				res.Header().Del(leego.HeaderContentLength)
+				res.SetWriter(tw)
+			})
+
+			err := next(c)
+			if tw == nil || tw.passthrough {
+				return err
+			}
+			// Writes after the transform, if any, go straight through
+			tw.passthrough = true
+			body := tw.buf.Bytes()
+			if len(body) > 0 && !c.Streaming() {
+				var terr error
+				if body, terr = config.Transform(body, c); terr != nil {
+					// Not sent yet, so it can still be replaced
+					code := http.StatusInternalServerError
+					if he, ok := terr.(*leego.HTTPError); ok {
+						code = he.Code
+					}
+					res.SetStatus(code)
+					res.Header().Set(leego.HeaderContentType, leego.MIMETextPlainCharsetUTF8)
+					tw.Writer.Write([]byte(http.StatusText(code)))
+					return terr
+				}
+			}
+			if _, werr := tw.Writer.Write(body); werr != nil && err == nil {
+				err = werr
+			}
+			return err
+		}
+	}
+}
+
+func (w *transformWriter) Write(b []byte) (int, error) {
+	if w.passthrough {
+		return w.Writer.Write(b)
+	}
+	if int64(w.buf.Len()+len(b)) > w.max {
+		if err := w.release(); err != nil {
+			return 0, err
+		}
+		return w.Writer.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+// Flush sends the body buffered so far unchanged, as the response is streamed.
+func (w *transformWriter) Flush() error {
+	if err := w.release(); err != nil {
+		return err
+	}
+	if bf, ok := w.Writer.(interface{ Flush() error }); ok {
+		return bf.Flush()
+	}
+	return nil
+}
+
+// release switches the writer to pass through, writing the buffered body.
+func (w *transformWriter) release() error {
+	if w.passthrough {
+		return nil
+	}
+	w.passthrough = true
+	_, err := w.Writer.Write(w.buf.Bytes())
+	w.buf.Reset()
+	return err
+}
@@ -0,0 +1,117 @@
+package middleware
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/engine/standard"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransformResponse(t *testing.T) {
+	e := leego.New()
+	upper := TransformResponseWithConfig(TransformResponseConfig{
+		Transform: func(body []byte, c leego.Context) ([]byte, error) {
+			return bytes.ToUpper(body), nil
+		},
+		MaxSize: 64,
+	})
+	e.GET("/json", func(c leego.Context) leego.LeegoError {
+		c.Response().Header().Set(leego.HeaderContentLength, "17")
+		return c.JSON(http.StatusOK, map[string]string{"name": "jon"})
+	}, upper)
+	e.GET("/large", func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, strings.Repeat("a", 100))
+	}, upper)
+	e.GET("/stream", func(c leego.Context) leego.LeegoError {
+		c.SetStreaming(true)
+		return c.String(http.StatusOK, "stream")
+	}, upper)
+
+	req := test.NewRequest(leego.GET, "/json", nil)
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, `{"NAME":"JON"}`, rec.Body.String())
+	assert.Empty(t, rec.Header().Get(leego.HeaderContentLength))
+
+	// Over the maximum size
+	req = test.NewRequest(leego.GET, "/large", nil)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, strings.Repeat("a", 100), rec.Body.String())
+
+	// Streaming
+	req = test.NewRequest(leego.GET, "/stream", nil)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, "stream", rec.Body.String())
+}
+
+func TestTransformResponseHeader(t *testing.T) {
+	e := leego.New()
+	e.Use(TransformResponse(func(body []byte, c leego.Context) ([]byte, error) {
+		c.Response().Header().Set(leego.HeaderContentSecurityPolicy, "script-src 'nonce-abc'")
+		return bytes.Replace(body, []byte("{{nonce}}"), []byte("abc"), -1), nil
+	}))
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		return c.HTML(http.StatusOK, `<script nonce="{{nonce}}"></script>`)
+	})
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.GET, "/", nil), rec)
+	assert.Equal(t, `<script nonce="abc"></script>`, rec.Body.String())
+	assert.Equal(t, "script-src 'nonce-abc'", rec.Header().Get(leego.HeaderContentSecurityPolicy))
+
+	// Over the wire, the header isn't sent before the transform
+	s := standard.New("")
+	s.SetHandler(e)
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	res, err := http.Get(ts.URL)
+	if assert.NoError(t, err) {
+		b, _ := ioutil.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, `<script nonce="abc"></script>`, string(b))
+		assert.Equal(t, "script-src 'nonce-abc'", res.Header.Get(leego.HeaderContentSecurityPolicy))
+	}
+}
+
+func TestTransformResponseError(t *testing.T) {
+	e := leego.New()
+	errTransform := errors.New("transform failed")
+	var hookErr leego.LeegoError
+	e.UseOnError(func(err leego.LeegoError, c leego.Context) leego.LeegoError {
+		hookErr = err
+		return err
+	})
+	e.Use(TransformResponse(func(body []byte, c leego.Context) ([]byte, error) {
+		if c.Path() == "/teapot" {
+			return nil, leego.NewHTTPError(http.StatusTeapot)
+		}
+		return nil, errTransform
+	}))
+	e.GET("/", func(c leego.Context) leego.LeegoError {
+		return c.JSON(http.StatusOK, "test")
+	})
+	e.GET("/teapot", func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, "test")
+	})
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.GET, "/", nil), rec)
+	assert.Equal(t, http.StatusInternalServerError, rec.Status())
+	assert.Equal(t, leego.MIMETextPlainCharsetUTF8, rec.Header().Get(leego.HeaderContentType))
+	assert.Equal(t, http.StatusText(http.StatusInternalServerError), rec.Body.String())
+	assert.Equal(t, errTransform, hookErr)
+
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(leego.GET, "/teapot", nil), rec)
+	assert.Equal(t, http.StatusTeapot, rec.Status())
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"regexp"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// UAConfig defines the config for UserAgentFilter middleware.
+	UAConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Allow lists regular expressions of user agents which are always let
+		// through, even if they match Deny or Scrapers.
+		// Optional.
+		Allow []string
+
+		// Deny lists regular expressions of user agents which are rejected with 403.
+		// Optional.
+		Deny []string
+
+		// Scrapers lists regular expressions of user agents which get an empty 200
+		// response, so they don't retry or learn they are blocked.
+		// Optional.
+		Scrapers []string
+	}
+)
+
+var (
+	// DefaultUAConfig is the default UserAgentFilter middleware config.
+	DefaultUAConfig = UAConfig{
+		Skipper: defaultSkipper,
+	}
+)
+
+// UserAgentFilter returns a middleware which filters requests by their
+// `User-Agent` header for basic bot mitigation.
+//
+// The filtering is best-effort only: the header is set by the client and is
+// trivially spoofed, so it must not be relied upon for access control.
+func UserAgentFilter(config UAConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Skipper == nil {
+		config.Skipper = DefaultUAConfig.Skipper
+	}
+
+	// Initialize
+	allow := compilePatterns(config.Allow)
+	deny := compilePatterns(config.Deny)
+	scrapers := compilePatterns(config.Scrapers)
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			ua := c.Request().Header().Get(leego.HeaderUserAgent)
+			if matchAny(allow, ua) {
+				return next(c)
+			}
+			if matchAny(scrapers, ua) {
+				c.NoContent(http.StatusOK)
+				return leego.ErrAborted
+			}
+			if matchAny(deny, ua) {
+				return leego.NewHTTPError(http.StatusForbidden)
+			}
+			return next(c)
+		}
+	}
+}
+
+func compilePatterns(patterns []string) []*regexp.Regexp {
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		res[i] = regexp.MustCompile(p)
+	}
+	return res
+}
+
+func matchAny(res []*regexp.Regexp, s string) bool {
+	for _, re := range res {
+		if re.MatchString(s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserAgentFilter(t *testing.T) {
+	e := leego.New()
+	h := UserAgentFilter(UAConfig{
+		Allow:    []string{`(?i)googlebot`},
+		Deny:     []string{`(?i)bot`, `^$`},
+		Scrapers: []string{`(?i)^scrapy`},
+	})(func(c leego.Context) leego.LeegoError {
+		return c.String(http.StatusOK, "test")
+	})
+	serve := func(ua string) (leego.LeegoError, *test.ResponseRecorder) {
+		req := test.NewRequest(leego.GET, "/", nil)
+		req.Header().Set(leego.HeaderUserAgent, ua)
+		rec := test.NewResponseRecorder()
+		return h(e.NewContext(req, rec)), rec
+	}
+
+	// Passthrough
+	err, rec := serve("Mozilla/5.0")
+	if assert.NoError(t, err) {
+		assert.Equal(t, "test", rec.Body.String())
+	}
+
+	// Blocked
+	err, _ = serve("EvilBot/1.0")
+	if he, ok := err.(*leego.HTTPError); assert.True(t, ok) {
+		assert.Equal(t, http.StatusForbidden, he.Code)
+	}
+
+	// Allow wins over Deny
+	err, _ = serve("Mozilla/5.0 (compatible; Googlebot/2.1)")
+	assert.NoError(t, err)
+
+	// Scrapers get an empty response
+	err, rec = serve("Scrapy/2.5")
+	assert.Equal(t, leego.ErrAborted, err)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Empty(t, rec.Body.String())
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/go-wyvern/leego"
+)
+
+type (
+	// VersionedETagConfig defines the config for VersionedETag middleware.
+	VersionedETagConfig struct {
+		// Skipper defines a function to skip middleware.
+		Skipper Skipper
+
+		// Version returns the version of the requested resource, e.g. a hash of
+		// the `updated_at` of a database row. An empty version disables the
+		// middleware for the request. Required.
+		Version func(leego.Context) string
+
+		// Weak marks the ETag as weak, i.e. semantically but not byte-for-byte
+		// equivalent responses share it.
+		// Optional. Default value false.
+		Weak bool
+	}
+)
+
+var (
+	// DefaultVersionedETagConfig is the default VersionedETag middleware config.
+	DefaultVersionedETagConfig = VersionedETagConfig{
+		Skipper: defaultSkipper,
+	}
+)
+
+// VersionedETag returns a middleware which sets the `ETag` response header from
+// a version provided by versionFn instead of hashing the response body. A GET or
+// HEAD request whose `If-None-Match` matches the ETag gets 304 without calling
+// the handler, which saves the expensive work of e.g. loading the resource.
+func VersionedETag(versionFn func(leego.Context) string) leego.MiddlewareFunc {
+	c := DefaultVersionedETagConfig
+	c.Version = versionFn
+	return VersionedETagWithConfig(c)
+}
+
+// VersionedETagWithConfig returns a VersionedETag middleware from config.
+// See `VersionedETag()`.
+func VersionedETagWithConfig(config VersionedETagConfig) leego.MiddlewareFunc {
+	// Defaults
+	if config.Version == nil {
+		panic("leego: versioned etag middleware requires a version function")
+	}
+	if config.Skipper == nil {
+		config.Skipper = DefaultVersionedETagConfig.Skipper
+	}
+
+	return func(next leego.HandlerFunc) leego.HandlerFunc {
+		return func(c leego.Context) leego.LeegoError {
+			if config.Skipper(c) {
+				return next(c)
+			}
+
+			v := config.Version(c)
+			if v == "" {
+				return next(c)
+			}
+			etag := `"` + v + `"`
+			if config.Weak {
+				etag = "W/" + etag
+			}
+			c.Response().Header().Set(leego.HeaderETag, etag)
+
+			req := c.Request()
+			if m := req.Method(); (m == leego.GET || m == leego.HEAD) &&
+				etagMatch(req.Header().Get(leego.HeaderIfNoneMatch), etag) {
+				c.NoContent(http.StatusNotModified)
+				return leego.ErrAborted
+			}
+			return next(c)
+		}
+	}
+}
+
+// etagMatch returns true if the `If-None-Match` header value matches etag,
+// using the weak comparison.
+func etagMatch(header, etag string) bool {
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if t == "*" || strings.TrimPrefix(t, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
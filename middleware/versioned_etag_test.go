@@ -0,0 +1,48 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego"
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersionedETag(t *testing.T) {
+	e := leego.New()
+	called := 0
+	h := VersionedETag(func(c leego.Context) string {
+		return "v42"
+	})(func(c leego.Context) leego.LeegoError {
+		called++
+		return c.String(http.StatusOK, "resource")
+	})
+	serve := func(inm string) (leego.LeegoError, *test.ResponseRecorder) {
+		req := test.NewRequest(leego.GET, "/", nil)
+		if inm != "" {
+			req.Header().Set(leego.HeaderIfNoneMatch, inm)
+		}
+		rec := test.NewResponseRecorder()
+		return h(e.NewContext(req, rec)), rec
+	}
+
+	err, rec := serve("")
+	if assert.NoError(t, err) {
+		assert.Equal(t, 1, called)
+		assert.Equal(t, `"v42"`, rec.Header().Get(leego.HeaderETag))
+		assert.Equal(t, "resource", rec.Body.String())
+	}
+
+	// Match skips the handler
+	err, rec = serve(`"v41", W/"v42"`)
+	assert.Equal(t, leego.ErrAborted, err)
+	assert.Equal(t, 1, called)
+	assert.Equal(t, http.StatusNotModified, rec.Status())
+	assert.Empty(t, rec.Body.String())
+
+	// Stale version
+	err, rec = serve(`"v41"`)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, called)
+}
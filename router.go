@@ -1,12 +1,32 @@
 package leego
 
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
 type (
 	// Router is the registry of all registered routes for an `Echo` instance for
 	// request matching and URL path parameter parsing.
 	Router struct {
 		tree   *node
-		routes map[string]Route
-		leego  *Leego
+		routes map[string]*Route
+		// names indexes the routes by handler name for reverse routing. A handler
+		// registered for several routes maps to the first one.
+		names map[string]*Route
+		// meta holds the metadata of the routes keyed like routes.
+		meta  map[string]map[string]interface{}
+		leego *Leego
+		// drained holds the drained routes keyed like routes, as a
+		// `map[string]bool` replaced on write, so `Find()` reads it without
+		// locking. drainMu serializes the writes.
+		drained atomic.Value
+		drainMu sync.Mutex
 	}
 	node struct {
 		kind          kind
@@ -45,17 +65,81 @@ func NewRouter(lee *Leego) *Router {
 		tree: &node{
 			methodHandler: new(methodHandler),
 		},
-		routes: make(map[string]Route),
-		leego:   lee,
+		routes: make(map[string]*Route),
+		names:  make(map[string]*Route),
+		meta:   make(map[string]map[string]interface{}),
+		leego:  lee,
 	}
 }
 
-// Add registers a new route for method and path with matching handler.
-func (r *Router) Add(method, path string, h HandlerFunc, lee *Leego) {
-	// Validate path
-	if path == "" {
-		//e.logger.Fatal("path cannot be empty")
+// MustCompilePath validates the route pattern and returns it, so it can be
+// used inline when registering routes. It panics with a message describing the
+// problem if the pattern is malformed. `Router#Add()` validates the patterns
+// the same way.
+func MustCompilePath(pattern string) string {
+	if err := validatePath(pattern); err != nil {
+		panic(err.Error())
+	}
+	return pattern
+}
+
+// validatePath returns an error if the route pattern is malformed, i.e. it's
+// empty, has a param without a name or with a name containing a character
+// which is not allowed, e.g. an attempted regex constraint, has duplicate param
+// names, or has a `*` which is not in the last segment, where it may be
+// followed by a name, e.g. `/files/*filepath`.
+func validatePath(pattern string) error {
+	if pattern == "" {
+		return errors.New("leego: route path can't be empty")
+	}
+	seen := map[string]bool{}
+	for i, l := 0, len(pattern); i < l; i++ {
+		switch pattern[i] {
+		case ':':
+			j := i + 1
+			for i = j; i < l && pattern[i] != '/'; i++ {
+				if c := pattern[i]; !isParamNameChar(c) {
+					return fmt.Errorf("leego: invalid route path %q: invalid character %q in param name at offset %d", pattern, c, i)
+				}
+			}
+			name := pattern[j:i]
+			if name == "" {
+				return fmt.Errorf("leego: invalid route path %q: missing param name at offset %d", pattern, j-1)
+			}
+			if seen[name] {
+				return fmt.Errorf("leego: invalid route path %q: duplicate param name %q", pattern, name)
+			}
+			seen[name] = true
+		case '*':
+			name := pattern[i+1:]
+			if strings.IndexByte(name, '/') >= 0 {
+				return fmt.Errorf("leego: invalid route path %q: `*` must be at the end", pattern)
+			}
+			for j := 0; j < len(name); j++ {
+				if c := name[j]; !isParamNameChar(c) {
+					return fmt.Errorf("leego: invalid route path %q: invalid character %q in param name at offset %d", pattern, c, i+1+j)
+				}
+			}
+			if seen[name] {
+				return fmt.Errorf("leego: invalid route path %q: duplicate param name %q", pattern, name)
+			}
+			return nil
+		}
 	}
+	return nil
+}
+
+func isParamNameChar(c byte) bool {
+	return c == '_' || c == '-' || c == '.' ||
+		'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// Add registers a new route for method and path with matching handler. Path
+// params are declared like `:id` and a final catch-all, capturing the rest of
+// the path including slashes, like `*filepath`, or `*` for a param named "_*".
+// It panics if the path is malformed, see `MustCompilePath()`.
+func (r *Router) Add(method, path string, h HandlerFunc, lee *Leego) {
+	MustCompilePath(path)
 	if path[0] != '/' {
 		path = "/" + path
 	}
@@ -81,8 +165,13 @@ func (r *Router) Add(method, path string, h HandlerFunc, lee *Leego) {
 			r.insert(method, path[:i], nil, pkind, ppath, pnames, lee)
 		} else if path[i] == '*' {
 			r.insert(method, path[:i], nil, skind, "", nil, lee)
-			pnames = append(pnames, "_*")
-			r.insert(method, path[:i + 1], h, akind, ppath, pnames, lee)
+			// A bare catch-all `*` keeps its "_*" name
+			name := path[i+1:]
+			if name == "" {
+				name = "_*"
+			}
+			pnames = append(pnames, name)
+			r.insert(method, path[:i+1], h, akind, ppath, pnames, lee)
 			return
 		}
 	}
@@ -269,6 +358,15 @@ func (n *node) findHandler(method string) HandlerFunc {
 	}
 }
 
+func (n *node) allowedMethods() (allowed []string) {
+	for _, m := range methods {
+		if h := n.findHandler(m); h != nil {
+			allowed = append(allowed, m)
+		}
+	}
+	return
+}
+
 func (n *node) checkMethodNotAllowed() HandlerFunc {
 	for _, m := range methods {
 		if h := n.findHandler(m); h != nil {
@@ -286,24 +384,138 @@ func (n *node) checkMethodNotAllowed() HandlerFunc {
 // - Get context from `Echo#AcquireContext()`
 // - Reset it `Context#Reset()`
 // - Return it `Echo#ReleaseContext()`.
+//
+// Consecutive slashes in path are treated as one, e.g. `/users//1` matches
+// `/users/:id`.
 func (r *Router) Find(method, path string, context Context) {
+	path = collapseSlashes(path)
+	pvalues := context.ParamValues()
+	pmap := make(map[string]string)
+
+	cn := r.lookup(path, pvalues)
+	if cn == nil {
+		// Not found
+		return
+	}
+
+	context.SetHandler(cn.findHandler(method))
+	context.SetPath(cn.ppath)
+	context.SetParamNames(cn.pnames...)
+
+	// NOTE: Slow zone...
+	if context.Handler() == nil {
+		context.SetHandler(cn.checkMethodNotAllowed())
+
+		// Dig further for any, might have an empty value for *, e.g.
+		// serving a directory. Issue #207.
+		if cn = cn.findChildByKind(akind); cn == nil {
+			return
+		}
+		if h := cn.findHandler(method); h != nil {
+			context.SetHandler(h)
+		} else {
+			context.SetHandler(cn.checkMethodNotAllowed())
+		}
+		context.SetPath(cn.ppath)
+		context.SetParamNames(cn.pnames...)
+		pvalues[len(cn.pnames)-1] = ""
+	}
+
+	if r.isDrained(method, cn.ppath) {
+		context.SetHandler(drainedHandler)
+	}
+
+	for i, name := range cn.pnames {
+		pmap[name] = pvalues[i]
+	}
+	context.SetParamsMap(pmap)
+	return
+}
+
+// drainRetryAfter is the `Retry-After` header value, in seconds, of the
+// responses of drained routes.
+const drainRetryAfter = "60"
+
+func drainedHandler(c Context) LeegoError {
+	c.Response().Header().Set(HeaderRetryAfter, drainRetryAfter)
+	return NewHTTPError(http.StatusServiceUnavailable)
+}
+
+// setDrained drains or undrains the route for method and path.
+func (r *Router) setDrained(method, path string, drained bool) {
+	if path == "" || path[0] != '/' {
+		path = "/" + path
+	}
+	r.drainMu.Lock()
+	defer r.drainMu.Unlock()
+	old, _ := r.drained.Load().(map[string]bool)
+	m := make(map[string]bool, len(old)+1)
+	for k := range old {
+		m[k] = true
+	}
+	if drained {
+		m[method+path] = true
+	} else {
+		delete(m, method+path)
+	}
+	r.drained.Store(m)
+}
+
+// isDrained returns true if the route for method and path is drained.
+func (r *Router) isDrained(method, path string) bool {
+	m, _ := r.drained.Load().(map[string]bool)
+	return m[method+path]
+}
+
+// Routes returns copies of the registered routes, sorted by path then method,
+// e.g. to generate documentation.
+func (r *Router) Routes() []Route {
+	routes := make([]Route, 0, len(r.routes))
+	for _, route := range r.routes {
+		routes = append(routes, *route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// AllowedMethods returns the HTTP methods which have a handler registered for
+// path, in the order of `methods`. It returns nil if no route matches path.
+func (r *Router) AllowedMethods(path string) (allowed []string) {
+	cn := r.lookup(collapseSlashes(path), make([]string, *r.leego.maxParam))
+	if cn == nil {
+		return
+	}
+	if allowed = cn.allowedMethods(); allowed == nil {
+		if cn = cn.findChildByKind(akind); cn != nil {
+			allowed = cn.allowedMethods()
+		}
+	}
+	return
+}
+
+// lookup walks the tree for path, loading path parameter values into pvalues.
+// It returns the matched node or nil if not found.
+func (r *Router) lookup(path string, pvalues []string) *node {
 	cn := r.tree // Current node as root
 
 	var (
 		search = path
-		c       *node  // Child node
-		n int    // Param counter
-		nk kind   // Next kind
-		nn      *node  // Next node
-		ns string // Next search
-		pmap  =make(map[string]string)
-		pvalues = context.ParamValues()
+		c      *node  // Child node
+		n      int    // Param counter
+		nk     kind   // Next kind
+		nn     *node  // Next node
+		ns     string // Next search
 	)
 
 	// Search order static > param > any
 	for {
 		if search == "" {
-			goto End
+			return cn
 		}
 
 		pl := 0 // Prefix length
@@ -334,11 +546,11 @@ func (r *Router) Find(method, path string, context Context) {
 				goto Any
 			}
 			// Not found
-			return
+			return nil
 		}
 
 		if search == "" {
-			goto End
+			return cn
 		}
 
 		// Static node
@@ -354,7 +566,7 @@ func (r *Router) Find(method, path string, context Context) {
 		}
 
 		// Param node
-		Param:
+	Param:
 		if c = cn.findChildByKind(pkind); c != nil {
 			// Issue #378
 			if len(pvalues) == n {
@@ -379,7 +591,7 @@ func (r *Router) Find(method, path string, context Context) {
 		}
 
 		// Any node
-		Any:
+	Any:
 		if cn = cn.findChildByKind(akind); cn == nil {
 			if nn != nil {
 				cn = nn
@@ -392,40 +604,25 @@ func (r *Router) Find(method, path string, context Context) {
 				}
 			}
 			// Not found
-			return
+			return nil
 		}
-		pvalues[len(cn.pnames) - 1] = search
-		goto End
+		pvalues[len(cn.pnames)-1] = search
+		return cn
 	}
+}
 
-	End:
-	context.SetHandler(cn.findHandler(method))
-	context.SetPath(cn.ppath)
-	context.SetParamNames(cn.pnames...)
-
-
-	// NOTE: Slow zone...
-	if context.Handler() == nil {
-		context.SetHandler(cn.checkMethodNotAllowed())
-
-		// Dig further for any, might have an empty value for *, e.g.
-		// serving a directory. Issue #207.
-		if cn = cn.findChildByKind(akind); cn == nil {
-			return
-		}
-		if h := cn.findHandler(method); h != nil {
-			context.SetHandler(h)
-		} else {
-			context.SetHandler(cn.checkMethodNotAllowed())
-		}
-		context.SetPath(cn.ppath)
-		context.SetParamNames(cn.pnames...)
-		pvalues[len(cn.pnames) - 1] = ""
+// collapseSlashes replaces runs of consecutive slashes in path with a single
+// slash. It only allocates if path contains such a run.
+func collapseSlashes(path string) string {
+	if !strings.Contains(path, "//") {
+		return path
 	}
-
-	for i, name := range cn.pnames {
-		pmap[name]=pvalues[i]
+	b := make([]byte, 0, len(path))
+	for i := 0; i < len(path); i++ {
+		if path[i] == '/' && i > 0 && path[i-1] == '/' {
+			continue
+		}
+		b = append(b, path[i])
 	}
-	context.SetParamsMap(pmap)
-	return
+	return string(b)
 }
@@ -0,0 +1,199 @@
+package leego
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouterMatchedPath(t *testing.T) {
+	e := New()
+	path := ""
+	e.GET("/users/:id", func(c Context) LeegoError {
+		path = c.Path()
+		return nil
+	})
+	c := e.NewContext(test.NewRequest(GET, "/users/1", nil), test.NewResponseRecorder())
+	e.router.Find(GET, "/users/1", c)
+	assert.Equal(t, "/users/:id", c.Path())
+	assert.Equal(t, "1", c.Param("id"))
+
+	e.ServeHTTP(test.NewRequest(GET, "/users/42", nil), test.NewResponseRecorder())
+	assert.Equal(t, "/users/:id", path)
+
+	// Unmatched requests don't inherit the pattern of a previous request
+	c.Reset(test.NewRequest(GET, "/nope", nil), test.NewResponseRecorder())
+	e.router.Find(GET, "/nope", c)
+	assert.Equal(t, "", c.Path())
+}
+
+func TestRouterAllowedMethods(t *testing.T) {
+	e := New()
+	h := func(c Context) LeegoError { return nil }
+	e.GET("/users", h)
+	e.POST("/users", h)
+	e.GET("/users/:id", h)
+	e.GET("/static/*", h)
+
+	assert.Equal(t, []string{GET, POST}, e.router.AllowedMethods("/users"))
+	assert.Equal(t, []string{GET}, e.router.AllowedMethods("/users/1"))
+	assert.Equal(t, []string{GET}, e.router.AllowedMethods("/static/"))
+	assert.Equal(t, []string{GET}, e.router.AllowedMethods("/static/js/app.js"))
+	assert.Nil(t, e.router.AllowedMethods("/nope"))
+}
+
+func TestRouterMethodNotAllowed(t *testing.T) {
+	e := New()
+	h := func(c Context) LeegoError { return nil }
+	e.GET("/users/:id", h)
+	e.PUT("/users/:id", h)
+	e.DELETE("/users/:id", h)
+
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(test.NewRequest(POST, "/users/1", nil), rec)
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Status())
+	assert.Equal(t, "DELETE, GET, PUT", rec.Header().Get(HeaderAllow))
+}
+
+func TestRouterDuplicateSlashes(t *testing.T) {
+	e := New()
+	h := func(c Context) LeegoError { return nil }
+	e.GET("/", h)
+	e.GET("/users/:id", h)
+	e.GET("/static/*", h)
+
+	cases := []struct {
+		path    string
+		pattern string
+		param   string
+	}{
+		{"/users//123", "/users/:id", "123"},
+		{"//users/123", "/users/:id", "123"},
+		{"//", "/", ""},
+		{"/users/123//", "", ""},
+		{"/static//js//app.js", "/static/*", "js/app.js"},
+	}
+	for _, tc := range cases {
+		c := e.NewContext(test.NewRequest(GET, tc.path, nil), test.NewResponseRecorder())
+		e.router.Find(GET, tc.path, c)
+		assert.Equal(t, tc.pattern, c.Path(), tc.path)
+		assert.Equal(t, tc.param, c.P(0), tc.path)
+	}
+}
+
+func TestRouterCatchAll(t *testing.T) {
+	e := New()
+	h := func(c Context) LeegoError { return nil }
+	e.GET("/files/*", h)
+	e.GET("/users/:id/files/*filepath", h)
+	assert.Equal(t, 2, *e.maxParam)
+
+	c := e.NewContext(test.NewRequest(GET, "/files/a/b/c.txt", nil), test.NewResponseRecorder())
+	e.router.Find(GET, "/files/a/b/c.txt", c)
+	assert.Equal(t, "/files/*", c.Path())
+	assert.Equal(t, "a/b/c.txt", c.Param("_*"))
+
+	c = e.NewContext(test.NewRequest(GET, "/users/1/files/a/b/c.txt", nil), test.NewResponseRecorder())
+	e.router.Find(GET, "/users/1/files/a/b/c.txt", c)
+	assert.Equal(t, "/users/:id/files/*filepath", c.Path())
+	assert.Equal(t, "1", c.Param("id"))
+	assert.Equal(t, "a/b/c.txt", c.Param("filepath"))
+	assert.Equal(t, map[string]string{"id": "1", "filepath": "a/b/c.txt"}, c.Params())
+
+	// Empty remainder
+	c = e.NewContext(test.NewRequest(GET, "/users/1/files/", nil), test.NewResponseRecorder())
+	e.router.Find(GET, "/users/1/files/", c)
+	assert.Equal(t, "/users/:id/files/*filepath", c.Path())
+	assert.Equal(t, "", c.Param("filepath"))
+}
+
+func TestRouterMustCompilePath(t *testing.T) {
+	assert.Equal(t, "/users/:id/files/*", MustCompilePath("/users/:id/files/*"))
+	assert.Equal(t, "/users/:id/files/*path", MustCompilePath("/users/:id/files/*path"))
+
+	for _, p := range []string{
+		"",
+		"/users/:",
+		"/users/:/files",
+		"/users/:id/:id",
+		"/users/:id{[0-9]+}",
+		"/users/:id{[0-9]+/files",
+		"/static/*/index.html",
+		"/static/*path/index.html",
+		"/static/*pa*th",
+		"/:path/*path",
+	} {
+		assert.Panics(t, func() { MustCompilePath(p) }, p)
+	}
+
+	// Validated on registration
+	e := New()
+	assert.Panics(t, func() {
+		e.GET("/users/:", func(c Context) LeegoError { return nil })
+	})
+}
+
+func TestRouterRouteMeta(t *testing.T) {
+	e := New()
+	e.Use(func(next HandlerFunc) HandlerFunc {
+		return func(c Context) LeegoError {
+			if c.RouteMeta()["requireAuth"] == true && c.Request().Header().Get(HeaderAuthorization) == "" {
+				return ErrUnauthorized
+			}
+			return next(c)
+		}
+	})
+	h := func(c Context) LeegoError {
+		return c.String(http.StatusOK, "ok")
+	}
+	e.GET("/admin", h).SetMeta("requireAuth", true)
+	e.Group("/api").GET("/users/:id", h).SetMeta("requireAuth", true)
+	e.GET("/public", h)
+
+	serve := func(path, auth string) int {
+		req := test.NewRequest(GET, path, nil)
+		if auth != "" {
+			req.Header().Set(HeaderAuthorization, auth)
+		}
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec.Status()
+	}
+	assert.Equal(t, http.StatusUnauthorized, serve("/admin", ""))
+	assert.Equal(t, http.StatusOK, serve("/admin", "Bearer token"))
+	assert.Equal(t, http.StatusUnauthorized, serve("/api/users/1", ""))
+	assert.Equal(t, http.StatusOK, serve("/public", ""))
+	assert.Equal(t, http.StatusNotFound, serve("/nope", ""))
+}
+
+func TestRouterRoutes(t *testing.T) {
+	e := New()
+	h := func(c Context) LeegoError { return nil }
+	e.POST("/users", h)
+	e.GET("/users/:id", h)
+	e.GET("/users", h)
+	e.DELETE("/users/:id", h)
+	e.GET("/", userHandler)
+
+	routes := e.Routes()
+	type methodPath struct{ method, path string }
+	got := make([]methodPath, len(routes))
+	for i, r := range routes {
+		got[i] = methodPath{r.Method, r.Path}
+	}
+	assert.Equal(t, []methodPath{
+		{GET, "/"},
+		{GET, "/users"},
+		{POST, "/users"},
+		{DELETE, "/users/:id"},
+		{GET, "/users/:id"},
+	}, got)
+	assert.Equal(t, "github.com/go-wyvern/leego.userHandler", routes[0].Handler)
+	assert.Equal(t, routes, e.Router().Routes())
+
+	// Copies
+	routes[0].Path = "/changed"
+	assert.Equal(t, "/", e.Routes()[0].Path)
+}
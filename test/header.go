@@ -0,0 +1,58 @@
+package test
+
+import "net/http"
+
+type (
+	// Header implements `engine.Header`.
+	Header struct {
+		header http.Header
+	}
+)
+
+// Add implements `engine.Header#Add` function.
+func (h *Header) Add(key, val string) {
+	h.header.Add(key, val)
+}
+
+// Del implements `engine.Header#Del` function.
+func (h *Header) Del(key string) {
+	h.header.Del(key)
+}
+
+// Get implements `engine.Header#Get` function.
+func (h *Header) Get(key string) string {
+	return h.header.Get(key)
+}
+
+// Set implements `engine.Header#Set` function.
+func (h *Header) Set(key, val string) {
+	h.header.Set(key, val)
+}
+
+// SetValues sets the values of the canonical key to values, used as is, e.g.
+// prebuilt once for many responses, so it doesn't allocate.
+func (h *Header) SetValues(key string, values []string) {
+	h.header[key] = values
+}
+
+// Values implements `engine.Header#Values` function.
+func (h *Header) Values(key string) []string {
+	return h.header.Values(key)
+}
+
+// Keys implements `engine.Header#Keys` function.
+func (h *Header) Keys() (keys []string) {
+	keys = make([]string, len(h.header))
+	i := 0
+	for k := range h.header {
+		keys[i] = k
+		i++
+	}
+	return
+}
+
+// Contains implements `engine.Header#Contains` function.
+func (h *Header) Contains(key string) bool {
+	_, ok := h.header[key]
+	return ok
+}
@@ -0,0 +1,183 @@
+package test
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/go-wyvern/leego/engine"
+)
+
+type (
+	// Request implements `engine.Request`.
+	Request struct {
+		request *http.Request
+		url     engine.URL
+		header  engine.Header
+	}
+)
+
+const (
+	defaultMemory = 64 << 20 // 64 MB
+)
+
+// NewRequest returns `Request` instance.
+func NewRequest(method, url string, body io.Reader) engine.Request {
+	r, _ := http.NewRequest(method, url, body)
+	r.RequestURI = url
+	return &Request{
+		request: r,
+		url:     &URL{url: r.URL},
+		header:  &Header{r.Header},
+	}
+}
+
+// IsTLS implements `engine.Request#TLS` function.
+func (r *Request) IsTLS() bool {
+	return r.request.TLS != nil
+}
+
+// Scheme implements `engine.Request#Scheme` function.
+func (r *Request) Scheme() string {
+	if r.IsTLS() {
+		return "https"
+	}
+	return "http"
+}
+
+// ConnectionState implements `engine.Request#ConnectionState` function.
+func (r *Request) ConnectionState() *tls.ConnectionState {
+	return r.request.TLS
+}
+
+// SetConnectionState sets the TLS connection state of the request, e.g. to
+// mock a verified client certificate.
+func (r *Request) SetConnectionState(cs *tls.ConnectionState) {
+	r.request.TLS = cs
+}
+
+// Host implements `engine.Request#Host` function.
+func (r *Request) Host() string {
+	return r.request.Host
+}
+
+// URL implements `engine.Request#URL` function.
+func (r *Request) URL() engine.URL {
+	return r.url
+}
+
+// Header implements `engine.Request#URL` function.
+func (r *Request) Header() engine.Header {
+	return r.header
+}
+
+// Referer implements `engine.Request#Referer` function.
+func (r *Request) Referer() string {
+	return r.request.Referer()
+}
+
+// ContentLength implements `engine.Request#ContentLength` function.
+func (r *Request) ContentLength() int64 {
+	return r.request.ContentLength
+}
+
+// UserAgent implements `engine.Request#UserAgent` function.
+func (r *Request) UserAgent() string {
+	return r.request.UserAgent()
+}
+
+// RemoteAddress implements `engine.Request#RemoteAddress` function.
+func (r *Request) RemoteAddress() string {
+	return r.request.RemoteAddr
+}
+
+// SetRemoteAddress sets the remote address of the request, e.g. to mock the
+// client of a request without proxy headers.
+func (r *Request) SetRemoteAddress(addr string) {
+	r.request.RemoteAddr = addr
+}
+
+// Method implements `engine.Request#Method` function.
+func (r *Request) Method() string {
+	return r.request.Method
+}
+
+// SetMethod implements `engine.Request#SetMethod` function.
+func (r *Request) SetMethod(method string) {
+	r.request.Method = method
+}
+
+// URI implements `engine.Request#URI` function.
+func (r *Request) URI() string {
+	return r.request.RequestURI
+}
+
+// SetURI implements `engine.Request#SetURI` function.
+func (r *Request) SetURI(uri string) {
+	r.request.RequestURI = uri
+}
+
+// Body implements `engine.Request#Body` function.
+func (r *Request) Body() io.Reader {
+	return r.request.Body
+}
+
+// SetBody implements `engine.Request#SetBody` function.
+func (r *Request) SetBody(reader io.Reader) {
+	r.request.Body = ioutil.NopCloser(reader)
+}
+
+// FormValue implements `engine.Request#FormValue` function.
+func (r *Request) FormValue(name string) string {
+	return r.request.FormValue(name)
+}
+
+// FormParams implements `engine.Request#FormParams` function.
+func (r *Request) FormParams() map[string][]string {
+	if strings.HasPrefix(r.header.Get("Content-Type"), "multipart/form-data") {
+		r.request.ParseMultipartForm(defaultMemory)
+	} else {
+		r.request.ParseForm()
+	}
+	return map[string][]string(r.request.Form)
+}
+
+// FormFile implements `engine.Request#FormFile` function.
+func (r *Request) FormFile(name string) (*multipart.FileHeader, error) {
+	_, fh, err := r.request.FormFile(name)
+	return fh, err
+}
+
+// MultipartForm implements `engine.Request#MultipartForm` function.
+func (r *Request) MultipartForm() (*multipart.Form, error) {
+	err := r.request.ParseMultipartForm(defaultMemory)
+	return r.request.MultipartForm, err
+}
+
+// ParseMultipartForm implements `engine.Request#ParseMultipartForm` function.
+func (r *Request) ParseMultipartForm(maxMemory int64) error {
+	return r.request.ParseMultipartForm(maxMemory)
+}
+
+// Cookie implements `engine.Request#Cookie` function.
+func (r *Request) Cookie(name string) (engine.Cookie, error) {
+	c, err := r.request.Cookie(name)
+	if err != nil {
+		return nil, errors.New("cookie not found")
+	}
+	return &Cookie{c}, nil
+}
+
+// Cookies implements `engine.Request#Cookies` function.
+func (r *Request) Cookies() []engine.Cookie {
+	cs := r.request.Cookies()
+	cookies := make([]engine.Cookie, len(cs))
+	for i, c := range cs {
+		cookies[i] = &Cookie{c}
+	}
+	return cookies
+}
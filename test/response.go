@@ -0,0 +1,139 @@
+package test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/go-wyvern/leego/engine"
+)
+
+type (
+	// Response implements `engine.Response`.
+	Response struct {
+		response  http.ResponseWriter
+		header    engine.Header
+		status    int
+		size      int64
+		committed bool
+		// headerWritten is set once the header is written to the recorder, which
+		// is deferred until the body reaches it.
+		headerWritten bool
+		writer        io.Writer
+		before        []func()
+	}
+
+	// bodyWriter is the last writer of the chain, which writes the header just
+	// before the first bytes of the body reach the recorder.
+	bodyWriter struct {
+		r *Response
+	}
+
+	// ResponseRecorder is an `engine.Response` that records its mutations for
+	// later inspection in tests.
+	ResponseRecorder struct {
+		engine.Response
+		Body *bytes.Buffer
+	}
+)
+
+// NewResponseRecorder returns `ResponseRecorder` instance.
+func NewResponseRecorder() *ResponseRecorder {
+	rec := httptest.NewRecorder()
+	res := &Response{
+		response: rec,
+		header:   &Header{rec.Header()},
+	}
+	res.writer = bodyWriter{res}
+	return &ResponseRecorder{
+		Response: res,
+		Body:     rec.Body,
+	}
+}
+
+// Header implements `engine.Response#Header` function.
+func (r *Response) Header() engine.Header {
+	return r.header
+}
+
+// WriteHeader implements `engine.Response#WriteHeader` function.
+func (r *Response) WriteHeader(code int) {
+	if r.committed {
+		return
+	}
+	r.status = code
+	for _, fn := range r.before {
+		fn()
+	}
+	r.committed = true
+}
+
+// SetStatus implements `engine.Response#SetStatus` function.
+func (r *Response) SetStatus(code int) {
+	if r.headerWritten {
+		return
+	}
+	r.status = code
+}
+
+// Write implements `engine.Response#Write` function.
+func (r *Response) Write(b []byte) (n int, err error) {
+	if !r.committed {
+		r.WriteHeader(http.StatusOK)
+	}
+	n, err = r.writer.Write(b)
+	r.size += int64(n)
+	return
+}
+
+// SetCookie implements `engine.Response#SetCookie` function.
+func (r *Response) SetCookie(c engine.Cookie) {
+	http.SetCookie(r.response, &http.Cookie{
+		Name:     c.Name(),
+		Value:    c.Value(),
+		Path:     c.Path(),
+		Domain:   c.Domain(),
+		Expires:  c.Expires(),
+		Secure:   c.Secure(),
+		HttpOnly: c.HTTPOnly(),
+	})
+}
+
+// Status implements `engine.Response#Status` function.
+func (r *Response) Status() int {
+	return r.status
+}
+
+// Size implements `engine.Response#Size` function.
+func (r *Response) Size() int64 {
+	return r.size
+}
+
+// Committed implements `engine.Response#Committed` function.
+func (r *Response) Committed() bool {
+	return r.committed
+}
+
+// Writer implements `engine.Response#Writer` function.
+func (r *Response) Writer() io.Writer {
+	return r.writer
+}
+
+// SetWriter implements `engine.Response#SetWriter` function.
+func (r *Response) SetWriter(w io.Writer) {
+	r.writer = w
+}
+
+// Before implements `engine.Response#Before` function.
+func (r *Response) Before(fn func()) {
+	r.before = append(r.before, fn)
+}
+
+func (w bodyWriter) Write(b []byte) (int, error) {
+	if !w.r.headerWritten {
+		w.r.headerWritten = true
+		w.r.response.WriteHeader(w.r.status)
+	}
+	return w.r.response.Write(b)
+}
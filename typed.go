@@ -0,0 +1,81 @@
+package leego
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+// Route metadata keys set by `Leego#AddTyped()`.
+const (
+	// MetaRequestType is the route metadata key of the `reflect.Type` of the
+	// request body of a typed handler.
+	MetaRequestType = "requestType"
+
+	// MetaResponseType is the route metadata key of the `reflect.Type` of the
+	// response body of a typed handler.
+	MetaResponseType = "responseType"
+)
+
+var (
+	contextType    = reflect.TypeOf((*Context)(nil)).Elem()
+	leegoErrorType = reflect.TypeOf((*LeegoError)(nil)).Elem()
+	errorType      = reflect.TypeOf((*error)(nil)).Elem()
+)
+
+// AddTyped registers a new route for method and path with a typed handler of
+// signature `func(Context, *Req) (*Resp, LeegoError)`, where the error may also
+// be an `error`. The request is bound into a new `Req`, validated with
+// `Validate()`, and the `Resp` returned is sent as JSON with status
+// 200, or 204 if nil. Binding and validation failures are sent as 400.
+//
+// The `Req` and `Resp` types are recorded as the route metadata
+// `MetaRequestType` and `MetaResponseType`, e.g. for OpenAPI generation.
+// AddTyped panics if handler doesn't have the expected signature.
+func (e *Leego) AddTyped(method, path string, handler interface{}) *Route {
+	reqType, resType, err := typedHandlerTypes(reflect.TypeOf(handler))
+	if err != nil {
+		panic(fmt.Sprintf("leego: invalid typed handler for %s %s: %v", method, path, err))
+	}
+	fn := reflect.ValueOf(handler)
+	h := func(c Context) LeegoError {
+		req := reflect.New(reqType)
+		if err := c.Bind(req.Interface()); err != nil {
+			if he, ok := err.(*HTTPError); ok {
+				return he
+			}
+			return NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		if err := Validate(req.Interface()); err != nil {
+			return NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		out := fn.Call([]reflect.Value{reflect.ValueOf(c), req})
+		if !out[1].IsNil() {
+			return out[1].Interface().(LeegoError)
+		}
+		if out[0].IsNil() {
+			return c.NoContent(http.StatusNoContent)
+		}
+		return c.JSON(http.StatusOK, out[0].Interface())
+	}
+	return e.add(method, path, h).
+		SetMeta(MetaRequestType, reqType).
+		SetMeta(MetaResponseType, resType)
+}
+
+// typedHandlerTypes returns the `Req` and `Resp` types of a typed handler of
+// signature `func(Context, *Req) (*Resp, LeegoError)`, or an error describing
+// the mismatch.
+func typedHandlerTypes(t reflect.Type) (req, res reflect.Type, err error) {
+	if t == nil || t.Kind() != reflect.Func {
+		return nil, nil, fmt.Errorf("got %v, want func(Context, *Req) (*Resp, LeegoError)", t)
+	}
+	if t.NumIn() != 2 || t.In(0) != contextType || t.In(1).Kind() != reflect.Ptr {
+		return nil, nil, fmt.Errorf("got %v, want parameters (Context, *Req)", t)
+	}
+	if t.NumOut() != 2 || t.Out(0).Kind() != reflect.Ptr ||
+		(t.Out(1) != leegoErrorType && t.Out(1) != errorType) {
+		return nil, nil, fmt.Errorf("got %v, want results (*Resp, LeegoError)", t)
+	}
+	return t.In(1).Elem(), t.Out(0).Elem(), nil
+}
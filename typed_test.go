@@ -0,0 +1,97 @@
+package leego
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type createUserRequest struct {
+	Name string `json:"name"`
+}
+
+func (r *createUserRequest) Validate() error {
+	if r.Name == "" {
+		return errors.New("name is required")
+	}
+	return nil
+}
+
+type createUserResponse struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+func TestLeegoAddTyped(t *testing.T) {
+	e := New()
+	r := e.AddTyped(POST, "/users", func(c Context, req *createUserRequest) (*createUserResponse, LeegoError) {
+		if req.Name == "root" {
+			return nil, NewHTTPError(http.StatusForbidden)
+		}
+		if req.Name == "none" {
+			return nil, nil
+		}
+		return &createUserResponse{ID: 1, Name: req.Name}, nil
+	})
+	meta := e.router.meta[r.Method+r.Path]
+	assert.Equal(t, reflect.TypeOf(createUserRequest{}), meta[MetaRequestType])
+	assert.Equal(t, reflect.TypeOf(createUserResponse{}), meta[MetaResponseType])
+
+	post := func(body string) *test.ResponseRecorder {
+		req := test.NewRequest(POST, "/users", strings.NewReader(body))
+		req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+		rec := test.NewResponseRecorder()
+		e.ServeHTTP(req, rec)
+		return rec
+	}
+
+	rec := post(`{"name":"jon"}`)
+	assert.Equal(t, http.StatusOK, rec.Status())
+	assert.Equal(t, `{"id":1,"name":"jon"}`, rec.Body.String())
+
+	rec = post(`{"name":""}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+	assert.Equal(t, "name is required", rec.Body.String())
+
+	rec = post(`{"name":"root"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Status())
+
+	rec = post(`{"name":"none"}`)
+	assert.Equal(t, http.StatusNoContent, rec.Status())
+
+	// Error results
+	e.AddTyped(PUT, "/users", func(c Context, req *createUserRequest) (*createUserResponse, error) {
+		return nil, errors.New("failed")
+	})
+	req := test.NewRequest(PUT, "/users", strings.NewReader(`{"name":"jon"}`))
+	req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusInternalServerError, rec.Status())
+}
+
+func TestLeegoAddTypedInvalid(t *testing.T) {
+	e := New()
+	for _, h := range []interface{}{
+		nil,
+		func(c Context) LeegoError { return nil },
+		func(c Context, req createUserRequest) (*createUserResponse, LeegoError) { return nil, nil },
+		func(req *createUserRequest) (*createUserResponse, LeegoError) { return nil, nil },
+		func(c Context, req *createUserRequest) *createUserResponse { return nil },
+		func(c Context, req *createUserRequest) (createUserResponse, LeegoError) {
+			return createUserResponse{}, nil
+		},
+		func(c Context, req *createUserRequest) (*createUserResponse, string) { return nil, "" },
+	} {
+		assert.Panics(t, func() { e.AddTyped(POST, "/users", h) })
+	}
+	assert.PanicsWithValue(t, "leego: invalid typed handler for POST /users: got <nil>, "+
+		"want func(Context, *Req) (*Resp, LeegoError)", func() {
+		e.AddTyped(POST, "/users", nil)
+	})
+}
@@ -0,0 +1,144 @@
+package leego
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+type (
+	// ValidationError is a validation failure of a value nested in the one
+	// passed to `Validate()`, at the dotted path of its field, e.g.
+	// `address.zipcode` or `items[1].qty`, named after the `json` tags.
+	ValidationError struct {
+		Field string
+		Err   error
+	}
+)
+
+var validatorType = reflect.TypeOf((*Validator)(nil)).Elem()
+
+// Error returns the error message prefixed with the field path.
+func (e *ValidationError) Error() string {
+	if e.Field == "" {
+		return e.Err.Error()
+	}
+	return e.Field + ": " + e.Err.Error()
+}
+
+// Validate validates i, typically a pointer to a bound request, and the values
+// nested in its fields, slices and maps, with those implementing `Validator`.
+// i is validated first, then its fields in order, and the first failure is
+// returned. The failure of a nested value is returned as a `*ValidationError`
+// with the path of its field.
+func Validate(i interface{}) error {
+	v := reflect.ValueOf(i)
+	if !v.IsValid() {
+		return nil
+	}
+	if v.Kind() != reflect.Ptr {
+		// Makes the value addressable, for the pointer receivers
+		p := reflect.New(v.Type())
+		p.Elem().Set(v)
+		v = p
+	}
+	return validateValue(v.Elem(), "")
+}
+
+// validateValue validates the addressable value v at path.
+func validateValue(v reflect.Value, path string) error {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		return validateValue(v.Elem(), path)
+	}
+	if v.CanAddr() && v.Addr().Type().Implements(validatorType) {
+		if err := v.Addr().Interface().(Validator).Validate(); err != nil {
+			return wrapValidationError(path, err)
+		}
+	} else if v.Type().Implements(validatorType) {
+		if err := v.Interface().(Validator).Validate(); err != nil {
+			return wrapValidationError(path, err)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		if isUnmarshaler(v.Type()) {
+			return nil
+		}
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if f.PkgPath != "" && !f.Anonymous {
+				// Unexported
+				continue
+			}
+			name := jsonFieldName(f)
+			if name == "-" {
+				continue
+			}
+			p := path
+			if !f.Anonymous {
+				p = joinFieldPath(path, name)
+			}
+			if err := validateValue(v.Field(i), p); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		// Sorted, so the failure returned is stable
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, k := range keys {
+			// Map values aren't addressable
+			e := reflect.New(v.Type().Elem()).Elem()
+			e.Set(v.MapIndex(k))
+			if err := validateValue(e, fmt.Sprintf("%s[%v]", path, k.Interface())); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// wrapValidationError returns err at path, extending the path of a
+// `*ValidationError` returned by a nested `Validate()`.
+func wrapValidationError(path string, err error) error {
+	if path == "" {
+		return err
+	}
+	if ve, ok := err.(*ValidationError); ok {
+		return &ValidationError{Field: joinFieldPath(path, ve.Field), Err: ve.Err}
+	}
+	return &ValidationError{Field: path, Err: err}
+}
+
+func joinFieldPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	if name == "" || strings.HasPrefix(name, "[") {
+		return path + name
+	}
+	return path + "." + name
+}
+
+// jsonFieldName returns the name of the struct field in JSON.
+func jsonFieldName(f reflect.StructField) string {
+	name := strings.Split(f.Tag.Get("json"), ",")[0]
+	if name == "" {
+		name = f.Name
+	}
+	return name
+}
@@ -0,0 +1,118 @@
+package leego
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/go-wyvern/leego/test"
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	zipcode string
+
+	orderAddress struct {
+		Street  string  `json:"street"`
+		Zipcode zipcode `json:"zipcode"`
+	}
+
+	orderItem struct {
+		SKU string `json:"sku"`
+		Qty int    `json:"qty"`
+	}
+
+	order struct {
+		ID       string             `json:"id"`
+		Address  orderAddress       `json:"address"`
+		Billing  *orderAddress      `json:"billing"`
+		Items    []orderItem        `json:"items"`
+		Gifts    map[string]zipcode `json:"gifts"`
+		internal zipcode
+	}
+)
+
+func (z zipcode) Validate() error {
+	if len(z) != 5 {
+		return errors.New("must have 5 digits")
+	}
+	return nil
+}
+
+func (i *orderItem) Validate() error {
+	if i.Qty < 1 {
+		return errors.New("qty: must be positive")
+	}
+	return nil
+}
+
+func (o *order) Validate() error {
+	if o.ID == "" {
+		return errors.New("id is required")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *order {
+		return &order{
+			ID:      "1",
+			Address: orderAddress{Zipcode: "75001"},
+			Items:   []orderItem{{SKU: "a", Qty: 1}, {SKU: "b", Qty: 2}},
+		}
+	}
+	assert.NoError(t, Validate(valid()))
+	assert.NoError(t, Validate(*valid()))
+	assert.NoError(t, Validate(nil))
+
+	o := valid()
+	o.ID = ""
+	assert.EqualError(t, Validate(o), "id is required")
+
+	o = valid()
+	o.Address.Zipcode = "123"
+	err := Validate(o)
+	assert.EqualError(t, err, "address.zipcode: must have 5 digits")
+	if ve, ok := err.(*ValidationError); assert.True(t, ok) {
+		assert.Equal(t, "address.zipcode", ve.Field)
+	}
+
+	o = valid()
+	o.Billing = &orderAddress{Zipcode: "1"}
+	assert.EqualError(t, Validate(o), "billing.zipcode: must have 5 digits")
+
+	o = valid()
+	o.Items[1].Qty = 0
+	assert.EqualError(t, Validate(o), "items[1]: qty: must be positive")
+
+	o = valid()
+	o.Gifts = map[string]zipcode{"b": "1", "a": "2"}
+	assert.EqualError(t, Validate(o), "gifts[a]: must have 5 digits")
+
+	// Unexported fields aren't validated
+	o = valid()
+	o.internal = "1"
+	assert.NoError(t, Validate(o))
+}
+
+func TestValidateBound(t *testing.T) {
+	e := New()
+	e.AddTyped(POST, "/orders", func(c Context, o *order) (*order, LeegoError) {
+		return o, nil
+	})
+	req := test.NewRequest(POST, "/orders", strings.NewReader(`{"id":"1","address":{"zipcode":"750"}}`))
+	req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	rec := test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+	assert.Equal(t, "address.zipcode: must have 5 digits", rec.Body.String())
+
+	// Type errors
+	req = test.NewRequest(POST, "/orders", strings.NewReader(`{"id":"1","items":[{"qty":"1"}]}`))
+	req.Header().Set(HeaderContentType, MIMEApplicationJSON)
+	rec = test.NewResponseRecorder()
+	e.ServeHTTP(req, rec)
+	assert.Equal(t, http.StatusBadRequest, rec.Status())
+	assert.Contains(t, rec.Body.String(), "qty")
+}